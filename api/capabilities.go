@@ -0,0 +1,43 @@
+package api
+
+// Capabilities declares what an image provider offers the bot
+type Capabilities struct {
+	Name          string
+	NSFW          bool // serves NSFW pictures on request
+	Mixed         bool // can mix SFW and NSFW pictures in one request
+	Animated      bool // serves GIFs
+	Tags          bool // searches by tag
+	Artists       bool // searches by artist
+	Popular       bool // sorts by likes
+	DominantColor bool // filters by dominant color
+}
+
+// Provider is an image source the bot fetches pictures from
+type Provider interface {
+	Capabilities() Capabilities
+	Stats() StatsSnapshot
+}
+
+// Capabilities returns what nekos.moe offers. Its pictures are all stills.
+func (c *Client) Capabilities() Capabilities {
+	return Capabilities{
+		Name:    "Nekos.moe",
+		NSFW:    true,
+		Mixed:   true,
+		Tags:    true,
+		Artists: true,
+		Popular: true,
+	}
+}
+
+// Capabilities returns what waifu.im offers
+func (c *WaifuClient) Capabilities() Capabilities {
+	return Capabilities{
+		Name:          "Waifu.im",
+		NSFW:          true,
+		Mixed:         true,
+		Animated:      true,
+		Tags:          true,
+		DominantColor: true,
+	}
+}
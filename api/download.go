@@ -0,0 +1,100 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxRedirects caps the redirects followed for a single request. Image CDNs
+// redirect once or twice at most; longer chains end on landing pages.
+const maxRedirects = 3
+
+// NotImageError is returned when a download answered with something other
+// than an image, e.g. the HTML landing page an expired CDN link redirects to
+type NotImageError struct {
+	URL         string // final URL after redirects
+	ContentType string
+}
+
+func (e *NotImageError) Error() string {
+	return fmt.Sprintf("%s is not an image (content type %q)", e.URL, e.ContentType)
+}
+
+// ErrTooLarge is returned when an image is bigger than the cap it was
+// downloaded with
+var ErrTooLarge = errors.New("image is larger than the download cap")
+
+// ErrNotReserved is returned when a Reserve refused the memory for an image
+var ErrNotReserved = errors.New("no memory reserved for the image")
+
+// Reserve is called before an image is read with the size it announced, or
+// with the download cap if it announced none. Returning false skips the image.
+type Reserve func(size int) bool
+
+// readImage reads an image body of at most maxBytes, 0 meaning no cap, after
+// reserving its memory with reserve, which may be nil
+func readImage(resp *http.Response, maxBytes int, reserve Reserve) ([]byte, error) {
+	size := maxBytes
+	if resp.ContentLength >= 0 {
+		if maxBytes > 0 && resp.ContentLength > int64(maxBytes) {
+			return nil, ErrTooLarge
+		}
+		size = int(resp.ContentLength)
+	}
+	if reserve != nil && !reserve(size) {
+		return nil, ErrNotReserved
+	}
+
+	if maxBytes <= 0 {
+		return io.ReadAll(resp.Body)
+	}
+	// Read one byte past the cap to tell a body that fits from a longer one
+	data, err := io.ReadAll(io.LimitReader(resp.Body, int64(maxBytes)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxBytes {
+		return nil, ErrTooLarge
+	}
+	return data, nil
+}
+
+// providerErr drops the errors of a download that the bot, not the provider,
+// called off, so they don't count against the provider's stats
+func providerErr(err error) error {
+	if errors.Is(err, ErrTooLarge) || errors.Is(err, ErrNotReserved) {
+		return nil
+	}
+	return err
+}
+
+// newHTTPClient creates the HTTP client shared by the API clients
+func newHTTPClient() *http.Client {
+	return &http.Client{
+		Timeout: 30 * time.Second,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return errors.New("stopped after too many redirects")
+			}
+			return nil
+		},
+	}
+}
+
+// checkImage rejects downloaded data that isn't an image. A missing or generic
+// content type is resolved by sniffing the data.
+func checkImage(resp *http.Response, data []byte) error {
+	contentType := resp.Header.Get("Content-Type")
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil && strings.HasPrefix(mediaType, "image/") {
+		return nil
+	}
+	if sniffed := http.DetectContentType(data); strings.HasPrefix(sniffed, "image/") {
+		return nil
+	}
+	return &NotImageError{URL: resp.Request.URL.String(), ContentType: contentType}
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDownloadWaifuImageReserved(t *testing.T) {
+	png := "\x89PNG\r\n\x1a\n" + strings.Repeat("x", 92) // 100 bytes
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		if r.URL.Path == "/chunked" {
+			// Flushing before writing leaves out the Content-Length
+			w.(http.Flusher).Flush()
+		}
+		w.Write([]byte(png))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		path     string
+		maxBytes int
+		refuse   bool
+		reserved int // size passed to reserve, -1 if it isn't asked
+		err      error
+	}{
+		{"fits", "/sized", 200, false, 100, nil},
+		{"no cap", "/sized", 0, false, 100, nil},
+		{"announced over the cap", "/sized", 50, false, -1, ErrTooLarge},
+		{"unannounced fits", "/chunked", 200, false, 200, nil},
+		{"unannounced over the cap", "/chunked", 50, false, 50, ErrTooLarge},
+		{"no memory", "/sized", 200, true, 100, ErrNotReserved},
+	}
+
+	client := NewWaifuClient("test")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reserved := -1
+			reserve := func(size int) bool {
+				reserved = size
+				return !tt.refuse
+			}
+
+			data, err := client.DownloadWaifuImageReserved(context.Background(), server.URL+tt.path, tt.maxBytes, reserve)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+			if reserved != tt.reserved {
+				t.Errorf("reserved %d bytes, want %d", reserved, tt.reserved)
+			}
+			if err == nil && string(data) != png {
+				t.Errorf("got %d bytes, want the whole image", len(data))
+			}
+		})
+	}
+
+	// Skipped downloads aren't the provider's fault
+	if stats := client.stats.Snapshot(); stats.Successes != stats.Requests {
+		t.Errorf("%d of %d downloads counted as provider failures, want none", stats.Requests-stats.Successes, stats.Requests)
+	}
+}
+
+func TestDownloadWaifuImageRedirects(t *testing.T) {
+	png := "\x89PNG\r\n\x1a\n" + strings.Repeat("x", 92)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/expired.png", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/landing", http.StatusFound)
+	})
+	mux.HandleFunc("/landing", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte("<!DOCTYPE html><html><body>This link has expired</body></html>"))
+	})
+	mux.HandleFunc("/moved.png", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/image.png", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/untyped.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write([]byte(png))
+	})
+	mux.HandleFunc("/image.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte(png))
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewWaifuClient("test")
+
+	t.Run("redirect to HTML", func(t *testing.T) {
+		_, err := client.DownloadWaifuImage(server.URL + "/expired.png")
+		var notImage *NotImageError
+		if !errors.As(err, &notImage) {
+			t.Fatalf("error = %v, want a NotImageError", err)
+		}
+		if !strings.HasSuffix(notImage.URL, "/landing") {
+			t.Errorf("error names %s, want the final URL", notImage.URL)
+		}
+	})
+
+	t.Run("redirect to an image", func(t *testing.T) {
+		if data, err := client.DownloadWaifuImage(server.URL + "/moved.png"); err != nil || string(data) != png {
+			t.Errorf("got %d bytes, %v; want the image", len(data), err)
+		}
+	})
+
+	t.Run("image without an image content type", func(t *testing.T) {
+		if _, err := client.DownloadWaifuImage(server.URL + "/untyped.png"); err != nil {
+			t.Errorf("sniffable image rejected: %v", err)
+		}
+	})
+
+	t.Run("redirect loop", func(t *testing.T) {
+		if _, err := client.DownloadWaifuImage(server.URL + "/loop"); err == nil {
+			t.Error("followed a redirect loop")
+		}
+	})
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// HealthNotifier is told when a provider starts or stops being unreachable
+type HealthNotifier func(message string)
+
+// healthMonitor watches the requests of one provider. Once every request has
+// failed for longer than threshold it notifies once, and once more when a
+// request succeeds again. Unlike the success rate of Stats it measures time,
+// so a burst of failures alone doesn't alert.
+type healthMonitor struct {
+	mutex        sync.Mutex
+	provider     string
+	threshold    time.Duration
+	notify       HealthNotifier
+	failingSince time.Time // first failure of the current streak, zero while healthy
+	alerted      bool
+}
+
+// observe records the outcome of a request made at now and returns the
+// message to send, if the provider's state changed
+func (m *healthMonitor) observe(err error, now time.Time) string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if err == nil {
+		message := ""
+		if m.alerted {
+			message = fmt.Sprintf("✅ %s is reachable again after failing for %s.", m.provider, now.Sub(m.failingSince).Round(time.Second))
+		}
+		m.failingSince, m.alerted = time.Time{}, false
+		return message
+	}
+
+	if m.failingSince.IsZero() {
+		m.failingSince = now
+	}
+	if m.alerted || now.Sub(m.failingSince) < m.threshold {
+		return ""
+	}
+	m.alerted = true
+	return fmt.Sprintf("🚨 %s has been unreachable for %s. Last error: %v", m.provider, now.Sub(m.failingSince).Round(time.Second), err)
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHealthMonitorTransitions(t *testing.T) {
+	m := &healthMonitor{provider: "Waifu.im", threshold: time.Hour}
+	start := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	boom := errors.New("boom")
+
+	steps := []struct {
+		name  string
+		after time.Duration // since start
+		err   error
+		want  string // prefix of the message, empty for none
+	}{
+		{"healthy", 0, nil, ""},
+		{"first failure", time.Minute, boom, ""},
+		{"failing under the threshold", 30 * time.Minute, boom, ""},
+		{"failing past the threshold", 61 * time.Minute, boom, "🚨 Waifu.im has been unreachable for 1h0m0s"},
+		{"still failing", 2 * time.Hour, boom, ""},
+		{"recovered", 3 * time.Hour, nil, "✅ Waifu.im is reachable again after failing for 2h59m0s"},
+		{"healthy again", 4 * time.Hour, nil, ""},
+		{"new streak starts over", 5 * time.Hour, boom, ""},
+		{"short streak ends quietly", 5*time.Hour + 10*time.Minute, nil, ""},
+	}
+
+	for _, step := range steps {
+		got := m.observe(step.err, start.Add(step.after))
+		if (got == "") != (step.want == "") || !strings.HasPrefix(got, step.want) {
+			t.Errorf("%s: message %q, want %q", step.name, got, step.want)
+		}
+	}
+}
+
+func TestMonitorHealthNotifies(t *testing.T) {
+	var failing atomic.Bool
+	failing.Store(true)
+	stubAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"items": []}`))
+	})
+
+	messages := make(chan string, 4)
+	client := NewWaifuClient("test")
+	// Without a threshold the first failure already alerts
+	client.MonitorHealth(0, func(message string) { messages <- message })
+
+	next := func() string {
+		select {
+		case message := <-messages:
+			return message
+		case <-time.After(time.Second):
+			return ""
+		}
+	}
+
+	client.GetWaifuImages(NSFWModeSFW, 1)
+	if got := next(); !strings.HasPrefix(got, "🚨 Waifu.im") {
+		t.Fatalf("got %q, want an alert after the failure", got)
+	}
+	client.GetWaifuImages(NSFWModeSFW, 1)
+
+	failing.Store(false)
+	if _, err := client.GetWaifuImages(NSFWModeSFW, 1); err != nil {
+		t.Fatal(err)
+	}
+	if got := next(); !strings.HasPrefix(got, "✅ Waifu.im") {
+		t.Fatalf("got %q, want a recovery notice after the alert", got)
+	}
+	if len(messages) != 0 {
+		t.Errorf("%d more notifications, want one alert and one recovery", len(messages))
+	}
+}
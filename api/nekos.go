@@ -2,10 +2,12 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"time"
 )
 
@@ -17,6 +19,7 @@ const (
 type Client struct {
 	httpClient *http.Client
 	userAgent  string
+	stats      Stats
 }
 
 // Image represents an image from the API
@@ -47,15 +50,20 @@ type RandomImageResponse struct {
 // New creates a new API client
 func New(userAgent string) *Client {
 	return &Client{
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		userAgent: userAgent,
+		httpClient: newHTTPClient(),
+		userAgent:  userAgent,
 	}
 }
 
 // GetRandomImages fetches random images from the API
 func (c *Client) GetRandomImages(count int, rating string) ([]Image, error) {
+	return c.GetRandomImagesContext(context.Background(), count, rating)
+}
+
+// GetRandomImagesContext is GetRandomImages, cancelled with ctx
+func (c *Client) GetRandomImagesContext(ctx context.Context, count int, rating string) (images []Image, err error) {
+	defer func() { c.stats.record(err) }()
+
 	// Use the correct endpoint: /images/random
 	endpoint := fmt.Sprintf("random/image?count=%d", count)
 
@@ -71,7 +79,7 @@ func (c *Client) GetRandomImages(count int, rating string) ([]Image, error) {
 
 	// Nekos.moe random endpoint doesn't support tags, so we ignore them for random images
 
-	req, err := http.NewRequest(http.MethodGet, baseURL+endpoint, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+endpoint, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -94,15 +102,30 @@ func (c *Client) GetRandomImages(count int, rating string) ([]Image, error) {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return result.Images, nil
+	return firstN(result.Images, count), nil
 }
 
+// DownloadImage downloads the image with the given ID
 func (c *Client) DownloadImage(imageURL string) ([]byte, error) {
+	return c.DownloadImageContext(context.Background(), imageURL)
+}
+
+// DownloadImageContext is DownloadImage, cancelled with ctx
+func (c *Client) DownloadImageContext(ctx context.Context, imageURL string) ([]byte, error) {
+	return c.DownloadImageReserved(ctx, imageURL, 0, nil)
+}
+
+// DownloadImageReserved is DownloadImageContext reading at most maxBytes, 0
+// meaning no cap, with the image's memory taken from reserve first. It returns
+// ErrTooLarge or ErrNotReserved if the image is skipped for either.
+func (c *Client) DownloadImageReserved(ctx context.Context, imageURL string, maxBytes int, reserve Reserve) (data []byte, err error) {
+	defer func() { c.stats.record(providerErr(err)) }()
+
 	// The API returns just the ID, we need to construct the full URL
 	// Format: https://nekos.moe/image/{ID}.jpg
 	fullURL := "https://nekos.moe/image/" + imageURL + ".jpg"
 
-	req, err := http.NewRequest(http.MethodGet, fullURL, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -119,19 +142,30 @@ func (c *Client) DownloadImage(imageURL string) ([]byte, error) {
 		return nil, fmt.Errorf("failed to download image: status %d", resp.StatusCode)
 	}
 
-	data, err := io.ReadAll(resp.Body)
+	data, err = readImage(resp, maxBytes, reserve)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read image data: %w", err)
 	}
 
+	if err := checkImage(resp, data); err != nil {
+		return nil, err
+	}
+
 	return data, nil
 }
 
 // GetImageByID gets a specific image by its ID
 func (c *Client) GetImageByID(id string) (*Image, error) {
+	return c.GetImageByIDContext(context.Background(), id)
+}
+
+// GetImageByIDContext is GetImageByID, cancelled with ctx
+func (c *Client) GetImageByIDContext(ctx context.Context, id string) (result *Image, err error) {
+	defer func() { c.stats.record(err) }()
+
 	endpoint := fmt.Sprintf("images/%s", id)
 
-	req, err := http.NewRequest(http.MethodGet, baseURL+endpoint, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+endpoint, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -159,6 +193,11 @@ func (c *Client) GetImageByID(id string) (*Image, error) {
 
 // SearchImages searches for images based on tags
 func (c *Client) SearchImages(tags []string, count int, rating string) ([]Image, error) {
+	return c.SearchImagesContext(context.Background(), tags, count, rating)
+}
+
+// SearchImagesContext is SearchImages, cancelled with ctx
+func (c *Client) SearchImagesContext(ctx context.Context, tags []string, count int, rating string) ([]Image, error) {
 	endpoint := "images/search?"
 
 	// Add tags
@@ -166,16 +205,33 @@ func (c *Client) SearchImages(tags []string, count int, rating string) ([]Image,
 		if i > 0 {
 			endpoint += "&"
 		}
-		endpoint += fmt.Sprintf("tags=%s", tag)
+		endpoint += "tags=" + url.QueryEscape(tag)
 	}
 
+	return c.search(ctx, endpoint, count, rating)
+}
+
+// SearchByArtist searches for images credited to an artist
+func (c *Client) SearchByArtist(artist string, count int, rating string) ([]Image, error) {
+	return c.SearchByArtistContext(context.Background(), artist, count, rating)
+}
+
+// SearchByArtistContext is SearchByArtist, cancelled with ctx
+func (c *Client) SearchByArtistContext(ctx context.Context, artist string, count int, rating string) ([]Image, error) {
+	return c.search(ctx, "images/search?artist="+url.QueryEscape(artist), count, rating)
+}
+
+// search runs an image search with the count and rating added to endpoint
+func (c *Client) search(ctx context.Context, endpoint string, count int, rating string) (images []Image, err error) {
+	defer func() { c.stats.record(err) }()
+
 	// Add count and rating
 	endpoint += fmt.Sprintf("&count=%d", count)
 	if rating != "" {
 		endpoint += fmt.Sprintf("&rating=%s", rating)
 	}
 
-	req, err := http.NewRequest(http.MethodGet, baseURL+endpoint, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+endpoint, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -198,5 +254,25 @@ func (c *Client) SearchImages(tags []string, count int, rating string) ([]Image,
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return result.Images, nil
+	return firstN(result.Images, count), nil
+}
+
+// firstN clamps items to at most n entries. The APIs don't always honor the
+// requested count, and callers rely on never getting more than they asked for.
+func firstN[T any](items []T, n int) []T {
+	if n >= 0 && len(items) > n {
+		return items[:n]
+	}
+	return items
+}
+
+// Stats returns the recent request statistics of the client
+func (c *Client) Stats() StatsSnapshot {
+	return c.stats.Snapshot()
+}
+
+// MonitorHealth calls notify once nekos.moe requests have failed for longer
+// than threshold, and again once one succeeds
+func (c *Client) MonitorHealth(threshold time.Duration, notify HealthNotifier) {
+	c.stats.monitorHealth("Nekos.moe", threshold, notify)
 }
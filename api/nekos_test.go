@@ -0,0 +1,67 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// stubAPI routes every outgoing request of the default transport, which the
+// clients use, to handler for the rest of the test
+func stubAPI(t *testing.T, handler http.HandlerFunc) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	target, _ := url.Parse(server.URL)
+
+	orig := http.DefaultTransport
+	http.DefaultTransport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		req = req.Clone(req.Context())
+		req.URL.Scheme, req.URL.Host = target.Scheme, target.Host
+		return orig.RoundTrip(req)
+	})
+	t.Cleanup(func() {
+		http.DefaultTransport = orig
+		server.Close()
+	})
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestFirstN(t *testing.T) {
+	items := []int{1, 2, 3}
+	tests := []struct {
+		n    int
+		want int
+	}{
+		{0, 0},
+		{1, 1},
+		{3, 3},
+		{5, 3},
+		{-1, 3},
+	}
+
+	for _, tt := range tests {
+		if got := firstN(items, tt.n); len(got) != tt.want {
+			t.Errorf("firstN(%v, %d) has %d items, want %d", items, tt.n, len(got), tt.want)
+		}
+	}
+}
+
+func TestGetRandomImagesClampsToCount(t *testing.T) {
+	stubAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		// The API ignores the count and answers with three images
+		json.NewEncoder(w).Encode(RandomImageResponse{Images: []Image{{ID: "a"}, {ID: "b"}, {ID: "c"}}})
+	})
+
+	images, err := New("test").GetRandomImages(1, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(images) != 1 || images[0].ID != "a" {
+		t.Errorf("got %+v, want exactly the first image", images)
+	}
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"sync"
+	"time"
+)
+
+// statsWindow is the number of most recent requests used for the success rate
+const statsWindow = 100
+
+// Stats tracks the outcome of the requests made by an API client
+type Stats struct {
+	mutex       sync.Mutex
+	outcomes    [statsWindow]bool
+	next        int
+	recorded    int
+	lastError   string
+	lastErrorAt time.Time
+	monitor     *healthMonitor // nil unless MonitorHealth was called
+}
+
+// StatsSnapshot is a point-in-time copy of a client's request statistics
+type StatsSnapshot struct {
+	Requests    int // requests in the recent window
+	Successes   int // successful requests in the recent window
+	LastError   string
+	LastErrorAt time.Time
+}
+
+// record stores the outcome of a single request and passes it on to the
+// health monitor, if any
+func (s *Stats) record(err error) {
+	s.mutex.Lock()
+	monitor := s.monitor
+	s.store(err)
+	s.mutex.Unlock()
+
+	if monitor == nil {
+		return
+	}
+	if message := monitor.observe(err, time.Now()); message != "" {
+		// Don't hold up the request with the notification
+		go monitor.notify(message)
+	}
+}
+
+// store must be called with the mutex held
+func (s *Stats) store(err error) {
+	s.outcomes[s.next] = err == nil
+	s.next = (s.next + 1) % statsWindow
+	if s.recorded < statsWindow {
+		s.recorded++
+	}
+
+	if err != nil {
+		s.lastError = err.Error()
+		s.lastErrorAt = time.Now()
+	}
+}
+
+// monitorHealth starts watching the requests for a failure streak longer
+// than threshold
+func (s *Stats) monitorHealth(provider string, threshold time.Duration, notify HealthNotifier) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.monitor = &healthMonitor{provider: provider, threshold: threshold, notify: notify}
+}
+
+// Snapshot returns a copy of the current statistics
+func (s *Stats) Snapshot() StatsSnapshot {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	snapshot := StatsSnapshot{
+		Requests:    s.recorded,
+		LastError:   s.lastError,
+		LastErrorAt: s.lastErrorAt,
+	}
+	for i := 0; i < s.recorded; i++ {
+		if s.outcomes[i] {
+			snapshot.Successes++
+		}
+	}
+	return snapshot
+}
+
+// SuccessRate returns the fraction of successful recent requests, or 1 if
+// there were none
+func (s StatsSnapshot) SuccessRate() float64 {
+	if s.Requests == 0 {
+		return 1
+	}
+	return float64(s.Successes) / float64(s.Requests)
+}
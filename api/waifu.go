@@ -2,21 +2,33 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 )
 
 const (
 	waifuBaseURL = "https://api.waifu.im/images"
+	waifuTagsURL = "https://api.waifu.im/tags"
+)
+
+// Image search paging limits. Larger requests are split into pages of at
+// most waifuMaxPageSize, the API's documented cap.
+const (
+	waifuMaxPageSize = 30
+	waifuMaxPages    = 10
 )
 
 // WaifuClient represents the Waifu.im API client
 type WaifuClient struct {
 	httpClient *http.Client
 	userAgent  string
+	stats      Stats
 }
 
 type NSFWMode int
@@ -37,6 +49,8 @@ type WaifuResponse struct {
 	HasPreviousPage bool         `json:"hasPreviousPage"`
 	HasNextPage     bool         `json:"hasNextPage"`
 }
+
+// Artist is the creator credited for a waifu.im image
 type Artist struct {
 	ID           int     `json:"id"`
 	Name         string  `json:"name"`
@@ -48,6 +62,58 @@ type Artist struct {
 	CreatorID    *int    `json:"creatorId"`
 	ImageCount   int     `json:"imageCount"`
 }
+
+// UnmarshalJSON accepts an artist given as a full object or as a bare name
+// string. A null artist leaves the zero value.
+func (a *Artist) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		*a = Artist{}
+		return json.Unmarshal(data, &a.Name)
+	}
+
+	type plain Artist // drops this method to avoid recursion
+	return json.Unmarshal(data, (*plain)(a))
+}
+
+// CreditArtists renders the artists of an image with markdown links to their
+// pages, e.g. "Name ([Twitter](...) · [Patreon](...))". Images without a
+// named artist are credited to "Unknown artist".
+func CreditArtists(artists []Artist) string {
+	var credits []string
+	for _, artist := range artists {
+		if artist.Name == "" {
+			continue
+		}
+
+		var links []string
+		for _, link := range []struct {
+			label string
+			url   *string
+		}{
+			{"Twitter", artist.Twitter},
+			{"Patreon", artist.Patreon},
+			{"Pixiv", artist.Pixiv},
+			{"DeviantArt", artist.DeviantArt},
+		} {
+			if link.url != nil && *link.url != "" {
+				links = append(links, fmt.Sprintf("[%s](%s)", link.label, *link.url))
+			}
+		}
+
+		credit := artist.Name
+		if len(links) > 0 {
+			credit += " (" + strings.Join(links, " · ") + ")"
+		}
+		credits = append(credits, credit)
+	}
+
+	if len(credits) == 0 {
+		return "Unknown artist"
+	}
+	return strings.Join(credits, ", ")
+}
+
+// WaifuImage is an image returned by the waifu.im API
 type WaifuImage struct {
 	ID             int64    `json:"id"`
 	PerceptualHash string   `json:"perceptualHash"`
@@ -79,7 +145,7 @@ type Tag struct {
 	ReviewStatusTag string `json:"reviewStatus"` // was review_status (verify if present)
 	CreatorID       *int64 `json:"creatorId"`    // nullable
 	ImageCount      int    `json:"imageCount"`   // new
-	IsNSFW          bool   `json:"is_nsfw"`      // verify if this still exists in tags
+	IsNSFW          bool   `json:"isNsfw"`
 }
 
 func (m NSFWMode) String() string {
@@ -98,25 +164,88 @@ func (m NSFWMode) String() string {
 // NewWaifuClient creates a new Waifu.im API client
 func NewWaifuClient(userAgent string) *WaifuClient {
 	return &WaifuClient{
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		userAgent: userAgent,
+		httpClient: newHTTPClient(),
+		userAgent:  userAgent,
 	}
 }
 
+// WaifuOptions filters a waifu.im image search
+type WaifuOptions struct {
+	Mode          NSFWMode
+	DominantColor string   // hex color such as #9B59B6, empty for any color
+	Animated      bool     // only animated images such as GIFs
+	Tag           string   // tag slug the images must have, empty for any tag
+	ExcludedTags  []string // tag slugs the images must not have
+}
+
 // GetWaifuImages fetches waifu images from the API
 func (c *WaifuClient) GetWaifuImages(mode NSFWMode, count int) ([]WaifuImage, error) {
-	if count < 1 {
-		count = 1
-	}
-	if count > 10 {
-		count = 10
+	return c.SearchWaifuImagesContext(context.Background(), WaifuOptions{Mode: mode}, count)
+}
+
+// GetWaifuImagesContext is GetWaifuImages, cancelled with ctx
+func (c *WaifuClient) GetWaifuImagesContext(ctx context.Context, mode NSFWMode, count int) ([]WaifuImage, error) {
+	return c.SearchWaifuImagesContext(ctx, WaifuOptions{Mode: mode}, count)
+}
+
+// SearchWaifuImages fetches waifu images matching the options from the API.
+// Counts above the API's page size cap are fetched over several pages, with
+// images seen on an earlier page skipped.
+func (c *WaifuClient) SearchWaifuImages(opts WaifuOptions, count int) ([]WaifuImage, error) {
+	return c.SearchWaifuImagesContext(context.Background(), opts, count)
+}
+
+// SearchWaifuImagesContext is SearchWaifuImages, cancelled with ctx
+func (c *WaifuClient) SearchWaifuImagesContext(ctx context.Context, opts WaifuOptions, count int) (images []WaifuImage, err error) {
+	defer func() { c.stats.record(err) }()
+
+	// Every page has the same size, so page numbers map to stable offsets
+	count = max(count, 1)
+	pageSize := min(count, waifuMaxPageSize)
+	seen := make(map[int64]bool)
+
+	for page := 1; len(images) < count && page <= waifuMaxPages; page++ {
+		result, err := c.fetchWaifuPage(ctx, opts, page, pageSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, img := range result.Items {
+			if !seen[img.ID] {
+				seen[img.ID] = true
+				images = append(images, img)
+			}
+		}
+		if len(result.Items) == 0 || !result.HasNextPage {
+			break
+		}
 	}
 
-	params := fmt.Sprintf("?IsNsfw=%s&pageSize=%d", mode.String(), count)
+	return firstN(images, count), nil
+}
 
-	req, err := http.NewRequest(http.MethodGet, waifuBaseURL+params, nil)
+// waifuQuery builds the query string of an image search page. Each excluded
+// tag is its own ExcludedTags parameter.
+func waifuQuery(opts WaifuOptions, page, pageSize int) string {
+	params := fmt.Sprintf("?IsNsfw=%s&pageNumber=%d&pageSize=%d", opts.Mode.String(), page, pageSize)
+	if opts.DominantColor != "" {
+		params += "&DominantColor=" + url.QueryEscape(opts.DominantColor)
+	}
+	if opts.Animated {
+		params += "&IsAnimated=True"
+	}
+	if opts.Tag != "" {
+		params += "&IncludedTags=" + url.QueryEscape(opts.Tag)
+	}
+	for _, tag := range opts.ExcludedTags {
+		params += "&ExcludedTags=" + url.QueryEscape(tag)
+	}
+	return params
+}
+
+// fetchWaifuPage fetches one page of images matching the options
+func (c *WaifuClient) fetchWaifuPage(ctx context.Context, opts WaifuOptions, page, pageSize int) (*WaifuResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, waifuBaseURL+waifuQuery(opts, page, pageSize), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -138,13 +267,27 @@ func (c *WaifuClient) GetWaifuImages(mode NSFWMode, count int) ([]WaifuImage, er
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
-
-	return result.Items, nil
+	return &result, nil
 }
 
 // DownloadWaifuImage downloads a waifu image from the provided URL
 func (c *WaifuClient) DownloadWaifuImage(imageURL string) ([]byte, error) {
-	req, err := http.NewRequest(http.MethodGet, imageURL, nil)
+	return c.DownloadWaifuImageContext(context.Background(), imageURL)
+}
+
+// DownloadWaifuImageContext is DownloadWaifuImage, cancelled with ctx
+func (c *WaifuClient) DownloadWaifuImageContext(ctx context.Context, imageURL string) ([]byte, error) {
+	return c.DownloadWaifuImageReserved(ctx, imageURL, 0, nil)
+}
+
+// DownloadWaifuImageReserved is DownloadWaifuImageContext reading at most
+// maxBytes, 0 meaning no cap, with the image's memory taken from reserve
+// first. It returns ErrTooLarge or ErrNotReserved if the image is skipped for
+// either.
+func (c *WaifuClient) DownloadWaifuImageReserved(ctx context.Context, imageURL string, maxBytes int, reserve Reserve) (data []byte, err error) {
+	defer func() { c.stats.record(providerErr(err)) }()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -161,11 +304,83 @@ func (c *WaifuClient) DownloadWaifuImage(imageURL string) ([]byte, error) {
 		return nil, fmt.Errorf("failed to download image: status %d", resp.StatusCode)
 	}
 
-	data, err := io.ReadAll(resp.Body)
+	data, err = readImage(resp, maxBytes, reserve)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read image data: %w", err)
 	}
 
+	if err := checkImage(resp, data); err != nil {
+		return nil, err
+	}
+
 	return data, nil
 }
 
+// Stats returns the recent request statistics of the client
+func (c *WaifuClient) Stats() StatsSnapshot {
+	return c.stats.Snapshot()
+}
+
+// MonitorHealth calls notify once waifu.im requests have failed for longer
+// than threshold, and again once one succeeds
+func (c *WaifuClient) MonitorHealth(threshold time.Duration, notify HealthNotifier) {
+	c.stats.monitorHealth("Waifu.im", threshold, notify)
+}
+
+// tagsPageSize is the page size requested from the tags endpoint
+const tagsPageSize = 100
+
+// TagsResponse is one page of the waifu.im tag list
+type TagsResponse struct {
+	Items       []Tag `json:"items"`
+	HasNextPage bool  `json:"hasNextPage"`
+}
+
+// GetTags fetches the full waifu.im tag list, following pagination
+func (c *WaifuClient) GetTags() ([]Tag, error) {
+	return c.GetTagsContext(context.Background())
+}
+
+// GetTagsContext is GetTags, cancelled with ctx
+func (c *WaifuClient) GetTagsContext(ctx context.Context) (tags []Tag, err error) {
+	defer func() { c.stats.record(err) }()
+
+	for page := 1; ; page++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s?pageNumber=%d&pageSize=%d", waifuTagsURL, page, tagsPageSize), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		req.Header.Set("User-Agent", c.userAgent)
+
+		result, err := c.fetchTagsPage(req)
+		if err != nil {
+			return nil, err
+		}
+
+		tags = append(tags, result.Items...)
+		if !result.HasNextPage || len(result.Items) == 0 {
+			return tags, nil
+		}
+	}
+}
+
+// fetchTagsPage sends a tags request and decodes its page
+func (c *WaifuClient) fetchTagsPage(req *http.Request) (*TagsResponse, error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result TagsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &result, nil
+}
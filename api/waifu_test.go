@@ -0,0 +1,157 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSearchWaifuImagesClampsToCount(t *testing.T) {
+	stubAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		// The API ignores the page size and answers with three images
+		json.NewEncoder(w).Encode(WaifuResponse{Items: []WaifuImage{{ID: 1}, {ID: 2}, {ID: 3}}})
+	})
+
+	images, err := NewWaifuClient("test").GetWaifuImages(NSFWModeSFW, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(images) != 1 || images[0].ID != 1 {
+		t.Errorf("got %+v, want exactly the first image", images)
+	}
+}
+
+func TestCreditArtists(t *testing.T) {
+	tests := []struct {
+		name string
+		json string // the artists field of an image
+		want string
+	}{
+		{"null", `null`, "Unknown artist"},
+		{"null entry", `[null]`, "Unknown artist"},
+		{"no artists", `[]`, "Unknown artist"},
+		{"bare name", `["Kuro"]`, "Kuro"},
+		{"object without links", `[{"id": 1, "name": "Kuro", "twitter": null}]`, "Kuro"},
+		{"object with links", `[{"id": 1, "name": "Kuro", "twitter": "https://x.com/kuro", "patreon": "https://patreon.com/kuro", "pixiv": ""}]`,
+			"Kuro ([Twitter](https://x.com/kuro) · [Patreon](https://patreon.com/kuro))"},
+		{"several", `["Kuro", {"name": "Shiro", "pixiv": "https://pixiv.net/u/2"}, {"name": ""}]`,
+			"Kuro, Shiro ([Pixiv](https://pixiv.net/u/2))"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var img WaifuImage
+			if err := json.Unmarshal([]byte(`{"id": 1, "artists": `+tt.json+`}`), &img); err != nil {
+				t.Fatal(err)
+			}
+			if got := CreditArtists(img.Artists); got != tt.want {
+				t.Errorf("CreditArtists = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchWaifuImagesPages(t *testing.T) {
+	// ids returns a page of the images from first to last
+	ids := func(first, last int64) []WaifuImage {
+		var images []WaifuImage
+		for id := first; id <= last; id++ {
+			images = append(images, WaifuImage{ID: id})
+		}
+		return images
+	}
+
+	tests := []struct {
+		name  string
+		count int
+		pages []WaifuResponse
+		want  int
+		sizes []string // pageSize of each request
+	}{
+		{"one page", 10, []WaifuResponse{{Items: ids(1, 10), HasNextPage: true}}, 10, []string{"10"}},
+		{"overlapping pages", 50, []WaifuResponse{
+			{Items: ids(1, 30), HasNextPage: true},
+			{Items: ids(21, 45), HasNextPage: true}, // the first ten were already seen
+			{Items: ids(46, 75), HasNextPage: true},
+		}, 50, []string{"30", "30", "30"}},
+		{"last page", 50, []WaifuResponse{
+			{Items: ids(1, 30), HasNextPage: true},
+			{Items: ids(31, 40)},
+		}, 40, []string{"30", "30"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mutex sync.Mutex
+			var sizes []string
+			stubAPI(t, func(w http.ResponseWriter, r *http.Request) {
+				page, _ := strconv.Atoi(r.URL.Query().Get("pageNumber"))
+				mutex.Lock()
+				sizes = append(sizes, r.URL.Query().Get("pageSize"))
+				mutex.Unlock()
+				json.NewEncoder(w).Encode(tt.pages[page-1])
+			})
+
+			images, err := NewWaifuClient("test").SearchWaifuImages(WaifuOptions{}, tt.count)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(images) != tt.want {
+				t.Fatalf("got %d images, want %d", len(images), tt.want)
+			}
+			for n, img := range images {
+				if img.ID != int64(n+1) {
+					t.Fatalf("image %d has ID %d, want the pages in order without duplicates", n, img.ID)
+				}
+			}
+			if !slices.Equal(sizes, tt.sizes) {
+				t.Errorf("requested page sizes %v, want %v", sizes, tt.sizes)
+			}
+		})
+	}
+}
+
+func TestWaifuQuery(t *testing.T) {
+	tests := []struct {
+		name string
+		opts WaifuOptions
+		want string
+	}{
+		{"defaults", WaifuOptions{}, "?IsNsfw=False&pageNumber=1&pageSize=5"},
+		{"mixed", WaifuOptions{Mode: NSFWModeAll}, "?IsNsfw=All&pageNumber=1&pageSize=5"},
+		{"included tag", WaifuOptions{Tag: "maid"}, "?IsNsfw=False&pageNumber=1&pageSize=5&IncludedTags=maid"},
+		{"excluded tags", WaifuOptions{ExcludedTags: []string{"maid", "uniform"}},
+			"?IsNsfw=False&pageNumber=1&pageSize=5&ExcludedTags=maid&ExcludedTags=uniform"},
+		{"included and excluded", WaifuOptions{Mode: NSFWModeNSFW, Tag: "waifu", ExcludedTags: []string{"maid"}},
+			"?IsNsfw=True&pageNumber=1&pageSize=5&IncludedTags=waifu&ExcludedTags=maid"},
+		{"escaped", WaifuOptions{DominantColor: "#9B59B6", ExcludedTags: []string{"raiden shogun"}},
+			"?IsNsfw=False&pageNumber=1&pageSize=5&DominantColor=%239B59B6&ExcludedTags=raiden+shogun"},
+		{"everything", WaifuOptions{Mode: NSFWModeSFW, DominantColor: "#FFFFFF", Animated: true, Tag: "maid", ExcludedTags: []string{"oppai"}},
+			"?IsNsfw=False&pageNumber=1&pageSize=5&DominantColor=%23FFFFFF&IsAnimated=True&IncludedTags=maid&ExcludedTags=oppai"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := waifuQuery(tt.opts, 1, 5); got != tt.want {
+				t.Errorf("waifuQuery = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// Each excluded tag must arrive as its own parameter
+	query, err := url.ParseQuery(strings.TrimPrefix(waifuQuery(WaifuOptions{ExcludedTags: []string{"maid", "raiden shogun"}}, 2, 30), "?"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := query["ExcludedTags"]; !slices.Equal(got, []string{"maid", "raiden shogun"}) {
+		t.Errorf("ExcludedTags = %q, want both tags", got)
+	}
+	if query.Get("pageNumber") != "2" || query.Get("pageSize") != "30" {
+		t.Errorf("paging = %s, %s; want 2, 30", query.Get("pageNumber"), query.Get("pageSize"))
+	}
+}
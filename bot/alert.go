@@ -0,0 +1,36 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"KawaiiBot/webhook"
+)
+
+// sendAlert posts an operator alert to ALERT_CHANNEL_ID and ALERT_WEBHOOK_URL,
+// whichever are configured
+func (b *Bot) sendAlert(message string) error {
+	var errs []error
+
+	if b.alertChannelID != "" {
+		if _, err := b.session.ChannelMessageSend(b.alertChannelID, message); err != nil {
+			errs = append(errs, fmt.Errorf("failed to post alert to channel %s: %w", b.alertChannelID, err))
+		}
+	}
+
+	if b.alertWebhookURL != "" {
+		if err := webhook.Notify(b.alertWebhookURL, message); err != nil {
+			errs = append(errs, fmt.Errorf("failed to post alert to webhook: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// providerAlert sends a provider health change to the alert targets
+func (b *Bot) providerAlert(message string) {
+	if err := b.sendAlert(message); err != nil {
+		slog.Error("Failed to send provider alert", "error", err)
+	}
+}
@@ -0,0 +1,132 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Limits for message command arguments
+const (
+	maxMessageArgs = 5
+	maxArgLength   = 32
+)
+
+// Bounds of the picture count of message commands
+const (
+	minCount = 1
+	maxCount = 10
+)
+
+// validateArgs rejects message command arguments that are too many, too long
+// or contain characters other than letters, digits, '-', '_' and the ':' of
+// key:value options, so garbage never reaches the image APIs.
+func validateArgs(args []string) error {
+	if len(args) > maxMessageArgs {
+		return fmt.Errorf("too many arguments, at most %d are allowed", maxMessageArgs)
+	}
+
+	for _, arg := range args {
+		if len([]rune(arg)) > maxArgLength {
+			return fmt.Errorf("arguments may be at most %d characters long", maxArgLength)
+		}
+		for _, r := range arg {
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' && r != ':' {
+				return errors.New("arguments may only contain letters, digits, '-', '_' and ':'")
+			}
+		}
+	}
+	return nil
+}
+
+// cutOption removes the first key:value argument with the given key and
+// returns its value and the remaining arguments
+func cutOption(args []string, key string) (string, bool, []string) {
+	for i, arg := range args {
+		if value, ok := strings.CutPrefix(strings.ToLower(arg), key+":"); ok {
+			return value, true, append(slices.Clone(args[:i]), args[i+1:]...)
+		}
+	}
+	return "", false, args
+}
+
+// parseYesNo reads a yes/no argument such as the nsfw option. "y", "yes",
+// "true" and "1" mean yes; anything else, including "n", "no", "false" and
+// "0", means no, so a typo never asks for NSFW pictures.
+func parseYesNo(s string) bool {
+	yes, _ := yesNo(s)
+	return yes
+}
+
+// yesNo is parseYesNo that also reports whether s was a yes/no value at all
+func yesNo(s string) (yes, ok bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "y", "yes", "true", "1":
+		return true, true
+	case "n", "no", "false", "0":
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+// resolveCount checks a requested picture count against minCount and
+// maxCount. Counts out of range are clamped, returning a notice for the user,
+// or rejected when reject is set.
+func resolveCount(n int, reject bool) (int, string, error) {
+	if n >= minCount && n <= maxCount {
+		return n, "", nil
+	}
+	if reject {
+		return 0, "", fmt.Errorf("count must be from %d to %d", minCount, maxCount)
+	}
+
+	clamped := min(max(n, minCount), maxCount)
+	return clamped, fmt.Sprintf("ℹ️ Count clamped to %d.", clamped), nil
+}
+
+// catgirlGIFText explains why the catgirl commands can't post GIFs
+const catgirlGIFText = "🐱 nekos.moe only has still pictures, so there are no catgirl GIFs. Try `/waifu` with `gif` for animated pictures."
+
+// isGIFArg reports whether a message command argument asks for animated
+// pictures
+func isGIFArg(arg string) bool {
+	switch strings.ToLower(arg) {
+	case "gif", "g", "animated":
+		return true
+	default:
+		return false
+	}
+}
+
+// parseWaifuArgs parses the !waifu arguments following the command name.
+// Tokens may come in any order: a number sets the count, unchecked so the
+// caller can apply resolveCount, a content keyword sets the mode and "gif"
+// asks for animated pictures. Later tokens win and unknown tokens are ignored.
+// The mode is empty if none was given, leaving it to the guild default.
+func parseWaifuArgs(args []string) (count int, contentMode string, gif bool) {
+	count = 1
+
+	for _, arg := range args {
+		arg = strings.ToLower(arg)
+		if n, err := strconv.Atoi(arg); err == nil {
+			count = n
+			continue
+		}
+
+		switch arg {
+		case "nsfw", "n", "ns":
+			contentMode = "nsfw"
+		case "all", "a", "both":
+			contentMode = "all"
+		case "sfw", "s", "safe":
+			contentMode = "sfw"
+		case "gif", "g", "animated":
+			gif = true
+		}
+	}
+	return count, contentMode, gif
+}
@@ -0,0 +1,197 @@
+package bot
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestValidateArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		ok   bool
+	}{
+		{"none", nil, true},
+		{"typical", []string{"3", "nsfw", "gif"}, true},
+		{"key value", []string{"tag:maid", "color:blue"}, true},
+		{"dashes and underscores", []string{"long-hair", "school_uniform"}, true},
+		{"non-ASCII letters", []string{"ねこ", "Größe"}, true},
+		{"huge number", []string{"99999999999999999999"}, true},
+		{"at the length limit", []string{strings.Repeat("x", maxArgLength)}, true},
+		{"over the length limit", []string{strings.Repeat("x", maxArgLength+1)}, false},
+		{"long in runes, not bytes", []string{strings.Repeat("ね", maxArgLength)}, true},
+		{"too many", []string{"1", "2", "3", "4", "5", "6"}, false},
+		{"at the count limit", []string{"1", "2", "3", "4", "5"}, true},
+		{"mention", []string{"<@123>"}, false},
+		{"url", []string{"https://example.com"}, false},
+		{"query injection", []string{"maid&IsNsfw=True"}, false},
+		{"emoji", []string{"🐱"}, false},
+		{"control character", []string{"a\x00b"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateArgs(tt.args)
+			if (err == nil) != tt.ok {
+				t.Errorf("validateArgs(%q) = %v, want ok %t", tt.args, err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestParseYesNo(t *testing.T) {
+	tests := []struct {
+		in  string
+		yes bool
+		ok  bool
+	}{
+		{"y", true, true},
+		{"yes", true, true},
+		{"true", true, true},
+		{"1", true, true},
+		{"YES", true, true},
+		{" True ", true, true},
+		{"n", false, true},
+		{"no", false, true},
+		{"false", false, true},
+		{"0", false, true},
+		{"No", false, true},
+		{"", false, false},
+		{"maybe", false, false},
+		{"2", false, false},
+		{"yess", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			yes, ok := yesNo(tt.in)
+			if yes != tt.yes || ok != tt.ok {
+				t.Errorf("yesNo(%q) = %t, %t; want %t, %t", tt.in, yes, ok, tt.yes, tt.ok)
+			}
+			if got := parseYesNo(tt.in); got != tt.yes {
+				t.Errorf("parseYesNo(%q) = %t, want %t", tt.in, got, tt.yes)
+			}
+		})
+	}
+}
+
+func TestResolveCount(t *testing.T) {
+	tests := []struct {
+		n       int
+		reject  bool
+		want    int
+		note    bool
+		wantErr bool
+	}{
+		{1, false, 1, false, false},
+		{10, false, 10, false, false},
+		{5, true, 5, false, false},
+		{11, false, 10, true, false},
+		{50, false, 10, true, false},
+		{0, false, 1, true, false},
+		{-3, false, 1, true, false},
+		{50, true, 0, false, true},
+		{0, true, 0, false, true},
+		{-3, true, 0, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d reject %t", tt.n, tt.reject), func(t *testing.T) {
+			got, note, err := resolveCount(tt.n, tt.reject)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %t", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("count = %d, want %d", got, tt.want)
+			}
+			if (note != "") != tt.note {
+				t.Errorf("note = %q, want a note %t", note, tt.note)
+			}
+			if tt.note && !strings.Contains(note, fmt.Sprint(tt.want)) {
+				t.Errorf("note %q doesn't name the clamped count %d", note, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseWaifuArgs(t *testing.T) {
+	tests := []struct {
+		args  string
+		count int
+		mode  string
+		gif   bool
+	}{
+		{"", 1, "", false},
+		{"3", 3, "", false},
+		{"nsfw 3", 3, "nsfw", false},
+		{"3 nsfw", 3, "nsfw", false},
+		{"y 3 n", 3, "nsfw", false},
+		{"n n", 1, "nsfw", false},
+		{"5 y y", 5, "", false},
+		{"nsfw sfw", 1, "sfw", false},
+		{"sfw nsfw", 1, "nsfw", false},
+		{"2 all 4", 4, "all", false},
+		{"GIF NSFW 2", 2, "nsfw", true},
+		{"animated", 1, "", true},
+		{"g both", 1, "all", true},
+		{"-3", -3, "", false},
+		{"0", 0, "", false},
+		{"99999", 99999, "", false},
+		{"foo bar:baz 3x", 1, "", false},
+		{"nsfwx 2", 2, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.args, func(t *testing.T) {
+			count, mode, gif := parseWaifuArgs(strings.Fields(tt.args))
+			if count != tt.count || mode != tt.mode || gif != tt.gif {
+				t.Errorf("parseWaifuArgs(%q) = %d, %q, %t; want %d, %q, %t",
+					tt.args, count, mode, gif, tt.count, tt.mode, tt.gif)
+			}
+		})
+	}
+}
+
+func FuzzParseWaifuArgs(f *testing.F) {
+	for _, seed := range []string{"", "y 3 n", "n n", "5 y y", "gif nsfw 2", "all -1 sfw", "9999999999999999999", "ǅ ß İ"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		args := strings.Fields(input)
+		count, mode, gif := parseWaifuArgs(args)
+
+		switch mode {
+		case "", "sfw", "nsfw", "all":
+		default:
+			t.Fatalf("mode = %q, want empty, sfw, nsfw or all", mode)
+		}
+		if gif != slices.ContainsFunc(args, isGIFArg) {
+			t.Errorf("gif = %t, but the arguments %q say otherwise", gif, args)
+		}
+		if !slices.ContainsFunc(args, isNumber) && count != 1 {
+			t.Errorf("count = %d without a number, want 1", count)
+		}
+
+		// Unknown tokens are ignored and later tokens win
+		with := func(extra string) []string { return append(slices.Clone(args), extra) }
+		if c, m, g := parseWaifuArgs(with("xyzzy")); c != count || m != mode || g != gif {
+			t.Errorf("an unknown token changed the result to %d, %q, %t", c, m, g)
+		}
+		if c, m, g := parseWaifuArgs(with("7")); c != 7 || m != mode || g != gif {
+			t.Errorf("a trailing count gave %d, %q, %t; want 7, %q, %t", c, m, g, mode, gif)
+		}
+		if c, m, g := parseWaifuArgs(with("SFW")); c != count || m != "sfw" || g != gif {
+			t.Errorf("a trailing mode gave %d, %q, %t; want %d, sfw, %t", c, m, g, count, gif)
+		}
+	})
+}
+
+// isNumber reports whether parseWaifuArgs reads arg as a count
+func isNumber(arg string) bool {
+	_, err := strconv.Atoi(arg)
+	return err == nil
+}
@@ -0,0 +1,116 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"KawaiiBot/api"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Artist search limits
+const (
+	maxArtistLength  = 64
+	artistSearchPool = 50
+)
+
+// handleByArtistSlashCommand handles the /by-artist slash command. It posts
+// nekos.moe images credited to an artist.
+func (b *Bot) handleByArtistSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) {
+	// Get options - defaults: count=3, SFW
+	artist := ""
+	count := 3
+	nsfw := false
+
+	parser := b.optionParser(i.GuildID)
+	for _, option := range data.Options {
+		switch option.Name {
+		case "name":
+			artist = strings.TrimSpace(option.StringValue())
+		case "count":
+			count = int(option.IntValue())
+		case "nsfw":
+			nsfw = parser.yesNo(option)
+		}
+	}
+	if parser.problem != "" {
+		b.respondError(s, i, parser.problem)
+		return
+	}
+
+	if artist == "" || len([]rune(artist)) > maxArtistLength {
+		b.respondError(s, i, fmt.Sprintf("Please give an artist name of at most %d characters.", maxArtistLength))
+		return
+	}
+	count = min(max(count, minCount), maxCount)
+
+	// DMs have no age restriction, so NSFW there is opt-in
+	if nsfw && b.nsfwBlockedInDM(i.GuildID) {
+		b.respondError(s, i, nsfwDMText)
+		return
+	}
+
+	// Servers may require a role for NSFW requests
+	if nsfw {
+		if roleID := b.missingRole(i.GuildID, i.Member, roleGateNSFW); roleID != "" {
+			b.respondRoleGateInteraction(s, i, roleID)
+			return
+		}
+	}
+
+	// Ask for confirmation first if the guild gates NSFW content
+	if nsfw && b.nsfwGated(i.GuildID, interactionUserID(i)) {
+		b.respondNSFWGateInteraction(s, i)
+		return
+	}
+
+	// Clamp to the guild's limit for the resolved rating
+	count, limitNote := b.applyCountLimit(i.GuildID, count, nsfw)
+
+	// Keep one guild from filling the disk
+	if b.guildAtFileCap(i.GuildID) {
+		b.respondError(s, i, tooManyFilesText)
+		return
+	}
+
+	// Defer response to avoid timeout
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		slog.Warn("Failed to defer interaction", "error", err)
+		return
+	}
+
+	// Show typing indicator
+	s.ChannelTyping(i.ChannelID)
+
+	rating := "safe"
+	if nsfw {
+		rating = "explicit"
+	}
+
+	results, err := b.nekosAPI.SearchByArtistContext(b.requests, artist, artistSearchPool, rating)
+	if err != nil {
+		b.editError(s, i, b.fetchFailedText(i.GuildID, "catgirl", err))
+		return
+	}
+
+	// Keep only the artist's own images in the requested rating, in case the
+	// search matched loosely
+	results = slices.DeleteFunc(results, func(img api.Image) bool {
+		return img.NSFW != nsfw || !strings.EqualFold(img.Artist, artist)
+	})
+
+	if len(results) == 0 {
+		b.editError(s, i, fmt.Sprintf("No images found by the artist %q.", artist))
+		return
+	}
+
+	images := results[:min(count, len(results))]
+	b.recordServed(i.GuildID, len(images))
+	b.sendImagesInteraction(s, i, images, joinNotes(fmt.Sprintf("🎨 Pictures by **%s**", artist), limitNote))
+}
@@ -4,163 +4,404 @@ package bot
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"KawaiiBot/api"
+	"KawaiiBot/config"
 	"KawaiiBot/scheduler"
+	"KawaiiBot/server"
 	"KawaiiBot/storage"
 	"KawaiiBot/webhook"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/gorilla/websocket"
 )
 
 const (
-	userAgent   = "KawaiiBot (kawaiibot, v1.0.0)"
 	picturesDir = "pictures"
 	maxFileAge  = 5 * time.Minute
-	botStatus   = "Looking at anime girls"
+
+	registerAttempts = 3
+	registerBackoff  = 2 * time.Second
+
+	// Idle cleanup backs off no further than a file may live, so a picture
+	// saved while idle isn't kept past its age
+	maxCleanupInterval = maxFileAge
 )
 
+// invitePermissions is the permission set requested by the /invite URL:
+//   - View Channel and Send Messages to reply to commands
+//   - Embed Links for the help and status embeds
+//   - Attach Files to upload the pictures
+//   - Manage Messages to delete the invoking !command message
+const invitePermissions = discordgo.PermissionViewChannel |
+	discordgo.PermissionSendMessages |
+	discordgo.PermissionEmbedLinks |
+	discordgo.PermissionAttachFiles |
+	discordgo.PermissionManageMessages
+
 // Bot represents the Discord bot
 type Bot struct {
-	session      *discordgo.Session
-	nekosAPI     *api.Client
-	waifuAPI     *api.WaifuClient
-	fileMutex    sync.Mutex
-	activeFiles  map[string]time.Time
-	storage      *storage.Storage
-	dailyWebhook *webhook.DailyWebhook
-	scheduler    *scheduler.Scheduler
-}
-
-// New creates a new bot instance
-func New(token string) (*Bot, error) {
+	session           *discordgo.Session
+	nekosAPI          *api.Client
+	waifuAPI          *api.WaifuClient
+	fileMutex         sync.Mutex
+	activeFiles       map[string]trackedFile
+	storage           *storage.Storage
+	dailyWebhook      *webhook.DailyWebhook
+	scheduler         *scheduler.Scheduler
+	httpServer        *server.Server // nil unless HTTP_ADDR is set
+	timezone          string
+	prefix            string
+	errorEmbeds       bool // render errors as embeds rather than plain text
+	compressImages    bool // re-encode oversized images instead of skipping them
+	connectAttempts   int
+	connectBackoff    time.Duration
+	maxFileSize       int // optional cap on uploads in bytes, 0 means the Discord limit
+	cleanupInterval   time.Duration
+	maxFilesPerGuild  int           // cap on a guild's files on disk at once, 0 means no cap
+	randomWaifuWeight int           // percentage of /random picks served by Waifu.im
+	rejectBadCounts   bool          // reject message command counts out of range instead of clamping
+	allowNSFWDM       bool          // serve NSFW requests made in direct messages
+	keepImages        bool          // archive served pictures instead of deleting them
+	crosspost         bool          // publish daily posts in announcement channels
+	events            *eventEmitter // nil unless SERVED_EVENTS_PATH is set
+	shutdownBudget    time.Duration // time each subsystem gets to stop
+	alertChannelID    string
+	alertWebhookURL   string
+	ownerID           string
+	status            string     // activity shown in the member list
+	statusType        string     // one of statusTypes
+	toggleMutex       sync.Mutex // keeps the stored and in-memory webhook state in step
+	pingMutex         sync.Mutex
+	lastPing          map[string]time.Time // last /webhook-ping per guild
+	posted            *messageIndex        // picture messages by ID, for trash reactions
+	tagCache          tagCache
+	crossposts        crosspostLimiter
+	rerolls           rerollLimiter
+	rateLimits        rateLimits         // Discord rate limits hit, for /provider-status
+	memory            imageMemory        // image bytes held for uploads, see IMAGE_MEMORY_MB
+	requests          context.Context    // provider requests of commands, cancelled on shutdown
+	cancelRequests    context.CancelFunc // cancels requests
+	connected         atomic.Bool        // gateway connection is up, see sessionHealthy
+}
+
+// New creates a new bot instance from the loaded configuration
+func New(cfg config.Config) (*Bot, error) {
 	if err := os.MkdirAll(picturesDir, 0o755); err != nil {
 		return nil, fmt.Errorf("failed to create pictures directory: %w", err)
 	}
 
-	dg, err := discordgo.New("Bot " + token)
+	dg, err := discordgo.New("Bot " + cfg.Token)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Discord session: %w", err)
 	}
 
-	// Set intents for guild messages, direct messages, and message content
+	// Set intents for guild messages, direct messages, message content and reactions
 	// MessageContent intent is now enabled in Discord Developer Portal
-	dg.Identify.Intents = discordgo.IntentsGuildMessages | discordgo.IntentsDirectMessages | discordgo.IntentsMessageContent | discordgo.IntentsGuilds
+	dg.Identify.Intents = discordgo.IntentsGuildMessages | discordgo.IntentsDirectMessages | discordgo.IntentsMessageContent | discordgo.IntentsGuilds |
+		discordgo.IntentsGuildMessageReactions | discordgo.IntentsDirectMessageReactions
 
 	// Initialize storage
-	storageInstance, err := storage.New("settings/bot_settings.json")
+	storageInstance, err := storage.New(cfg.StoragePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize storage: %w", err)
 	}
 
+	// Open the served events log, if enabled
+	events, err := newEventEmitter(cfg.ServedEventsPath)
+	if err != nil {
+		return nil, err
+	}
+
 	// Initialize API clients
-	nekosAPI := api.New(userAgent)
-	waifuAPI := api.NewWaifuClient(userAgent)
+	nekosAPI := api.New(cfg.UserAgent)
+	waifuAPI := api.NewWaifuClient(cfg.UserAgent)
 
 	// Initialize webhook and scheduler
-	dailyWebhook := webhook.New(nekosAPI, waifuAPI)
-	schedulerInstance := scheduler.New(dailyWebhook)
+	dailyWebhook := webhook.New(nekosAPI, waifuAPI, cfg.Webhook)
+	schedulerInstance := scheduler.New(dailyWebhook, storageInstance, cfg.Webhook)
 
 	// Sync webhook enabled state with storage
-	dailyWebhook.SetEnabled(storageInstance.GetDailyWebhookEnabled())
+	syncDailyEnabled(dailyWebhook, storageInstance)
 
 	bot := &Bot{
-		session:      dg,
-		nekosAPI:     nekosAPI,
-		waifuAPI:     waifuAPI,
-		activeFiles:  make(map[string]time.Time),
-		storage:      storageInstance,
-		dailyWebhook: dailyWebhook,
-		scheduler:    schedulerInstance,
+		session:           dg,
+		nekosAPI:          nekosAPI,
+		waifuAPI:          waifuAPI,
+		activeFiles:       make(map[string]trackedFile),
+		lastPing:          make(map[string]time.Time),
+		posted:            newMessageIndex(postedMessageTTL, maxPostedMessages),
+		storage:           storageInstance,
+		dailyWebhook:      dailyWebhook,
+		scheduler:         schedulerInstance,
+		timezone:          cfg.Timezone,
+		prefix:            cfg.Prefix,
+		errorEmbeds:       cfg.ErrorEmbeds,
+		compressImages:    cfg.CompressImages,
+		connectAttempts:   cfg.ConnectAttempts,
+		connectBackoff:    cfg.ConnectBackoff,
+		maxFileSize:       cfg.MaxFileSize,
+		cleanupInterval:   cfg.CleanupInterval,
+		maxFilesPerGuild:  cfg.MaxFilesPerGuild,
+		randomWaifuWeight: cfg.RandomWaifuWeight,
+		rejectBadCounts:   cfg.RejectBadCounts,
+		allowNSFWDM:       cfg.AllowNSFWDM,
+		keepImages:        cfg.KeepImages,
+		crosspost:         cfg.Crosspost,
+		events:            events,
+		shutdownBudget:    cfg.ShutdownStep,
+		alertChannelID:    cfg.AlertChannelID,
+		alertWebhookURL:   cfg.AlertWebhookURL,
+		ownerID:           cfg.OwnerID,
+		status:            cfg.Status,
+		statusType:        cfg.StatusType,
+		memory:            imageMemory{limit: cfg.ImageMemory},
+	}
+	bot.requests, bot.cancelRequests = context.WithCancel(context.Background())
+
+	if !slices.Contains(statusTypes, bot.statusType) {
+		if bot.statusType != "" {
+			slog.Warn("Unknown BOT_STATUS_TYPE, using listening", "type", cfg.StatusType)
+		}
+		bot.statusType = statusListening
+	}
+
+	// Let the daily webhook post to DAILY_CHANNEL_ID through the bot session
+	dailyWebhook.SetChannelSender(bot.sendDailyToChannel)
+
+	// Remember what the daily webhook posted for /today
+	dailyWebhook.SetSentHook(bot.recordDailyPost)
+
+	// Tell operators when the daily webhook fails or a provider stays
+	// unreachable, if an alert target is set
+	if bot.alertChannelID != "" || bot.alertWebhookURL != "" {
+		schedulerInstance.SetAlerter(bot.sendAlert)
+		nekosAPI.MonitorHealth(cfg.ProviderAlert, bot.providerAlert)
+		waifuAPI.MonitorHealth(cfg.ProviderAlert, bot.providerAlert)
+	}
+
+	// Hold the daily post back while the gateway is down
+	schedulerInstance.SetHealthCheck(bot.sessionHealthy)
+
+	// Let external automation trigger the daily webhook over HTTP
+	if cfg.HTTPAddr != "" {
+		bot.httpServer = server.New(cfg.HTTPAddr, cfg.HTTPToken, schedulerInstance.ForceSend)
 	}
 
 	// Register handlers
 	dg.AddHandler(bot.readyHandler)
 	dg.AddHandler(bot.interactionHandler)
 	dg.AddHandler(bot.messageHandler)
+	dg.AddHandler(bot.reactionAddHandler)
+	dg.AddHandler(bot.rateLimitHandler)
+	dg.AddHandler(bot.resumedHandler)
+	dg.AddHandler(bot.disconnectHandler)
 
 	return bot, nil
 }
 
 // Start opens the websocket connection and registers slash commands
-func (b *Bot) Start(ctx context.Context, locEnv string) error {
-	if err := b.session.Open(); err != nil {
+func (b *Bot) Start(ctx context.Context) error {
+	if err := b.openSession(); err != nil {
 		return fmt.Errorf("failed to open connection: %w", err)
 	}
 
 	// Register slash commands
+	// A partially registered command set is not fatal, message commands keep working
 	if err := b.registerCommands(); err != nil {
-		return fmt.Errorf("failed to register commands: %w", err)
+		slog.Error("Failed to register some commands", "error", err)
 	}
 
-	// Start cleanup routine
-	go b.cleanupRoutine(ctx)
+	// Start cleanup routine, archived pictures are never cleaned up
+	if b.keepImages {
+		slog.Warn("KEEP_IMAGES is on, served pictures are kept and never deleted. Watch the disk usage.", "dir", filepath.Join(picturesDir, archiveDir))
+	} else {
+		go b.cleanupRoutine(ctx)
+	}
+
+	// Save the served request counters in batches
+	go b.statsFlushRoutine(ctx)
 
 	// Start scheduler
-	if err := b.scheduler.Start(ctx, locEnv); err != nil {
-		fmt.Printf("Warning: failed to start scheduler: %v\n", err)
+	if err := b.scheduler.Start(ctx, b.timezone); err != nil {
+		slog.Error("Failed to start scheduler", "error", err)
+	}
+
+	if b.httpServer != nil {
+		b.httpServer.Start()
 	}
 
 	return nil
 }
 
-// Stop closes the websocket connection and cleans up
-func (b *Bot) Stop(ctx context.Context) error {
-	// Stop scheduler
-	if err := b.scheduler.Stop(); err != nil {
-		fmt.Printf("Warning: failed to stop scheduler: %v\n", err)
-	}
+// fatalCloseCodes are the gateway close codes a retry can't fix, with a hint
+// at the cause
+var fatalCloseCodes = map[int]string{
+	4004: "authentication failed, check DISCORD_BOT_TOKEN",
+	4013: "invalid gateway intents",
+	4014: "disallowed gateway intents, enable the privileged intents in the Discord developer portal",
+}
 
-	// Unregister commands
-	if err := b.unregisterCommands(); err != nil {
-		fmt.Printf("Warning: failed to unregister commands: %v\n", err)
+// openSession opens the websocket connection, retrying transient failures
+// with exponential backoff. Authentication failures and gateway close codes
+// in fatalCloseCodes are returned at once.
+func (b *Bot) openSession() error {
+	backoff := b.connectBackoff
+	var err error
+	for attempt := 1; attempt <= b.connectAttempts; attempt++ {
+		slog.Info("Connecting to Discord", "attempt", attempt, "of", b.connectAttempts)
+		if err = b.session.Open(); err == nil {
+			return nil
+		}
+
+		var restErr *discordgo.RESTError
+		if errors.As(err, &restErr) && restErr.Response != nil &&
+			(restErr.Response.StatusCode == http.StatusUnauthorized || restErr.Response.StatusCode == http.StatusForbidden) {
+			return fmt.Errorf("authentication failed, check DISCORD_BOT_TOKEN: %w", err)
+		}
+		// The gateway closes the connection when it rejects the identify
+		var closeErr *websocket.CloseError
+		if errors.As(err, &closeErr) {
+			if hint, fatal := fatalCloseCodes[closeErr.Code]; fatal {
+				return fmt.Errorf("%s: %w", hint, err)
+			}
+		}
+		if errors.Is(err, discordgo.ErrWSAlreadyOpen) {
+			return nil
+		}
+
+		slog.Warn("Failed to connect to Discord", "attempt", attempt, "of", b.connectAttempts, "error", err)
+		if attempt < b.connectAttempts {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
 	}
+	return err
+}
 
-	b.cleanupAllFiles()
-	return b.session.Close()
+// Stop closes the websocket connection and cleans up
+func (b *Bot) Stop(ctx context.Context) error {
+	// Each subsystem gets its own budget, so a stuck one can't use up the
+	// whole shutdown deadline
+	var errs []error
+	for _, step := range b.shutdownSteps() {
+		if err := runShutdownStep(ctx, step, b.shutdownBudget); err != nil {
+			slog.Error("Failed to stop", "step", step.name, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", step.name, err))
+		}
+	}
+	return errors.Join(errs...)
 }
 
 // readyHandler is called when the bot is ready
 func (b *Bot) readyHandler(s *discordgo.Session, event *discordgo.Ready) {
-	fmt.Printf("Bot is ready! Logged in as %s#%s\n", event.User.Username, event.User.Discriminator)
+	slog.Info("Bot is ready!", "user", event.User.Username+"#"+event.User.Discriminator)
+	b.connected.Store(true)
 
 	// Set custom status
-	if err := s.UpdateListeningStatus(botStatus); err != nil {
-		fmt.Printf("Failed to set status: %v\n", err)
+	if err := b.updateStatus(s); err != nil {
+		slog.Warn("Failed to set status", "error", err)
+	}
+}
+
+// Activity types BOT_STATUS_TYPE accepts
+const (
+	statusPlaying   = "playing"
+	statusListening = "listening"
+	statusWatching  = "watching"
+)
+
+var statusTypes = []string{statusPlaying, statusListening, statusWatching}
+
+// updateStatus shows BOT_STATUS with the configured activity type
+func (b *Bot) updateStatus(s *discordgo.Session) error {
+	switch b.statusType {
+	case statusPlaying:
+		return s.UpdateGameStatus(0, b.status)
+	case statusWatching:
+		return s.UpdateWatchStatus(0, b.status)
+	default:
+		return s.UpdateListeningStatus(b.status)
 	}
 }
 
 // sendImagesMessage sends images via regular message
 func (b *Bot) sendImagesMessage(s *discordgo.Session, m *discordgo.MessageCreate, images []api.Image, message string) {
 	files := make([]*discordgo.File, 0, len(images))
+	limit := b.uploadLimitForGuild(m.GuildID)
+	skipped := 0
+	ids := make([]string, 0, len(images)) // IDs of the attached images, in order
+	var linked []string                   // images linked for lack of image memory
+
+	// Hold the image bytes against the memory budget until they are sent
+	reserved := 0
+	defer func() { b.memory.release(reserved) }()
+
+	// Record every image once the outcome is known, including early returns
+	attached := make([]bool, len(images))
+	uploaded := false
+	defer func() {
+		for n, img := range images {
+			b.events.emit(servedEvent{GuildID: m.GuildID, UserID: m.Author.ID, Provider: providerNekos, ImageID: img.ID, NSFW: img.NSFW, Success: uploaded && attached[n]})
+		}
+	}()
 
-	for _, img := range images {
+	for n, img := range images {
 		// Generate unique filename
 		filename := fmt.Sprintf("catgirl_%s_%d.jpg", img.ID, time.Now().Unix())
-		filepath := filepath.Join(picturesDir, filename)
 
-		// Download the image
-		imageData, err := b.nekosAPI.DownloadImage(img.ID)
-		if err != nil {
+		// Download the image, linking it instead while image memory is short
+		imageData, held, err := b.downloadReserved(func(reserve api.Reserve) ([]byte, error) {
+			return b.nekosAPI.DownloadImageReserved(b.requests, img.ID, b.downloadCap(limit), reserve)
+		})
+		switch {
+		case errors.Is(err, api.ErrNotReserved):
+			linked = append(linked, fmt.Sprintf("https://nekos.moe/image/%s.jpg", img.ID))
+			continue
+		case errors.Is(err, api.ErrTooLarge):
+			skipped++
 			continue
+		case err != nil:
+			continue
+		}
+		reserved += held
+
+		// Shrink or skip images over the upload limit instead of failing the whole batch
+		if len(imageData) > limit {
+			compressed, compressedName, ok := b.shrinkToLimit(imageData, filename, limit)
+			if !ok {
+				skipped++
+				continue
+			}
+			imageData, filename = compressed, compressedName
 		}
 
+		filepath := filepath.Join(picturesDir, filename)
+
 		// Save to file
 		if err := os.WriteFile(filepath, imageData, 0o644); err != nil {
 			continue
 		}
 
 		// Track file for cleanup
-		b.trackFile(filename)
+		b.trackFile(filename, m.GuildID)
 
 		// Create file
+		attached[n] = true
+		ids = append(ids, img.ID)
 		files = append(files, &discordgo.File{
 			Name:        filename,
 			ContentType: "image/jpg", // All images from nekos.moe are JPG
@@ -171,10 +412,21 @@ func (b *Bot) sendImagesMessage(s *discordgo.Session, m *discordgo.MessageCreate
 		go b.scheduleFileDeletion(filename, "")
 	}
 
-	// Send message with files only (no text content)
-	_, err := s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
-		Files: files,
+	// Only send if we have files to send
+	if len(files) == 0 {
+		b.sendNoFilesMessage(s, m, message, joinNotes(oversizedNote(skipped, limit), linkedNote(linked)))
+		return
+	}
+
+	// Send message with files, noting any skipped images
+	msg, err := retryRateLimited(&b.rateLimits, files, func(options ...discordgo.RequestOption) (*discordgo.Message, error) {
+		return s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+			Content: joinNotes(message, oversizedNote(skipped, limit), linkedNote(linked), b.captionNote(m.GuildID, ids)),
+			Files:   files,
+		}, options...)
 	})
+	uploaded = err == nil
+	b.trackPosted(msg, m.Author.ID, nekosPosted(images))
 	if err != nil {
 		// Fallback to URLs
 		var urls []string
@@ -182,49 +434,89 @@ func (b *Bot) sendImagesMessage(s *discordgo.Session, m *discordgo.MessageCreate
 			urls = append(urls, fmt.Sprintf("https://nekos.moe/image/%s.jpg", img.ID))
 		}
 
-		s.ChannelMessageSend(m.ChannelID, strings.Join(urls, "\n"))
+		content := joinNotes(urls...)
+		if content == "" {
+			b.sendError(s, m, noDownloadsText)
+			return
+		}
+		fallback, _ := s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+			Content: content,
+			Flags:   b.fallbackFlags(m.GuildID),
+		})
+		b.trackPosted(fallback, m.Author.ID, nekosPosted(images))
 	}
 }
 
 // sendWaifuImagesMessage sends waifu images via regular message
 func (b *Bot) sendWaifuImagesMessage(s *discordgo.Session, m *discordgo.MessageCreate, images []api.WaifuImage, message string) {
 	files := make([]*discordgo.File, 0, len(images))
+	limit := b.uploadLimitForGuild(m.GuildID)
+	skipped := 0
+	ids := make([]string, 0, len(images)) // IDs of the attached images, in order
+	var linked []string                   // images linked for lack of image memory
+
+	// Hold the image bytes against the memory budget until they are sent
+	reserved := 0
+	defer func() { b.memory.release(reserved) }()
+
+	// Record every image once the outcome is known, including early returns
+	attached := make([]bool, len(images))
+	uploaded := false
+	defer func() {
+		for n, img := range images {
+			b.events.emit(servedEvent{GuildID: m.GuildID, UserID: m.Author.ID, Provider: providerWaifu, ImageID: strconv.FormatInt(img.ID, 10), NSFW: img.IsNSFW, Success: uploaded && attached[n]})
+		}
+	}()
 
-	for _, img := range images {
+	for n, img := range images {
 		// Generate unique filename
-		filename := fmt.Sprintf("waifu_%d_%d%s", img.ID, time.Now().Unix(), img.Extension)
-		filepath := filepath.Join(picturesDir, filename)
+		filename := fmt.Sprintf("waifu_%d_%d%s", img.ID, time.Now().Unix(), waifuExtension(img))
 
-		// Download the image using the URL from the API response
-		imageData, err := b.waifuAPI.DownloadWaifuImage(img.URL)
-		if err != nil {
-			fmt.Printf("Warning: failed to download waifu image %d: %v\n", img.ID, err)
+		// Download the image using the URL from the API response, linking it
+		// instead while image memory is short
+		imageData, held, err := b.downloadReserved(func(reserve api.Reserve) ([]byte, error) {
+			return b.waifuAPI.DownloadWaifuImageReserved(b.requests, img.URL, b.downloadCap(limit), reserve)
+		})
+		switch {
+		case errors.Is(err, api.ErrNotReserved):
+			linked = append(linked, img.URL)
+			continue
+		case errors.Is(err, api.ErrTooLarge):
+			skipped++
+			continue
+		case err != nil:
+			slog.Warn("Failed to download waifu image", "image", img.ID, "error", err)
 			continue
 		}
+		reserved += held
+
+		// Shrink or skip images over the upload limit instead of failing the whole batch
+		if len(imageData) > limit {
+			compressed, compressedName, ok := b.shrinkToLimit(imageData, filename, limit)
+			if !ok {
+				skipped++
+				continue
+			}
+			imageData, filename = compressed, compressedName
+		}
+
+		filepath := filepath.Join(picturesDir, filename)
 
 		// Save to file (for debugging/cleanup)
 		if err := os.WriteFile(filepath, imageData, 0o644); err != nil {
-			fmt.Printf("Warning: failed to save waifu image %s: %v\n", filename, err)
+			slog.Warn("Failed to save waifu image", "file", filename, "error", err)
 			continue
 		}
 
 		// Track file for cleanup
-		b.trackFile(filename)
+		b.trackFile(filename, m.GuildID)
 
 		// Determine content type based on extension
-		contentType := "image/jpeg" // default fallback
-		switch strings.ToLower(img.Extension) {
-		case ".gif":
-			contentType = "image/gif"
-		case ".png":
-			contentType = "image/png"
-		case ".webp":
-			contentType = "image/webp"
-		case ".jpg", ".jpeg":
-			contentType = "image/jpeg"
-		}
+		contentType := contentTypeFor(filename)
 
 		// Create discordgo.File with the downloaded data
+		attached[n] = true
+		ids = append(ids, strconv.FormatInt(img.ID, 10))
 		files = append(files, &discordgo.File{
 			Name:        filename,
 			ContentType: contentType,
@@ -237,22 +529,36 @@ func (b *Bot) sendWaifuImagesMessage(s *discordgo.Session, m *discordgo.MessageC
 
 	// Only send if we have files to send
 	if len(files) == 0 {
-		s.ChannelMessageSend(m.ChannelID, "❌ Failed to download any images. Try again later.")
+		b.sendNoFilesMessage(s, m, message, joinNotes(oversizedNote(skipped, limit), linkedNote(linked)))
 		return
 	}
 
 	// Send message with files
-	_, err := s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
-		Files: files,
+	msg, err := retryRateLimited(&b.rateLimits, files, func(options ...discordgo.RequestOption) (*discordgo.Message, error) {
+		return s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+			Content: joinNotes(message, oversizedNote(skipped, limit), linkedNote(linked), b.captionNote(m.GuildID, ids)),
+			Files:   files,
+		}, options...)
 	})
+	uploaded = err == nil
+	b.trackPosted(msg, m.Author.ID, waifuPosted(images))
 	// Fallback to URLs only if sending files completely fails
 	if err != nil {
-		fmt.Printf("Warning: failed to send waifu images as files, falling back to URLs: %v\n", err)
+		slog.Warn("Failed to send waifu images as files, falling back to URLs", "error", err)
 		var urls []string
 		for _, img := range images {
 			urls = append(urls, img.URL)
 		}
-		s.ChannelMessageSend(m.ChannelID, strings.Join(urls, "\n"))
+		content := joinNotes(urls...)
+		if content == "" {
+			b.sendError(s, m, noDownloadsText)
+			return
+		}
+		fallback, _ := s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+			Content: content,
+			Flags:   b.fallbackFlags(m.GuildID),
+		})
+		b.trackPosted(fallback, m.Author.ID, waifuPosted(images))
 	}
 }
 
@@ -266,51 +572,94 @@ func (b *Bot) handleCatgirlMessageCommand(s *discordgo.Session, m *discordgo.Mes
 		}
 	}()
 
-	// Parse command arguments
-	args := strings.Fields(m.Content)
+	// Parse command arguments, rejecting abusive input
+	args := b.messageArgs(m.Content)
+	if err := validateArgs(args[1:]); err != nil {
+		b.sendError(s, m, fmt.Sprintf("Invalid arguments: %v", err))
+		return
+	}
 
-	var count int = 1     // Default count = 1     // Default count
-	var nsfw string = "n" // Default to SFW
+	// nekos.moe has no animated pictures, say so instead of ignoring "gif"
+	if slices.ContainsFunc(args[1:], isGIFArg) {
+		b.sendError(s, m, catgirlGIFText)
+		return
+	}
+
+	var count int = 1 // Default count = 1     // Default count
+	requested := ""   // rating asked for, empty for the guild default
 
-	// Parse count argument
+	// Parse count argument, clamping or rejecting counts out of range
+	countNote := ""
 	if len(args) > 1 {
-		if parsedCount, err := strconv.Atoi(args[1]); err == nil && parsedCount >= 1 && parsedCount <= 10 {
-			count = parsedCount
+		if parsedCount, err := strconv.Atoi(args[1]); err == nil {
+			count, countNote, err = resolveCount(parsedCount, b.rejectBadCounts)
+			if err != nil {
+				b.sendError(s, m, fmt.Sprintf("Invalid arguments: %v", err))
+				return
+			}
 		}
 	}
 
 	// Parse NSFW argument
 	if len(args) > 2 {
-		if strings.ToLower(args[2]) == "y" || strings.ToLower(args[2]) == "yes" {
-			nsfw = "y"
+		requested = ratingSafe
+		if parseYesNo(args[2]) {
+			requested = ratingExplicit
 		}
 	}
 
-	// Determine rating
-	rating := "safe"
-	if nsfw == "y" {
-		rating = "explicit"
+	// Determine rating, falling back to the guild default
+	rating := b.requestRating(s, m.GuildID, m.ChannelID, requested)
+	nsfw := rating != ratingSafe
+
+	// DMs have no age restriction, so NSFW there is opt-in
+	if nsfw && b.nsfwBlockedInDM(m.GuildID) {
+		b.sendError(s, m, nsfwDMText)
+		return
 	}
 
-	// Show typing indicator
-	s.ChannelTyping(m.ChannelID)
+	// Servers may require a role for NSFW requests
+	if nsfw {
+		if roleID := b.missingRole(m.GuildID, m.Member, roleGateNSFW); roleID != "" {
+			b.respondRoleGateMessage(s, m, roleID)
+			return
+		}
+	}
+
+	// Ask for confirmation first if the guild gates NSFW content
+	if nsfw && b.nsfwGated(m.GuildID, m.Author.ID) {
+		b.respondNSFWGateMessage(s, m)
+		return
+	}
+
+	// Clamp to the guild's limit for the resolved rating
+	count, limitNote := b.applyCountLimit(m.GuildID, count, nsfw)
+
+	// Keep one guild from filling the disk
+	if b.guildAtFileCap(m.GuildID) {
+		b.sendError(s, m, tooManyFilesText)
+		return
+	}
+
+	// Show typing indicator until the pictures are sent
+	stopTyping := keepTyping(s, m.ChannelID)
+	defer stopTyping()
 
 	// Fetch images
-	images, err := b.nekosAPI.GetRandomImages(count, rating)
+	images, err := b.nekosAPI.GetRandomImagesContext(b.requests, count, nekosRating(rating))
 	if err != nil {
-		content := fmt.Sprintf("Sorry, I couldn't fetch catgirl images: %v", err)
-		s.ChannelMessageSend(m.ChannelID, content)
+		b.sendError(s, m, b.fetchFailedText(m.GuildID, "catgirl", err))
 		return
 	}
 
 	if len(images) == 0 {
-		content := "Sorry, no catgirl images found!"
-		s.ChannelMessageSend(m.ChannelID, content)
+		b.sendError(s, m, b.noImagesText(m.GuildID, "catgirl"))
 		return
 	}
 
-	// Send images (no text content)
-	b.sendImagesMessage(s, m, images, "")
+	// Send images, with a note only if the count was clamped
+	b.recordServed(m.GuildID, len(images))
+	b.sendImagesMessage(s, m, images, joinNotes(countNote, limitNote))
 }
 
 // handleWaifuMessageCommand handles the !waifu message command
@@ -324,128 +673,128 @@ func (b *Bot) handleWaifuMessageCommand(s *discordgo.Session, m *discordgo.Messa
 	}()
 
 	// Parse command arguments - defaults: count=1, mode=SFW
-	args := strings.Fields(m.Content)
-	count := 1
-	contentMode := "sfw"
-
-	// Parse count argument (if present)
-	if len(args) > 1 {
-		if parsedCount, err := strconv.Atoi(args[1]); err == nil {
-			count = parsedCount
-			if count < 1 {
-				count = 1
-			}
-			if count > 10 {
-				count = 10
-			}
+	args := b.messageArgs(m.Content)
+	if err := validateArgs(args[1:]); err != nil {
+		b.sendError(s, m, fmt.Sprintf("Invalid arguments: %v", err))
+		return
+	}
+	// Parse the optional color:<name> filter (anywhere in the arguments)
+	opts := api.WaifuOptions{}
+	if color, ok, rest := cutOption(args, "color"); ok {
+		hex, err := colorHex(color)
+		if err != nil {
+			b.sendError(s, m, fmt.Sprintf("Invalid color: %v", err))
+			return
 		}
+		opts.DominantColor = hex
+		args = rest
 	}
 
-	// Parse content mode argument (if present)
-	if len(args) > 2 {
-		arg := strings.ToLower(args[2])
-		switch arg {
-		case "nsfw", "n", "ns":
-			contentMode = "nsfw"
-		case "all", "a", "both":
-			contentMode = "all"
-		case "sfw", "s", "safe":
-			contentMode = "sfw"
+	// Parse any not:<tag> exclusions (anywhere in the arguments)
+	var excluded []string
+	for {
+		tag, ok, rest := cutOption(args, "not")
+		if !ok {
+			break
 		}
+		excluded, args = append(excluded, tag), rest
+	}
+	if len(excluded) > 0 {
+		slugs, err := b.excludedTags(excluded, opts.Tag)
+		if err != nil {
+			b.sendError(s, m, fmt.Sprintf("Invalid tag: %v", err))
+			return
+		}
+		opts.ExcludedTags = slugs
+	}
+
+	// Parse count, content mode and gif in any order
+	count, contentMode, gif := parseWaifuArgs(args[1:])
+	opts.Animated = gif
+	if contentMode == "" {
+		contentMode = contentModeFor(b.requestRating(s, m.GuildID, m.ChannelID, ""))
+	}
+
+	count, countNote, err := resolveCount(count, b.rejectBadCounts)
+	if err != nil {
+		b.sendError(s, m, fmt.Sprintf("Invalid arguments: %v", err))
+		return
 	}
 
 	// Map string to NSFWMode
-	var mode api.NSFWMode
-	switch contentMode {
-	case "nsfw":
-		mode = api.NSFWModeNSFW
-	case "all":
-		mode = api.NSFWModeAll
-	default:
-		mode = api.NSFWModeSFW
+	opts.Mode = nsfwModeFor(contentMode)
+
+	// DMs have no age restriction, so NSFW there is opt-in
+	if opts.Mode != api.NSFWModeSFW && b.nsfwBlockedInDM(m.GuildID) {
+		b.sendError(s, m, nsfwDMText)
+		return
 	}
 
-	// Show typing indicator
-	s.ChannelTyping(m.ChannelID)
+	// Servers may require a role for NSFW requests
+	if opts.Mode != api.NSFWModeSFW {
+		if roleID := b.missingRole(m.GuildID, m.Member, roleGateNSFW); roleID != "" {
+			b.respondRoleGateMessage(s, m, roleID)
+			return
+		}
+	}
+
+	// Ask for confirmation first if the guild gates NSFW content
+	if opts.Mode != api.NSFWModeSFW && b.nsfwGated(m.GuildID, m.Author.ID) {
+		b.respondNSFWGateMessage(s, m)
+		return
+	}
+
+	// Clamp to the guild's limit for the resolved rating
+	count, limitNote := b.applyCountLimit(m.GuildID, count, opts.Mode != api.NSFWModeSFW)
+
+	// Keep one guild from filling the disk
+	if b.guildAtFileCap(m.GuildID) {
+		b.sendError(s, m, tooManyFilesText)
+		return
+	}
+
+	// Show typing indicator until the pictures are sent
+	stopTyping := keepTyping(s, m.ChannelID)
+	defer stopTyping()
 
 	// Fetch images
-	images, err := b.waifuAPI.GetWaifuImages(mode, count)
+	images, err := b.fetchWaifuImages(opts, count)
 	if err != nil {
-		content := fmt.Sprintf("Sorry, I couldn't fetch waifu images: %v", err)
-		s.ChannelMessageSend(m.ChannelID, content)
+		b.sendError(s, m, b.fetchFailedText(m.GuildID, "waifu", err))
 		return
 	}
 
 	if len(images) == 0 {
-		content := "Sorry, no waifu images found!"
-		s.ChannelMessageSend(m.ChannelID, content)
+		b.sendError(s, m, b.noImagesText(m.GuildID, "waifu"))
 		return
 	}
 
-	// Send images (no text content)
-	b.sendWaifuImagesMessage(s, m, images, "")
+	// Send images, noting if fewer matched than requested
+	b.recordServed(m.GuildID, len(images))
+	b.sendWaifuImagesMessage(s, m, images, joinNotes(countNote, limitNote, shortfallNote(len(images), count)))
 }
 
 // handleHelpMessageCommand handles the !help message command
 func (b *Bot) handleHelpMessageCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
-	helpText := "## 🌸 Kawaii Bot Help 🌸\n\n" +
-		"*Your personal anime picture companion!*\n\n" +
-		"### 📸 Available Commands\n\n" +
-		"**🐱 Catgirl Commands**\n" +
-		"├ `!catgirl [count] [nsfw]` - Message command\n" +
-		"└ `/catgirl <count> [nsfw]` - Slash command\n" +
-		"• **count**: 1-10 pictures (optional, defaults to 1)\n" +
-		"• **nsfw**: `y/yes` or `n/no` (optional, defaults to no)\n\n" +
-		"**💜 Waifu Commands**\n" +
-		"├ `!waifu [count] [content]` - Message command\n" +
-		"└ `/waifu [count] [content]` - Slash command\n" +
-		"• **count**: 1-10 pictures (optional, defaults to 1)\n" +
-		"• **content** (optional, defaults to SFW):\n" +
-		"  - `sfw` / `s` / `safe` - SFW only\n" +
-		"  - `nsfw` / `n` / `ns` - NSFW only\n" +
-		"  - `all` / `a` / `both` - Both SFW and NSFW\n\n" +
-		"**📅 Daily Webhook**\n" +
-		"├ `!webhook` - Toggle daily webhook (message command)\n" +
-		"└ `/webhook` - Toggle daily webhook (slash command)\n" +
-		"• Sends 1 waifu + 1 catgirl picture daily at 6 AM\n" +
-		"• Requires `WEBHOOK_URL` environment variable\n\n" +
-		"### 💡 Tips\n" +
-		"• Examples: `!waifu`, `!waifu 5`, `!waifu 3 nsfw`, `!waifu 7 all`\n" +
-		"• Slash command has dropdown menu for easy selection\n" +
-		"• Your command message will be automatically deleted\n\n" +
-		"*Powered by Nekos.moe API & Waifu.im* 💕"
-	s.ChannelMessageSend(m.ChannelID, helpText)
+	s.ChannelMessageSendEmbed(m.ChannelID, b.helpEmbed())
 }
 
 // handleWebhookMessageCommand handles the !webhook message command
 func (b *Bot) handleWebhookMessageCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
-	// Check if webhook URL is configured
-	_, url := b.dailyWebhook.GetStatus()
-	if url == "" {
-		s.ChannelMessageSend(m.ChannelID, "❌ Daily webhook is not configured. Please set the `WEBHOOK_URL` environment variable.")
+	// Check if a webhook URL or daily channel is configured
+	if !b.dailyWebhook.HasDestination() {
+		b.sendError(s, m, b.notConfiguredText())
 		return
 	}
 
 	// Toggle the webhook status
-	newState, err := b.storage.ToggleDailyWebhookEnabled()
+	newState, err := b.toggleDailyWebhook()
 	if err != nil {
-		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("❌ Failed to toggle webhook: %v", err))
+		b.sendError(s, m, fmt.Sprintf("Failed to toggle webhook: %v", err))
 		return
 	}
 
-	// Update the webhook enabled state
-	b.dailyWebhook.SetEnabled(newState)
-
-	// Create response message
-	status := "disabled"
-	emoji := "🔴"
-	if newState {
-		status = "enabled"
-		emoji = "🟢"
-	}
-
-	response := fmt.Sprintf("%s Daily webhook is now **%s**!\n\n📅 **Schedule**: Every day at 6\n🌸 **Content**: 1 waifu + 1 catgirl picture\n🔗 **Webhook URL**: `%s`", emoji, status, url)
-	s.ChannelMessageSend(m.ChannelID, response)
+	s.ChannelMessageSend(m.ChannelID, b.webhookStatusText(newState))
 }
 
 // messageHandler handles regular message commands
@@ -458,107 +807,87 @@ func (b *Bot) messageHandler(s *discordgo.Session, m *discordgo.MessageCreate) {
 	}
 
 	// Check for prefix commands
-	if strings.HasPrefix(m.Content, "!catgirl") {
+	if !strings.HasPrefix(m.Content, b.prefix) {
+		return
+	}
+	args := b.messageArgs(m.Content)
+	if len(args) == 0 {
+		return
+	}
+
+	command := strings.ToLower(args[0])
+
+	// Servers may require a role for picture commands
+	if roleID := b.missingRole(m.GuildID, m.Member, command); roleID != "" {
+		b.respondRoleGateMessage(s, m, roleID)
+		return
+	}
+
+	switch command {
+	case "catgirl":
 		b.handleCatgirlMessageCommand(s, m)
-	} else if strings.HasPrefix(m.Content, "!waifu") {
+	case "waifu":
 		b.handleWaifuMessageCommand(s, m)
-	} else if strings.HasPrefix(m.Content, "!help") {
+	case "help":
 		b.handleHelpMessageCommand(s, m)
-	} else if strings.HasPrefix(m.Content, "!webhook") {
+	case "webhook":
 		b.handleWebhookMessageCommand(s, m)
 	}
 }
 
-// registerCommands registers slash commands
+// messageArgs splits a prefixed message into the command name followed by its arguments
+func (b *Bot) messageArgs(content string) []string {
+	return strings.Fields(strings.TrimPrefix(content, b.prefix))
+}
+
+// registerCommands registers slash commands globally. It first tries a single
+// bulk overwrite and falls back to creating each command on its own with
+// retries, so one failing command doesn't leave the rest unregistered.
 func (b *Bot) registerCommands() error {
-	commands := []*discordgo.ApplicationCommand{
-		{
-			Name:        "catgirl",
-			Description: "Get adorable catgirl pictures 🐱",
-			Options: []*discordgo.ApplicationCommandOption{
-				{
-					Type:        discordgo.ApplicationCommandOptionInteger,
-					Name:        "count",
-					Description: "Number of pictures (1-10)",
-					Required:    false,
-					MinValue:    &[]float64{1}[0],
-					MaxValue:    10,
-				},
-				{
-					Type:        discordgo.ApplicationCommandOptionString,
-					Name:        "nsfw",
-					Description: "Include NSFW content? (y=yes/n=no, defaults to no)",
-					Required:    false,
-					Choices: []*discordgo.ApplicationCommandOptionChoice{
-						{
-							Name:  "Yes",
-							Value: "y",
-						},
-						{
-							Name:  "No",
-							Value: "n",
-						},
-					},
-				},
-			},
-		},
-		{
-			Name:        "waifu",
-			Description: "Get beautiful waifu pictures 💜",
-			Options: []*discordgo.ApplicationCommandOption{
-				{
-					Type:        discordgo.ApplicationCommandOptionInteger,
-					Name:        "count",
-					Description: "Number of pictures (1-10, default: 1)",
-					Required:    false,
-					MinValue:    &[]float64{1}[0],
-					MaxValue:    10,
-				},
-				{
-					Type:        discordgo.ApplicationCommandOptionString,
-					Name:        "content",
-					Description: "Content type (default: SFW)",
-					Required:    false,
-					Choices: []*discordgo.ApplicationCommandOptionChoice{
-						{
-							Name:  "SFW Only",
-							Value: "sfw",
-						},
-						{
-							Name:  "NSFW Only",
-							Value: "nsfw",
-						},
-						{
-							Name:  "All (SFW + NSFW)",
-							Value: "all",
-						},
-					},
-				},
-			},
-		},
-		{
-			Name:        "help",
-			Description: "Show help information about the bot",
-		},
-		{
-			Name:        "webhook",
-			Description: "Toggle daily webhook for waifu/catgirl pictures",
-		},
-		{
-			Name:        "forcewebhook",
-			Description: "force send a WebHook for testing",
-		},
-	}
+	appCommands := applicationCommands()
+	appID := b.session.State.User.ID
 
-	// Register commands globally
-	for _, cmd := range commands {
-		_, err := b.session.ApplicationCommandCreate(b.session.State.User.ID, "", cmd)
+	err := withRetry(registerAttempts, registerBackoff, func() error {
+		_, err := b.session.ApplicationCommandBulkOverwrite(appID, "", appCommands)
+		return err
+	})
+	if err == nil {
+		slog.Info("Registered commands", "count", len(appCommands))
+		return nil
+	}
+	slog.Warn("Bulk command registration failed, registering commands one by one", "error", err)
+
+	var errs []error
+	registered := make([]string, 0, len(appCommands))
+	for _, cmd := range appCommands {
+		err := withRetry(registerAttempts, registerBackoff, func() error {
+			_, err := b.session.ApplicationCommandCreate(appID, "", cmd)
+			return err
+		})
 		if err != nil {
-			return fmt.Errorf("failed to create command %s: %w", cmd.Name, err)
+			errs = append(errs, fmt.Errorf("failed to create command %s: %w", cmd.Name, err))
+			continue
 		}
+		registered = append(registered, cmd.Name)
 	}
 
-	return nil
+	slog.Info("Registered commands", "count", len(registered), "of", len(appCommands), "commands", strings.Join(registered, ", "))
+	return errors.Join(errs...)
+}
+
+// withRetry calls fn up to attempts times, doubling the wait after each failure
+func withRetry(attempts int, backoff time.Duration, fn func() error) error {
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+		if attempt < attempts {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+	return err
 }
 
 // unregisterCommands removes slash commands
@@ -570,7 +899,7 @@ func (b *Bot) unregisterCommands() error {
 
 	for _, cmd := range commands {
 		if err := b.session.ApplicationCommandDelete(b.session.State.User.ID, "", cmd.ID); err != nil {
-			fmt.Printf("Warning: failed to delete command %s: %v\n", cmd.Name, err)
+			slog.Warn("Failed to delete command", "command", cmd.Name, "error", err)
 		}
 	}
 
@@ -579,12 +908,22 @@ func (b *Bot) unregisterCommands() error {
 
 // interactionHandler handles slash command interactions
 func (b *Bot) interactionHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type == discordgo.InteractionMessageComponent {
+		b.componentHandler(s, i)
+		return
+	}
 	if i.Type != discordgo.InteractionApplicationCommand {
 		return
 	}
 
 	data := i.ApplicationCommandData()
 
+	// Servers may require a role for picture commands
+	if roleID := b.missingRole(i.GuildID, i.Member, data.Name); roleID != "" {
+		b.respondRoleGateInteraction(s, i, roleID)
+		return
+	}
+
 	switch data.Name {
 	case "catgirl":
 		b.handleCatgirlSlashCommand(s, i, data)
@@ -596,143 +935,351 @@ func (b *Bot) interactionHandler(s *discordgo.Session, i *discordgo.InteractionC
 		b.handleWebhookSlashCommand(s, i)
 	case "forcewebhook":
 		b.forceWebHookSlashCommand(s, i)
+	case "invite":
+		b.handleInviteSlashCommand(s, i)
+	case "providers":
+		b.handleProvidersSlashCommand(s, i)
+	case "nsfw-gate":
+		b.handleNSFWGateSlashCommand(s, i, data)
+	case "provider-status":
+		b.handleProviderStatusSlashCommand(s, i)
+	case "webhook-pause":
+		b.handleWebhookPauseSlashCommand(s, i, data)
+	case "daily-schedule":
+		b.handleDailyScheduleSlashCommand(s, i, data)
+	case "count-limits":
+		b.handleCountLimitsSlashCommand(s, i, data)
+	case "message-template":
+		b.handleMessageTemplateSlashCommand(s, i, data)
+	case "captions":
+		b.handleCaptionsSlashCommand(s, i, data)
+	case "default-rating":
+		b.handleDefaultRatingSlashCommand(s, i, data)
+	case "embed-size":
+		b.handleEmbedSizeSlashCommand(s, i, data)
+	case "strict-options":
+		b.handleStrictOptionsSlashCommand(s, i, data)
+	case "trash-reactions":
+		b.handleTrashReactionsSlashCommand(s, i, data)
+	case "webhook-time":
+		b.handleWebhookTimeSlashCommand(s, i, data)
+	case "webhook-ping":
+		b.handleWebhookPingSlashCommand(s, i, data)
+	case "webhook-snooze":
+		b.handleWebhookSnoozeSlashCommand(s, i)
+	case "webhook-retries":
+		b.handleWebhookRetriesSlashCommand(s, i, data)
+	case "webhook-skipdays":
+		b.handleWebhookSkipDaysSlashCommand(s, i, data)
+	case "top":
+		b.handleTopSlashCommand(s, i, data)
+	case "collage":
+		b.handleCollageSlashCommand(s, i, data)
+	case "random":
+		b.handleRandomSlashCommand(s, i)
+	case "by-artist":
+		b.handleByArtistSlashCommand(s, i, data)
+	case "role-gate":
+		b.handleRoleGateSlashCommand(s, i, data)
+	case "surprise":
+		b.handleSurpriseSlashCommand(s, i)
+	case "fullres":
+		b.handleFullresSlashCommand(s, i)
+	case fullresMenuName:
+		b.handleFullresMenuCommand(s, i, data)
+	case "today":
+		b.handleTodaySlashCommand(s, i)
+	case "diag":
+		b.handleDiagSlashCommand(s, i)
+	case "resources":
+		b.handleResourcesSlashCommand(s, i)
+	case "loglevel":
+		b.handleLogLevelSlashCommand(s, i, data)
+	case "waifu-info":
+		b.handleWaifuInfoSlashCommand(s, i, data)
+	case "config":
+		b.handleConfigSlashCommand(s, i)
+	case "link-previews":
+		b.handleLinkPreviewsSlashCommand(s, i, data)
+	case "stats-reset":
+		b.handleStatsResetSlashCommand(s, i)
 	}
 }
 
-// handleCatgirlSlashCommand handles the /catgirl slash command
-func (b *Bot) handleCatgirlSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) {
-	// Defer response to avoid timeout
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
-	})
-	if err != nil {
-		fmt.Printf("Failed to defer interaction: %v\n", err)
-		return
+// componentHandler handles message component interactions such as buttons
+func (b *Bot) componentHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	customID := i.MessageComponentData().CustomID
+
+	switch {
+	case strings.HasPrefix(customID, nsfwConfirmPrefix):
+		b.handleNSFWConfirmComponent(s, i, customID)
+	case strings.HasPrefix(customID, statsResetConfirmPrefix), strings.HasPrefix(customID, statsResetCancelPrefix):
+		b.handleStatsResetComponent(s, i, customID)
+	case customID == dailyRerollID:
+		b.handleDailyRerollComponent(s, i)
+	default:
+		// Buttons of removed or renamed features, or from an older version
+		slog.Warn("Unknown component clicked", "component", customID, "user", interactionUserID(i))
+		b.respondError(s, i, expiredComponentText)
 	}
+}
+
+// expiredComponentText answers clicks on buttons the bot no longer handles
+const expiredComponentText = "This button has expired. Run the command again to get a fresh one."
 
-	// Get options
-	var count int
-	var nsfw string = "n" // Default to SFW
+// handleCatgirlSlashCommand handles the /catgirl slash command
+func (b *Bot) handleCatgirlSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) {
+	// Get options - defaults: count=1, SFW
+	count := slashCount(data.Options)
+	requested := "" // rating asked for, empty for the guild default
 
+	parser := b.optionParser(i.GuildID)
 	for _, option := range data.Options {
 		switch option.Name {
-		case "count":
-			count = int(option.IntValue())
 		case "nsfw":
-			nsfw = strings.ToLower(strings.TrimSpace(option.StringValue()))
+			requested = ratingSafe
+			if parser.yesNo(option) {
+				requested = ratingExplicit
+			}
+		case "gif":
+			if option.BoolValue() {
+				b.respondError(s, i, catgirlGIFText)
+				return
+			}
 		}
 	}
+	if parser.problem != "" {
+		b.respondError(s, i, parser.problem)
+		return
+	}
 
-	// Validate and default NSFW parameter
-	if nsfw != "y" && nsfw != "n" {
-		nsfw = "n" // Default to SFW for any invalid input
+	// Determine rating, falling back to the guild default
+	rating := b.requestRating(s, i.GuildID, i.ChannelID, requested)
+	nsfw := rating != ratingSafe
+
+	// DMs have no age restriction, so NSFW there is opt-in
+	if nsfw && b.nsfwBlockedInDM(i.GuildID) {
+		b.respondError(s, i, nsfwDMText)
+		return
 	}
 
-	// Determine rating
-	rating := "safe"
-	if nsfw == "y" {
-		rating = "explicit"
+	// Servers may require a role for NSFW requests
+	if nsfw {
+		if roleID := b.missingRole(i.GuildID, i.Member, roleGateNSFW); roleID != "" {
+			b.respondRoleGateInteraction(s, i, roleID)
+			return
+		}
+	}
+
+	// Ask for confirmation first if the guild gates NSFW content
+	if nsfw && b.nsfwGated(i.GuildID, interactionUserID(i)) {
+		b.respondNSFWGateInteraction(s, i)
+		return
+	}
+
+	// Clamp to the guild's limit for the resolved rating
+	count, limitNote := b.applyCountLimit(i.GuildID, count, nsfw)
+
+	// Keep one guild from filling the disk
+	if b.guildAtFileCap(i.GuildID) {
+		b.respondError(s, i, tooManyFilesText)
+		return
+	}
+
+	// Defer response to avoid timeout
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		slog.Warn("Failed to defer interaction", "error", err)
+		return
 	}
 
 	// Show typing indicator
 	s.ChannelTyping(i.ChannelID)
 
 	// Fetch images
-	images, err := b.nekosAPI.GetRandomImages(count, rating)
+	images, err := b.nekosAPI.GetRandomImagesContext(b.requests, count, nekosRating(rating))
 	if err != nil {
-		content := fmt.Sprintf("Sorry, I couldn't fetch catgirl images: %v", err)
-		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
-			Content: &content,
-		})
+		b.editError(s, i, b.fetchFailedText(i.GuildID, "catgirl", err))
 		return
 	}
 
 	if len(images) == 0 {
-		content := "Sorry, no catgirl images found!"
-		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
-			Content: &content,
-		})
+		b.editError(s, i, b.noImagesText(i.GuildID, "catgirl"))
 		return
 	}
 
 	// Send images (no text content)
-	b.sendImagesInteraction(s, i, images, "")
+	b.recordServed(i.GuildID, len(images))
+	b.sendImagesInteraction(s, i, images, limitNote)
 }
 
 // handleWaifuSlashCommand handles the /waifu slash command
 func (b *Bot) handleWaifuSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) {
-	// Defer response to avoid timeout
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
-	})
-	if err != nil {
-		fmt.Printf("Failed to defer interaction: %v\n", err)
+	// Get options - defaults: count=1, mode=SFW
+	count := slashCount(data.Options)
+	contentMode := "" // empty for the guild default
+	color := ""
+	exclude := "" // comma separated tags to leave out
+	gif := false
+
+	parser := b.optionParser(i.GuildID)
+	for _, option := range data.Options {
+		if option.Name == "content" {
+			contentMode = parser.choice(option, "sfw", "nsfw", "all")
+		}
+		if option.Name == "color" {
+			color = option.StringValue()
+		}
+		if option.Name == "gif" {
+			gif = option.BoolValue()
+		}
+		if option.Name == "exclude" {
+			exclude = option.StringValue()
+		}
+	}
+	if parser.problem != "" {
+		b.respondError(s, i, parser.problem)
 		return
 	}
 
-	// Get options - defaults: count=1, mode=SFW
-	count := 1
-	contentMode := "sfw"
+	// Map string to NSFWMode, falling back to the guild default
+	if contentMode == "" {
+		contentMode = contentModeFor(b.requestRating(s, i.GuildID, i.ChannelID, ""))
+	}
+	opts := api.WaifuOptions{Mode: nsfwModeFor(contentMode), Animated: gif}
 
-	for _, option := range data.Options {
-		if option.Name == "count" {
-			count = int(option.IntValue())
+	// Map the color name to the hex value waifu.im expects
+	if color != "" {
+		hex, err := colorHex(color)
+		if err != nil {
+			b.respondError(s, i, fmt.Sprintf("Invalid color: %v", err))
+			return
 		}
-		if option.Name == "content" {
-			contentMode = strings.ToLower(strings.TrimSpace(option.StringValue()))
+		opts.DominantColor = hex
+	}
+
+	// DMs have no age restriction, so NSFW there is opt-in
+	if opts.Mode != api.NSFWModeSFW && b.nsfwBlockedInDM(i.GuildID) {
+		b.respondError(s, i, nsfwDMText)
+		return
+	}
+
+	// Servers may require a role for NSFW requests
+	if opts.Mode != api.NSFWModeSFW {
+		if roleID := b.missingRole(i.GuildID, i.Member, roleGateNSFW); roleID != "" {
+			b.respondRoleGateInteraction(s, i, roleID)
+			return
 		}
 	}
 
-	// Map string to NSFWMode
-	var mode api.NSFWMode
-	switch contentMode {
-	case "nsfw":
-		mode = api.NSFWModeNSFW
-	case "all":
-		mode = api.NSFWModeAll
-	default:
-		mode = api.NSFWModeSFW
+	// Ask for confirmation first if the guild gates NSFW content
+	if opts.Mode != api.NSFWModeSFW && b.nsfwGated(i.GuildID, interactionUserID(i)) {
+		b.respondNSFWGateInteraction(s, i)
+		return
+	}
+
+	// Clamp to the guild's limit for the resolved rating
+	count, limitNote := b.applyCountLimit(i.GuildID, count, opts.Mode != api.NSFWModeSFW)
+
+	// Keep one guild from filling the disk
+	if b.guildAtFileCap(i.GuildID) {
+		b.respondError(s, i, tooManyFilesText)
+		return
+	}
+
+	// Defer response to avoid timeout
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		slog.Warn("Failed to defer interaction", "error", err)
+		return
 	}
 
 	// Show typing indicator
 	s.ChannelTyping(i.ChannelID)
 
+	// Check the exclusions against the tag list, which may need fetching
+	if exclude != "" {
+		slugs, err := b.excludedTags(strings.Split(exclude, ","), opts.Tag)
+		if err != nil {
+			b.editError(s, i, fmt.Sprintf("Invalid tag: %v", err))
+			return
+		}
+		opts.ExcludedTags = slugs
+	}
+
 	// Fetch images
-	images, err := b.waifuAPI.GetWaifuImages(mode, count)
+	images, err := b.fetchWaifuImages(opts, count)
 	if err != nil {
-		content := fmt.Sprintf("Sorry, I couldn't fetch waifu images: %v", err)
-		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
-			Content: &content,
-		})
+		b.editError(s, i, b.fetchFailedText(i.GuildID, "waifu", err))
 		return
 	}
 
 	if len(images) == 0 {
-		content := "Sorry, no waifu images found!"
-		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
-			Content: &content,
-		})
+		b.editError(s, i, b.noImagesText(i.GuildID, "waifu"))
 		return
 	}
 
-	// Send images (no text content)
-	b.sendWaifuImagesInteraction(s, i, images, "")
+	// Send images, noting if fewer matched than requested
+	b.recordServed(i.GuildID, len(images))
+	b.sendWaifuImagesInteraction(s, i, images, joinNotes(limitNote, shortfallNote(len(images), count)))
 }
 
 // sendImagesInteraction sends images via interaction webhook
 func (b *Bot) sendImagesInteraction(s *discordgo.Session, i *discordgo.InteractionCreate, images []api.Image, message string) {
 	files := make([]*discordgo.File, 0, len(images))
+	limit := b.uploadLimitForGuild(i.GuildID)
+	skipped := 0
+	ids := make([]string, 0, len(images)) // IDs of the attached images, in order
+	var linked []string                   // images linked for lack of image memory
+
+	// Hold the image bytes against the memory budget until they are sent
+	reserved := 0
+	defer func() { b.memory.release(reserved) }()
+
+	// Record every image once the outcome is known, including early returns
+	attached := make([]bool, len(images))
+	uploaded := false
+	defer func() {
+		for n, img := range images {
+			b.events.emit(servedEvent{GuildID: i.GuildID, UserID: interactionUserID(i), Provider: providerNekos, ImageID: img.ID, NSFW: img.NSFW, Success: uploaded && attached[n]})
+		}
+	}()
 
-	for _, img := range images {
+	for n, img := range images {
 		// Generate unique filename
 		filename := fmt.Sprintf("catgirl_%s_%d.jpg", img.ID, time.Now().Unix())
-		filepath := filepath.Join(picturesDir, filename)
 
-		// Download the image
-		imageData, err := b.nekosAPI.DownloadImage(img.ID)
-		if err != nil {
+		// Download the image, linking it instead while image memory is short
+		imageData, held, err := b.downloadReserved(func(reserve api.Reserve) ([]byte, error) {
+			return b.nekosAPI.DownloadImageReserved(b.requests, img.ID, b.downloadCap(limit), reserve)
+		})
+		switch {
+		case errors.Is(err, api.ErrNotReserved):
+			linked = append(linked, fmt.Sprintf("https://nekos.moe/image/%s.jpg", img.ID))
+			continue
+		case errors.Is(err, api.ErrTooLarge):
+			skipped++
+			continue
+		case err != nil:
 			continue
 		}
+		reserved += held
+
+		// Shrink or skip images over the upload limit instead of failing the whole batch
+		if len(imageData) > limit {
+			compressed, compressedName, ok := b.shrinkToLimit(imageData, filename, limit)
+			if !ok {
+				skipped++
+				continue
+			}
+			imageData, filename = compressed, compressedName
+		}
+
+		filepath := filepath.Join(picturesDir, filename)
 
 		// Save to file
 		if err := os.WriteFile(filepath, imageData, 0o644); err != nil {
@@ -740,9 +1287,11 @@ func (b *Bot) sendImagesInteraction(s *discordgo.Session, i *discordgo.Interacti
 		}
 
 		// Track file for cleanup
-		b.trackFile(filename)
+		b.trackFile(filename, i.GuildID)
 
 		// Create file
+		attached[n] = true
+		ids = append(ids, img.ID)
 		files = append(files, &discordgo.File{
 			Name:        filename,
 			ContentType: "image/jpg", // All images from nekos.moe are JPG
@@ -753,10 +1302,21 @@ func (b *Bot) sendImagesInteraction(s *discordgo.Session, i *discordgo.Interacti
 		go b.scheduleFileDeletion(filename, "")
 	}
 
-	// Send follow-up message with files only (no text content)
-	_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-		Files: files,
+	// Only send if we have files to send
+	if len(files) == 0 {
+		b.sendNoFilesInteraction(s, i, message, joinNotes(oversizedNote(skipped, limit), linkedNote(linked)))
+		return
+	}
+
+	// Send follow-up message with files, noting any skipped images
+	msg, err := retryRateLimited(&b.rateLimits, files, func(options ...discordgo.RequestOption) (*discordgo.Message, error) {
+		return s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: joinNotes(message, oversizedNote(skipped, limit), linkedNote(linked), b.captionNote(i.GuildID, ids)),
+			Files:   files,
+		}, options...)
 	})
+	uploaded = err == nil
+	b.trackPosted(msg, interactionUserID(i), nekosPosted(images))
 	if err != nil {
 		// Fallback to URLs (no text content)
 		var urls []string
@@ -764,26 +1324,71 @@ func (b *Bot) sendImagesInteraction(s *discordgo.Session, i *discordgo.Interacti
 			urls = append(urls, fmt.Sprintf("https://nekos.moe/image/%s.jpg", img.ID))
 		}
 
-		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-			Content: strings.Join(urls, "\n"),
+		content := joinNotes(urls...)
+		if content == "" {
+			b.editError(s, i, noDownloadsText)
+			return
+		}
+		fallback, _ := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: content,
+			Flags:   b.fallbackFlags(i.GuildID),
 		})
+		b.trackPosted(fallback, interactionUserID(i), nekosPosted(images))
 	}
 }
 
 // sendWaifuImagesInteraction sends waifu images via interaction webhook
 func (b *Bot) sendWaifuImagesInteraction(s *discordgo.Session, i *discordgo.InteractionCreate, images []api.WaifuImage, message string) {
 	files := make([]*discordgo.File, 0, len(images))
+	limit := b.uploadLimitForGuild(i.GuildID)
+	skipped := 0
+	ids := make([]string, 0, len(images)) // IDs of the attached images, in order
+	var linked []string                   // images linked for lack of image memory
+
+	// Hold the image bytes against the memory budget until they are sent
+	reserved := 0
+	defer func() { b.memory.release(reserved) }()
+
+	// Record every image once the outcome is known, including early returns
+	attached := make([]bool, len(images))
+	uploaded := false
+	defer func() {
+		for n, img := range images {
+			b.events.emit(servedEvent{GuildID: i.GuildID, UserID: interactionUserID(i), Provider: providerWaifu, ImageID: strconv.FormatInt(img.ID, 10), NSFW: img.IsNSFW, Success: uploaded && attached[n]})
+		}
+	}()
 
-	for _, img := range images {
+	for n, img := range images {
 		// Generate unique filename
-		filename := fmt.Sprintf("waifu_%d_%d%s", img.ID, time.Now().Unix(), img.Extension)
-		filepath := filepath.Join(picturesDir, filename)
+		filename := fmt.Sprintf("waifu_%d_%d%s", img.ID, time.Now().Unix(), waifuExtension(img))
 
-		// Download the image
-		imageData, err := b.waifuAPI.DownloadWaifuImage(img.URL)
-		if err != nil {
+		// Download the image, linking it instead while image memory is short
+		imageData, held, err := b.downloadReserved(func(reserve api.Reserve) ([]byte, error) {
+			return b.waifuAPI.DownloadWaifuImageReserved(b.requests, img.URL, b.downloadCap(limit), reserve)
+		})
+		switch {
+		case errors.Is(err, api.ErrNotReserved):
+			linked = append(linked, img.URL)
+			continue
+		case errors.Is(err, api.ErrTooLarge):
+			skipped++
+			continue
+		case err != nil:
 			continue
 		}
+		reserved += held
+
+		// Shrink or skip images over the upload limit instead of failing the whole batch
+		if len(imageData) > limit {
+			compressed, compressedName, ok := b.shrinkToLimit(imageData, filename, limit)
+			if !ok {
+				skipped++
+				continue
+			}
+			imageData, filename = compressed, compressedName
+		}
+
+		filepath := filepath.Join(picturesDir, filename)
 
 		// Save to file
 		if err := os.WriteFile(filepath, imageData, 0o644); err != nil {
@@ -791,20 +1396,14 @@ func (b *Bot) sendWaifuImagesInteraction(s *discordgo.Session, i *discordgo.Inte
 		}
 
 		// Track file for cleanup
-		b.trackFile(filename)
+		b.trackFile(filename, i.GuildID)
 
 		// Determine content type based on extension
-		contentType := "image/jpeg" // default
-		switch img.Extension {
-		case ".gif":
-			contentType = "image/gif"
-		case ".png":
-			contentType = "image/png"
-		case ".webp":
-			contentType = "image/webp"
-		}
+		contentType := contentTypeFor(filename)
 
 		// Create file
+		attached[n] = true
+		ids = append(ids, strconv.FormatInt(img.ID, 10))
 		files = append(files, &discordgo.File{
 			Name:        filename,
 			ContentType: contentType,
@@ -815,10 +1414,21 @@ func (b *Bot) sendWaifuImagesInteraction(s *discordgo.Session, i *discordgo.Inte
 		go b.scheduleFileDeletion(filename, "")
 	}
 
-	// Send follow-up message with files only (no text content)
-	_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-		Files: files,
+	// Only send if we have files to send
+	if len(files) == 0 {
+		b.sendNoFilesInteraction(s, i, message, joinNotes(oversizedNote(skipped, limit), linkedNote(linked)))
+		return
+	}
+
+	// Send follow-up message with files, noting any skipped images
+	msg, err := retryRateLimited(&b.rateLimits, files, func(options ...discordgo.RequestOption) (*discordgo.Message, error) {
+		return s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: joinNotes(message, oversizedNote(skipped, limit), linkedNote(linked), b.captionNote(i.GuildID, ids)),
+			Files:   files,
+		}, options...)
 	})
+	uploaded = err == nil
+	b.trackPosted(msg, interactionUserID(i), waifuPosted(images))
 	if err != nil {
 		// Fallback to URLs (no text content)
 		var urls []string
@@ -826,55 +1436,82 @@ func (b *Bot) sendWaifuImagesInteraction(s *discordgo.Session, i *discordgo.Inte
 			urls = append(urls, img.URL)
 		}
 
-		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-			Content: strings.Join(urls, "\n"),
+		content := joinNotes(urls...)
+		if content == "" {
+			b.editError(s, i, noDownloadsText)
+			return
+		}
+		fallback, _ := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: content,
+			Flags:   b.fallbackFlags(i.GuildID),
 		})
+		b.trackPosted(fallback, interactionUserID(i), waifuPosted(images))
 	}
 }
 
 // handleHelpSlashCommand handles the /help slash command
 func (b *Bot) handleHelpSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	helpText := "## 🌸 Kawaii Bot Help 🌸\n\n" +
-		"*Your personal anime picture companion!*\n\n" +
-		"### 📸 Available Commands\n\n" +
-		"**🐱 Catgirl Command**\n" +
-		"`/catgirl <count> [nsfw]` - Get catgirl pictures\n" +
-		"• **count**: 1-10 pictures (required)\n" +
-		"• **nsfw**: `y/yes` or `n/no` (optional, defaults to no)\n\n" +
-		"**💜 Waifu Command**\n" +
-		"`/waifu <count> [nsfw] [gif]` - Get waifu pictures\n" +
-		"• **count**: 1-10 pictures (required)\n" +
-		"• **nsfw**: `y/yes` or `n/no` (optional, defaults to no)\n" +
-		"• **gif**: `y/yes` or `n/no` (optional, defaults to no)\n\n" +
-		"**📅 Daily Webhook**\n" +
-		"`/webhook` - Toggle daily webhook\n" +
-		"• Sends 1 waifu + 1 catgirl picture daily at 6 AM\n" +
-		"• Requires `WEBHOOK_URL` environment variable\n\n" +
-		"*Powered by Nekos.moe API & Waifu.im* 💕"
-
 	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
-			Content: helpText,
+			Embeds: []*discordgo.MessageEmbed{b.helpEmbed()},
 		},
 	})
 }
 
 func (b *Bot) forceWebHookSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	b.scheduler.ForceSend()
+	// Defer response, starting the send may take a moment
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		slog.Warn("Failed to defer interaction", "error", err)
+		return
+	}
+
+	if !b.dailyWebhook.HasDestination() {
+		b.editError(s, i, b.notConfiguredText())
+		return
+	}
+	if err := b.scheduler.ForceSend(); errors.Is(err, scheduler.ErrSendRunning) {
+		b.editError(s, i, "The daily webhook is being sent right now, try again once it is done.")
+		return
+	} else if err != nil {
+		b.editError(s, i, "The daily webhook is disabled. Enable it with `/webhook` first.")
+		return
+	}
+
+	content := "📤 Sending the daily webhook now."
+	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: &content,
+	})
+}
+
+// handleInviteSlashCommand handles the /invite slash command
+func (b *Bot) handleInviteSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
-			Content: "force sending Webhook",
+			Content: fmt.Sprintf("💌 Add me to your server: %s", inviteURL(s.State.User.ID)),
+			Flags:   discordgo.MessageFlagsEphemeral,
 		},
 	})
 }
 
+// inviteURL builds the OAuth2 URL for adding the bot to a server
+func inviteURL(clientID string) string {
+	params := url.Values{}
+	params.Set("client_id", clientID)
+	params.Set("scope", "bot applications.commands")
+	params.Set("permissions", strconv.FormatInt(invitePermissions, 10))
+	return "https://discord.com/oauth2/authorize?" + params.Encode()
+}
+
 // File management methods
-func (b *Bot) trackFile(filename string) {
+func (b *Bot) trackFile(filename, guildID string) {
 	b.fileMutex.Lock()
 	defer b.fileMutex.Unlock()
-	b.activeFiles[filename] = time.Now()
+	b.activeFiles[filename] = trackedFile{GuildID: guildID, Created: time.Now()}
 }
 
 func (b *Bot) scheduleFileDeletion(filename string, messageID string) {
@@ -886,9 +1523,15 @@ func (b *Bot) deleteFile(filename string) {
 	b.fileMutex.Lock()
 	defer b.fileMutex.Unlock()
 
-	filepath := filepath.Join(picturesDir, filename)
-	if err := os.Remove(filepath); err != nil {
-		fmt.Printf("Warning: failed to delete file %s: %v\n", filename, err)
+	if b.keepImages {
+		if err := archiveFile(filename); err != nil {
+			slog.Warn("Failed to archive file", "file", filename, "error", err)
+		}
+	} else {
+		filepath := filepath.Join(picturesDir, filename)
+		if err := os.Remove(filepath); err != nil {
+			slog.Warn("Failed to delete file", "file", filename, "error", err)
+		}
 	}
 
 	delete(b.activeFiles, filename)
@@ -907,75 +1550,62 @@ func (b *Bot) cleanupAllFiles() {
 	}
 }
 
+// cleanupRoutine periodically removes old pictures. The interval doubles while
+// no files are active, up to maxCleanupInterval (maxFileAge), and resets once
+// files appear.
 func (b *Bot) cleanupRoutine(ctx context.Context) {
-	ticker := time.NewTicker(1 * time.Minute)
-	defer ticker.Stop()
+	interval := b.cleanupInterval
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case <-ticker.C:
-			b.cleanupOldFiles()
+		case <-timer.C:
+			if b.cleanupOldFiles() == 0 {
+				interval = min(interval*2, max(maxCleanupInterval, b.cleanupInterval))
+			} else {
+				interval = b.cleanupInterval
+			}
+			timer.Reset(interval)
 		}
 	}
 }
 
 // handleWebhookSlashCommand handles the /webhook slash command
 func (b *Bot) handleWebhookSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	// Check if webhook URL is configured
-	_, url := b.dailyWebhook.GetStatus()
-	if url == "" {
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: "❌ Daily webhook is not configured. Please set the `WEBHOOK_URL` environment variable.",
-			},
-		})
+	// Check if a webhook URL or daily channel is configured
+	if !b.dailyWebhook.HasDestination() {
+		b.respondError(s, i, b.notConfiguredText())
 		return
 	}
 
 	// Toggle the webhook status
-	newState, err := b.storage.ToggleDailyWebhookEnabled()
+	newState, err := b.toggleDailyWebhook()
 	if err != nil {
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: fmt.Sprintf("❌ Failed to toggle webhook: %v", err),
-			},
-		})
+		b.respondError(s, i, fmt.Sprintf("Failed to toggle webhook: %v", err))
 		return
 	}
 
-	// Update the webhook enabled state
-	b.dailyWebhook.SetEnabled(newState)
-
-	// Create response message
-	status := "disabled"
-	emoji := "🔴"
-	if newState {
-		status = "enabled"
-		emoji = "🟢"
-	}
-
-	response := fmt.Sprintf("%s Daily webhook is now **%s**!\n\n📅 **Schedule**: Every day at midnight\n🌸 **Content**: 1 waifu + 1 catgirl picture\n🔗 **Webhook URL**: `%s`", emoji, status, url)
-
 	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
-			Content: response,
+			Content: b.webhookStatusText(newState),
 		},
 	})
 }
 
-func (b *Bot) cleanupOldFiles() {
+// cleanupOldFiles deletes expired files and returns how many files were active
+func (b *Bot) cleanupOldFiles() int {
 	b.fileMutex.Lock()
 	defer b.fileMutex.Unlock()
 
 	now := time.Now()
-	for filename, createdTime := range b.activeFiles {
-		if now.Sub(createdTime) > maxFileAge {
+	for filename, file := range b.activeFiles {
+		if now.Sub(file.Created) > maxFileAge {
 			go b.deleteFile(filename)
 		}
 	}
+	return len(b.activeFiles)
 }
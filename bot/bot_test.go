@@ -0,0 +1,45 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestCatgirlGIFRequest(t *testing.T) {
+	t.Run("message", func(t *testing.T) {
+		posts := stubDiscord(t)
+		s, _ := discordgo.New("Bot test")
+		b := &Bot{prefix: "!"}
+		m := &discordgo.MessageCreate{Message: &discordgo.Message{ID: "m", ChannelID: "c", Content: "!catgirl 2 gif", Author: &discordgo.User{ID: "u"}}}
+
+		b.handleCatgirlMessageCommand(s, m)
+
+		var sent []discordPost
+		for _, post := range posts() {
+			if post.Method == "POST" {
+				sent = append(sent, post)
+			}
+		}
+		if len(sent) != 1 || sent[0].Content != "❌ "+catgirlGIFText {
+			t.Errorf("posted %+v, want only the no-GIFs note", sent)
+		}
+	})
+
+	t.Run("slash", func(t *testing.T) {
+		posts := stubDiscord(t)
+		s, _ := discordgo.New("Bot test")
+		b := &Bot{}
+		i := &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{ID: "i", Token: "token", User: &discordgo.User{ID: "u"}}}
+		data := discordgo.ApplicationCommandInteractionData{Name: "catgirl", Options: []*discordgo.ApplicationCommandInteractionDataOption{
+			{Name: "gif", Type: discordgo.ApplicationCommandOptionBoolean, Value: true},
+		}}
+
+		b.handleCatgirlSlashCommand(s, i, data)
+
+		got := posts()
+		if len(got) != 1 || got[0].Data.Content != "❌ "+catgirlGIFText {
+			t.Errorf("posted %+v, want only the no-GIFs note", got)
+		}
+	})
+}
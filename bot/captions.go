@@ -0,0 +1,56 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// captionNote numbers the images of a multi-image post with their IDs, so
+// users can refer to a specific one. It is empty unless the guild turned
+// captions on.
+func (b *Bot) captionNote(guildID string, ids []string) string {
+	if len(ids) < 2 || guildID == "" || !b.storage.GetGuildSettings(guildID).ImageCaptions {
+		return ""
+	}
+
+	lines := make([]string, len(ids))
+	for n, id := range ids {
+		lines[n] = fmt.Sprintf("Image %d of %d — ID `%s`", n+1, len(ids), id)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// handleCaptionsSlashCommand handles the /captions slash command
+func (b *Bot) handleCaptionsSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) {
+	if i.GuildID == "" || !isGuildAdmin(i) {
+		b.respondError(s, i, "Only server admins can change image captions.")
+		return
+	}
+
+	enabled := false
+	for _, option := range data.Options {
+		if option.Name == "state" {
+			enabled = option.StringValue() == "on"
+		}
+	}
+
+	if err := b.storage.SetImageCaptions(i.GuildID, enabled); err != nil {
+		b.respondError(s, i, fmt.Sprintf("Failed to update image captions: %v", err))
+		return
+	}
+
+	content := "🏷️ Image captions are now **off**."
+	if enabled {
+		content = "🏷️ Image captions are now **on**, multi-image posts list each picture's number and ID."
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
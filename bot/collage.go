@@ -0,0 +1,220 @@
+package bot
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Collage layout limits
+const (
+	maxCollageImages = 4
+	collageTileSize  = 512 // width and height of one tile in pixels
+	collageQuality   = 85
+)
+
+// collageGrid returns the columns and rows used for n tiles
+func collageGrid(n int) (int, int) {
+	switch {
+	case n <= 1:
+		return 1, 1
+	case n == 2:
+		return 2, 1
+	default:
+		return 2, 2
+	}
+}
+
+// buildCollage arranges images in a grid of square tiles. Each image is
+// scaled to cover its tile and cropped around its center, so differing
+// dimensions line up. Unused tiles stay black.
+func buildCollage(images []image.Image) image.Image {
+	cols, rows := collageGrid(len(images))
+	dst := image.NewRGBA(image.Rect(0, 0, cols*collageTileSize, rows*collageTileSize))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+
+	for n, img := range images {
+		x, y := n%cols*collageTileSize, n/cols*collageTileSize
+		drawTile(dst, image.Rect(x, y, x+collageTileSize, y+collageTileSize), img)
+	}
+	return dst
+}
+
+// drawTile draws src into tile of dst, scaled with nearest neighbour
+// sampling to cover the tile and cropped to its center
+func drawTile(dst *image.RGBA, tile image.Rectangle, src image.Image) {
+	bounds := src.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	if srcW == 0 || srcH == 0 {
+		return
+	}
+
+	// Scale by the larger factor so the image covers the whole tile
+	scale := max(float64(tile.Dx())/float64(srcW), float64(tile.Dy())/float64(srcH))
+	offsetX := (float64(srcW) - float64(tile.Dx())/scale) / 2
+	offsetY := (float64(srcH) - float64(tile.Dy())/scale) / 2
+
+	for y := 0; y < tile.Dy(); y++ {
+		sy := bounds.Min.Y + min(int(offsetY+float64(y)/scale), srcH-1)
+		for x := 0; x < tile.Dx(); x++ {
+			sx := bounds.Min.X + min(int(offsetX+float64(x)/scale), srcW-1)
+			dst.Set(tile.Min.X+x, tile.Min.Y+y, src.At(sx, sy))
+		}
+	}
+}
+
+// encodeCollage encodes a collage as a JPEG of at most limit bytes
+func encodeCollage(img image.Image, limit int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: collageQuality}); err != nil {
+		return nil, fmt.Errorf("failed to encode collage: %w", err)
+	}
+	if buf.Len() <= limit {
+		return buf.Bytes(), nil
+	}
+	return compressToLimit(buf.Bytes(), limit)
+}
+
+// handleCollageSlashCommand handles the /collage slash command. It combines
+// up to four catgirl pictures into a single image.
+func (b *Bot) handleCollageSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) {
+	// Get options - defaults: count=4, SFW
+	count := maxCollageImages
+	nsfw := false
+
+	parser := b.optionParser(i.GuildID)
+	for _, option := range data.Options {
+		switch option.Name {
+		case "count":
+			count = int(option.IntValue())
+		case "nsfw":
+			nsfw = parser.yesNo(option)
+		}
+	}
+	if parser.problem != "" {
+		b.respondError(s, i, parser.problem)
+		return
+	}
+	count = min(max(count, 2), maxCollageImages)
+
+	// DMs have no age restriction, so NSFW there is opt-in
+	if nsfw && b.nsfwBlockedInDM(i.GuildID) {
+		b.respondError(s, i, nsfwDMText)
+		return
+	}
+
+	// Servers may require a role for NSFW requests
+	if nsfw {
+		if roleID := b.missingRole(i.GuildID, i.Member, roleGateNSFW); roleID != "" {
+			b.respondRoleGateInteraction(s, i, roleID)
+			return
+		}
+	}
+
+	// Ask for confirmation first if the guild gates NSFW content
+	if nsfw && b.nsfwGated(i.GuildID, interactionUserID(i)) {
+		b.respondNSFWGateInteraction(s, i)
+		return
+	}
+
+	// Clamp to the guild's limit for the resolved rating
+	count, limitNote := b.applyCountLimit(i.GuildID, count, nsfw)
+
+	// Keep one guild from filling the disk
+	if b.guildAtFileCap(i.GuildID) {
+		b.respondError(s, i, tooManyFilesText)
+		return
+	}
+
+	// Defer response, downloading and compositing takes a while
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		slog.Warn("Failed to defer interaction", "error", err)
+		return
+	}
+
+	// Show typing indicator
+	s.ChannelTyping(i.ChannelID)
+
+	rating := "safe"
+	if nsfw {
+		rating = "explicit"
+	}
+
+	results, err := b.nekosAPI.GetRandomImagesContext(b.requests, count, rating)
+	if err != nil {
+		b.editError(s, i, b.fetchFailedText(i.GuildID, "catgirl", err))
+		return
+	}
+
+	// Download and decode the pictures, skipping any that fail
+	tiles := make([]image.Image, 0, len(results))
+	for _, result := range results {
+		imageData, err := b.nekosAPI.DownloadImageContext(b.requests, result.ID)
+		if err != nil {
+			slog.Warn("Failed to download catgirl image", "image", result.ID, "error", err)
+			continue
+		}
+		img, _, err := image.Decode(bytes.NewReader(imageData))
+		if err != nil {
+			slog.Warn("Failed to decode catgirl image", "image", result.ID, "error", err)
+			continue
+		}
+		tiles = append(tiles, img)
+	}
+
+	if len(tiles) == 0 {
+		b.editError(s, i, b.noImagesText(i.GuildID, "catgirl"))
+		return
+	}
+
+	collage, err := encodeCollage(buildCollage(tiles), b.uploadLimitForGuild(i.GuildID))
+	if err != nil {
+		b.editError(s, i, fmt.Sprintf("Sorry, I couldn't build the collage: %v", err))
+		return
+	}
+
+	filename := fmt.Sprintf("collage_%s_%d.jpg", i.ID, time.Now().Unix())
+	if err := os.WriteFile(filepath.Join(picturesDir, filename), collage, 0o644); err != nil {
+		slog.Warn("Failed to save collage", "error", err)
+	} else {
+		b.trackFile(filename, i.GuildID)
+		go b.scheduleFileDeletion(filename, "")
+	}
+
+	note := limitNote
+	if len(tiles) < count {
+		note = joinNotes(note, fmt.Sprintf("ℹ️ Only %d of %d pictures could be loaded.", len(tiles), count))
+	}
+
+	b.recordServed(i.GuildID, len(tiles))
+	files := []*discordgo.File{
+		{
+			Name:        filename,
+			ContentType: "image/jpeg",
+			Reader:      bytes.NewReader(collage),
+		},
+	}
+	msg, err := retryRateLimited(&b.rateLimits, files, func(options ...discordgo.RequestOption) (*discordgo.Message, error) {
+		return s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: note,
+			Files:   files,
+		}, options...)
+	})
+	if err != nil {
+		b.editError(s, i, fmt.Sprintf("Sorry, I couldn't upload the collage: %v", err))
+		return
+	}
+	b.trackPosted(msg, interactionUserID(i), nekosPosted(results))
+}
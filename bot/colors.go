@@ -0,0 +1,94 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"KawaiiBot/api"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// namedColor is a color users can filter waifu pictures by
+type namedColor struct {
+	Name string
+	RGB  int
+}
+
+// namedColors lists the supported color filters, in the order they are shown
+var namedColors = []namedColor{
+	{"red", 0xE53935},
+	{"orange", 0xFB8C00},
+	{"yellow", 0xFDD835},
+	{"green", 0x43A047},
+	{"blue", 0x1E88E5},
+	{"purple", 0x8E24AA},
+	{"pink", 0xEC407A},
+	{"brown", 0x6D4C41},
+	{"black", 0x212121},
+	{"white", 0xF5F5F5},
+	{"gray", 0x9E9E9E},
+}
+
+// colorNames returns the names of the supported color filters
+func colorNames() []string {
+	names := make([]string, 0, len(namedColors))
+	for _, color := range namedColors {
+		names = append(names, color.Name)
+	}
+	return names
+}
+
+// colorHex maps a color name to the hex value sent to waifu.im
+func colorHex(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, color := range namedColors {
+		if color.Name == name {
+			return fmt.Sprintf("#%06X", color.RGB), nil
+		}
+	}
+	return "", fmt.Errorf("unknown color %q, valid colors are: %s", name, strings.Join(colorNames(), ", "))
+}
+
+// nearestColorName returns the supported color closest to a hex color such as
+// #9B59B6, or an empty string if the value can't be parsed
+func nearestColorName(hex string) string {
+	value, err := strconv.ParseInt(strings.TrimPrefix(hex, "#"), 16, 32)
+	if err != nil {
+		return ""
+	}
+	r, g, b := int(value>>16&0xFF), int(value>>8&0xFF), int(value&0xFF)
+
+	nearest, best := "", -1
+	for _, color := range namedColors {
+		dr := r - color.RGB>>16&0xFF
+		dg := g - color.RGB>>8&0xFF
+		db := b - color.RGB&0xFF
+		if distance := dr*dr + dg*dg + db*db; best < 0 || distance < best {
+			nearest, best = color.Name, distance
+		}
+	}
+	return nearest
+}
+
+// matchesColor reports whether an image's dominant color is closest to the
+// requested color. Every image matches when no color was requested.
+func matchesColor(img api.WaifuImage, opts api.WaifuOptions) bool {
+	if opts.DominantColor == "" {
+		return true
+	}
+	return nearestColorName(img.DominantColor) == nearestColorName(opts.DominantColor)
+}
+
+// colorChoices builds the slash command choices for the color option
+func colorChoices() []*discordgo.ApplicationCommandOptionChoice {
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, len(namedColors))
+	for _, color := range namedColors {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  strings.ToUpper(color.Name[:1]) + color.Name[1:],
+			Value: color.Name,
+		})
+	}
+	return choices
+}
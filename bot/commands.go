@@ -0,0 +1,905 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// commandCategory groups commands in the help embed
+type commandCategory string
+
+const (
+	categoryImages  commandCategory = "Images"
+	categoryWebhook commandCategory = "Webhook"
+	categoryAdmin   commandCategory = "Admin"
+	categoryInfo    commandCategory = "Info"
+)
+
+// categoryOrder is the order categories are rendered in the help embed
+var categoryOrder = []commandCategory{categoryImages, categoryWebhook, categoryAdmin, categoryInfo}
+
+// categoryEmoji decorates the category field names in the help embed
+var categoryEmoji = map[commandCategory]string{
+	categoryImages:  "📸",
+	categoryWebhook: "📅",
+	categoryAdmin:   "🛠️",
+	categoryInfo:    "ℹ️",
+}
+
+// commandInfo describes a bot command. It is the single source for both
+// slash command registration and the help embed.
+type commandInfo struct {
+	Name        string
+	Description string
+	Category    commandCategory
+	Usage       string // argument synopsis shown in help, e.g. "[count] [nsfw]"
+	Message     bool   // also available as a prefix message command
+	AdminOnly   bool   // hidden from members without Manage Server by default
+	ContextMenu bool   // registered as a message context menu entry instead of a slash command
+	Options     []*discordgo.ApplicationCommandOption
+}
+
+// adminPermissions is the default member permission required for admin commands
+var adminPermissions int64 = discordgo.PermissionManageGuild
+
+// commands lists every command the bot understands
+var commands = []commandInfo{
+	{
+		Name:        "catgirl",
+		Description: "Get adorable catgirl pictures 🐱",
+		Category:    categoryImages,
+		Usage:       "[count] [nsfw]",
+		Message:     true,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "count",
+				Description: "Number of pictures (1-10)",
+				Required:    false,
+				MinValue:    &[]float64{1}[0],
+				MaxValue:    10,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "nsfw",
+				Description: "Include NSFW content? (y=yes/n=no, defaults to no)",
+				Required:    false,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{
+						Name:  "Yes",
+						Value: "y",
+					},
+					{
+						Name:  "No",
+						Value: "n",
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "gif",
+				Description: "Only animated pictures (not offered by nekos.moe, try /waifu)",
+				Required:    false,
+			},
+		},
+	},
+	{
+		Name:        "waifu",
+		Description: "Get beautiful waifu pictures 💜",
+		Category:    categoryImages,
+		Usage:       "[count] [content] [gif] [color:<name>] [not:<tag>]",
+		Message:     true,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "count",
+				Description: "Number of pictures (1-10, default: 1)",
+				Required:    false,
+				MinValue:    &[]float64{1}[0],
+				MaxValue:    10,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "content",
+				Description: "Content type (default: SFW)",
+				Required:    false,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{
+						Name:  "SFW Only",
+						Value: "sfw",
+					},
+					{
+						Name:  "NSFW Only",
+						Value: "nsfw",
+					},
+					{
+						Name:  "All (SFW + NSFW)",
+						Value: "all",
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "gif",
+				Description: "Only animated pictures",
+				Required:    false,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "color",
+				Description: "Dominant color of the picture",
+				Required:    false,
+				Choices:     colorChoices(),
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "exclude",
+				Description: "Tags the pictures must not have, comma separated, e.g. maid",
+				Required:    false,
+			},
+		},
+	},
+	{
+		Name:        "top",
+		Description: "Get the most liked catgirl pictures for a tag 🏆",
+		Category:    categoryImages,
+		Usage:       "<tag> [count] [nsfw]",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "tag",
+				Description: "Tag to search for, e.g. smile",
+				Required:    true,
+				MaxLength:   maxArgLength,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "count",
+				Description: "Number of pictures (1-10, default: 3)",
+				Required:    false,
+				MinValue:    &[]float64{1}[0],
+				MaxValue:    10,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "nsfw",
+				Description: "Include NSFW content? (y=yes/n=no, defaults to no)",
+				Required:    false,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{
+						Name:  "Yes",
+						Value: "y",
+					},
+					{
+						Name:  "No",
+						Value: "n",
+					},
+				},
+			},
+		},
+	},
+	{
+		Name:        "by-artist",
+		Description: "Get catgirl pictures by a nekos.moe artist 🎨",
+		Category:    categoryImages,
+		Usage:       "<name> [count] [nsfw]",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "name",
+				Description: "Artist name as credited on nekos.moe",
+				Required:    true,
+				MaxLength:   maxArtistLength,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "count",
+				Description: "Number of pictures (1-10, default: 3)",
+				Required:    false,
+				MinValue:    &[]float64{1}[0],
+				MaxValue:    10,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "nsfw",
+				Description: "Include NSFW content? (y=yes/n=no, defaults to no)",
+				Required:    false,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{
+						Name:  "Yes",
+						Value: "y",
+					},
+					{
+						Name:  "No",
+						Value: "n",
+					},
+				},
+			},
+		},
+	},
+	{
+		Name:        "collage",
+		Description: "Get several catgirl pictures combined into one image 🖼️",
+		Category:    categoryImages,
+		Usage:       "[count] [nsfw]",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "count",
+				Description: "Number of pictures (2-4, default: 4)",
+				Required:    false,
+				MinValue:    &[]float64{2}[0],
+				MaxValue:    maxCollageImages,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "nsfw",
+				Description: "Include NSFW content? (y=yes/n=no, defaults to no)",
+				Required:    false,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{
+						Name:  "Yes",
+						Value: "y",
+					},
+					{
+						Name:  "No",
+						Value: "n",
+					},
+				},
+			},
+		},
+	},
+	{
+		Name:        "random",
+		Description: "Get a random SFW picture from either provider 🎲",
+		Category:    categoryImages,
+	},
+	{
+		Name:        "surprise",
+		Description: "Roll a random waifu tag and get a picture for it 🎰",
+		Category:    categoryImages,
+	},
+	{
+		Name:        "today",
+		Description: "Show the pictures from today's daily post",
+		Category:    categoryImages,
+	},
+	{
+		Name:        "waifu-info",
+		Description: "Preview a waifu picture's size and details without posting it",
+		Category:    categoryImages,
+		Usage:       "[content]",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "content",
+				Description: "Content type (default: SFW)",
+				Required:    false,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{
+						Name:  "SFW Only",
+						Value: "sfw",
+					},
+					{
+						Name:  "NSFW Only",
+						Value: "nsfw",
+					},
+					{
+						Name:  "All (SFW + NSFW)",
+						Value: "all",
+					},
+				},
+			},
+		},
+	},
+	{
+		Name:        "fullres",
+		Description: "Get the full-resolution links of the latest pictures posted in this channel",
+		Category:    categoryImages,
+	},
+	{
+		Name:        fullresMenuName,
+		Description: "Get the full-resolution links of a picture message",
+		Category:    categoryImages,
+		ContextMenu: true,
+	},
+	{
+		Name:        "webhook",
+		Description: "Toggle daily webhook for waifu/catgirl pictures",
+		Category:    categoryWebhook,
+		Message:     true,
+	},
+	{
+		Name:        "webhook-pause",
+		Description: "Pause the daily webhook for a while (bot owner only)",
+		Category:    categoryWebhook,
+		Usage:       "<duration>",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "duration",
+				Description: "How long to pause, e.g. 12h or 3d (0 resumes)",
+				Required:    true,
+			},
+		},
+	},
+	{
+		Name:        "webhook-snooze",
+		Description: "Skip only the next daily webhook (bot owner only)",
+		Category:    categoryWebhook,
+	},
+	{
+		Name:        "webhook-skipdays",
+		Description: "Set weekdays the daily webhook is not sent on (bot owner only)",
+		Category:    categoryWebhook,
+		Usage:       "<days>",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "days",
+				Description: "Comma separated weekdays, e.g. sat,sun (none clears)",
+				Required:    true,
+			},
+		},
+	},
+	{
+		Name:        "webhook-retries",
+		Description: "Set how often a failing daily webhook is attempted (bot owner only)",
+		Category:    categoryWebhook,
+		Usage:       "<count>",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "count",
+				Description: "Number of attempts (1-10)",
+				Required:    true,
+				MinValue:    &[]float64{1}[0],
+				MaxValue:    10,
+			},
+		},
+	},
+	{
+		Name:        "webhook-time",
+		Description: "Change the daily webhook send time until the next restart (bot owner only)",
+		Category:    categoryWebhook,
+		Usage:       "<HH:MM>",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "time",
+				Description: "Send time as HH:MM in the bot's timezone, e.g. 08:00",
+				Required:    true,
+			},
+		},
+	},
+	{
+		Name:        "daily-schedule",
+		Description: "Post the daily pictures to a channel of this server at its own time",
+		Category:    categoryWebhook,
+		Usage:       "<time|off> [channel] [timezone]",
+		AdminOnly:   true,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "time",
+				Description: "Time of day as HH:MM, e.g. 08:00 (off turns it off)",
+				Required:    true,
+			},
+			{
+				Type:         discordgo.ApplicationCommandOptionChannel,
+				Name:         "channel",
+				Description:  "Channel to post to (default: this channel)",
+				Required:     false,
+				ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "timezone",
+				Description: "IANA timezone, e.g. Europe/Berlin (default: the bot's timezone)",
+				Required:    false,
+			},
+		},
+	},
+	{
+		Name:        "webhook-ping",
+		Description: "Send a test message to a webhook URL without saving it",
+		Category:    categoryWebhook,
+		Usage:       "<url>",
+		AdminOnly:   true,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "url",
+				Description: "Discord webhook URL to test",
+				Required:    true,
+			},
+		},
+	},
+	{
+		Name:        "forcewebhook",
+		Description: "force send a WebHook for testing",
+		Category:    categoryAdmin,
+	},
+	{
+		Name:        "nsfw-gate",
+		Description: "Require a one-time 18+ confirmation before serving NSFW",
+		Category:    categoryAdmin,
+		Usage:       "<on|off>",
+		AdminOnly:   true,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "state",
+				Description: "Turn the NSFW confirmation gate on or off",
+				Required:    true,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{
+						Name:  "On",
+						Value: "on",
+					},
+					{
+						Name:  "Off",
+						Value: "off",
+					},
+				},
+			},
+		},
+	},
+	{
+		Name:        "link-previews",
+		Description: "Show or hide link previews when pictures are posted as URLs",
+		Category:    categoryAdmin,
+		Usage:       "<on|off>",
+		AdminOnly:   true,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "state",
+				Description: "Turn link previews on or off",
+				Required:    true,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{
+						Name:  "On",
+						Value: "on",
+					},
+					{
+						Name:  "Off",
+						Value: "off",
+					},
+				},
+			},
+		},
+	},
+	{
+		Name:        "captions",
+		Description: "Number each picture of a multi-image post with its ID",
+		Category:    categoryAdmin,
+		Usage:       "<on|off>",
+		AdminOnly:   true,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "state",
+				Description: "Turn image captions on or off",
+				Required:    true,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{
+						Name:  "On",
+						Value: "on",
+					},
+					{
+						Name:  "Off",
+						Value: "off",
+					},
+				},
+			},
+		},
+	},
+	{
+		Name:        "default-rating",
+		Description: "Pick the rating picture commands use when none is given",
+		Category:    categoryAdmin,
+		Usage:       "<safe|explicit|mixed>",
+		AdminOnly:   true,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "rating",
+				Description: "Default rating, explicit and mixed only apply in age-restricted channels",
+				Required:    true,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{
+						Name:  "Safe",
+						Value: ratingSafe,
+					},
+					{
+						Name:  "Explicit",
+						Value: ratingExplicit,
+					},
+					{
+						Name:  "Mixed (SFW + NSFW)",
+						Value: ratingMixed,
+					},
+				},
+			},
+		},
+	},
+	{
+		Name:        "embed-size",
+		Description: "Show the pictures of daily posts at full size or as thumbnails",
+		Category:    categoryAdmin,
+		Usage:       "<full|thumbnail>",
+		AdminOnly:   true,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "size",
+				Description: "How large the pictures are shown",
+				Required:    true,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{
+						Name:  "Full",
+						Value: embedSizeFull,
+					},
+					{
+						Name:  "Thumbnail",
+						Value: embedSizeThumbnail,
+					},
+				},
+			},
+		},
+	},
+	{
+		Name:        "strict-options",
+		Description: "Reject picture commands with an invalid option value instead of using the default",
+		Category:    categoryAdmin,
+		Usage:       "<on|off>",
+		AdminOnly:   true,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "state",
+				Description: "Turn strict option checking on or off",
+				Required:    true,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{
+						Name:  "On",
+						Value: "on",
+					},
+					{
+						Name:  "Off",
+						Value: "off",
+					},
+				},
+			},
+		},
+	},
+	{
+		Name:        "message-template",
+		Description: "Customize one of the bot's messages in this server",
+		Category:    categoryAdmin,
+		Usage:       "<message> [text]",
+		AdminOnly:   true,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "message",
+				Description: "Which message to change",
+				Required:    true,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{
+						Name:  "No images found",
+						Value: msgNoImages,
+					},
+					{
+						Name:  "Fetch failed",
+						Value: msgFetchFailed,
+					},
+					{
+						Name:  "Cooldown",
+						Value: msgCooldown,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "text",
+				Description: "New text with placeholders like {kind}, {error} or {wait}; leave empty to reset",
+				MaxLength:   maxTemplateLength,
+			},
+		},
+	},
+	{
+		Name:        "trash-reactions",
+		Description: "Let users delete a picture by reacting with 🗑️",
+		Category:    categoryAdmin,
+		Usage:       "<on|off>",
+		AdminOnly:   true,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "state",
+				Description: "Turn trash reactions on or off",
+				Required:    true,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{
+						Name:  "On",
+						Value: "on",
+					},
+					{
+						Name:  "Off",
+						Value: "off",
+					},
+				},
+			},
+		},
+	},
+	{
+		Name:        "count-limits",
+		Description: "Set the most SFW and NSFW pictures a command may post",
+		Category:    categoryAdmin,
+		Usage:       "[sfw] [nsfw]",
+		AdminOnly:   true,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "sfw",
+				Description: "Most SFW pictures per command (1-10)",
+				Required:    false,
+				MinValue:    &[]float64{1}[0],
+				MaxValue:    maxCount,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "nsfw",
+				Description: "Most NSFW pictures per command (1-10)",
+				Required:    false,
+				MinValue:    &[]float64{1}[0],
+				MaxValue:    maxCount,
+			},
+		},
+	},
+	{
+		Name:        "role-gate",
+		Description: "Require a role for NSFW requests or a picture command",
+		Category:    categoryAdmin,
+		Usage:       "<command> [role]",
+		AdminOnly:   true,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "command",
+				Description: "What to restrict",
+				Required:    true,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{
+						Name:  "NSFW requests",
+						Value: roleGateNSFW,
+					},
+					{
+						Name:  "/catgirl",
+						Value: "catgirl",
+					},
+					{
+						Name:  "/waifu",
+						Value: "waifu",
+					},
+					{
+						Name:  "/top",
+						Value: "top",
+					},
+					{
+						Name:  "/by-artist",
+						Value: "by-artist",
+					},
+					{
+						Name:  "/collage",
+						Value: "collage",
+					},
+					{
+						Name:  "/random",
+						Value: "random",
+					},
+					{
+						Name:  "/surprise",
+						Value: "surprise",
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionRole,
+				Name:        "role",
+				Description: "Role members need; leave empty to remove the restriction",
+				Required:    false,
+			},
+		},
+	},
+	{
+		Name:        "provider-status",
+		Description: "Show the health of the image providers",
+		Category:    categoryAdmin,
+		AdminOnly:   true,
+	},
+	{
+		Name:        "config",
+		Description: "Show the bot's effective configuration for this server",
+		Category:    categoryAdmin,
+		AdminOnly:   true,
+	},
+	{
+		Name:        "stats-reset",
+		Description: "Reset this server's image statistics",
+		Category:    categoryAdmin,
+		AdminOnly:   true,
+	},
+	{
+		Name:        "loglevel",
+		Description: "Change the bot's log level at runtime (bot owner only)",
+		Category:    categoryAdmin,
+		Usage:       "<level>",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "level",
+				Description: "Minimum level to log",
+				Required:    true,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{
+						Name:  "Debug",
+						Value: "debug",
+					},
+					{
+						Name:  "Info",
+						Value: "info",
+					},
+					{
+						Name:  "Warn",
+						Value: "warn",
+					},
+					{
+						Name:  "Error",
+						Value: "error",
+					},
+				},
+			},
+		},
+	},
+	{
+		Name:        "diag",
+		Description: "Run a self-test of Discord, the image providers, disk and webhook (bot owner only)",
+		Category:    categoryAdmin,
+	},
+	{
+		Name:        "resources",
+		Description: "Show the bot's memory, goroutines, files and caches (bot owner only)",
+		Category:    categoryAdmin,
+	},
+	{
+		Name:        "help",
+		Description: "Show help information about the bot",
+		Category:    categoryInfo,
+		Message:     true,
+	},
+	{
+		Name:        "invite",
+		Description: "Get a link to add the bot to your own server",
+		Category:    categoryInfo,
+	},
+	{
+		Name:        "providers",
+		Description: "Show which features each image provider supports",
+		Category:    categoryInfo,
+	},
+}
+
+// applicationCommands converts the command table into slash command definitions
+func applicationCommands() []*discordgo.ApplicationCommand {
+	appCommands := make([]*discordgo.ApplicationCommand, 0, len(commands))
+	for _, cmd := range commands {
+		appCommand := &discordgo.ApplicationCommand{
+			Name:        cmd.Name,
+			Description: cmd.Description,
+			Options:     cmd.Options,
+		}
+		if cmd.ContextMenu {
+			// Context menu entries take no description or options
+			appCommand = &discordgo.ApplicationCommand{
+				Name: cmd.Name,
+				Type: discordgo.MessageApplicationCommand,
+			}
+		}
+		if cmd.AdminOnly {
+			appCommand.DefaultMemberPermissions = &adminPermissions
+		}
+		appCommands = append(appCommands, appCommand)
+	}
+	return appCommands
+}
+
+// helpEmbed builds the categorized help embed shared by !help and /help
+func (b *Bot) helpEmbed() *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:       "🌸 Kawaii Bot Help 🌸",
+		Description: fmt.Sprintf("*Your personal anime picture companion!*\nMessage command prefix: `%s`", b.prefix),
+		Color:       0xE91E63, // Pink color
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Powered by Nekos.moe API & Waifu.im 💕",
+		},
+	}
+
+	for _, category := range categoryOrder {
+		var lines []string
+		for _, cmd := range commands {
+			if cmd.Category != category {
+				continue
+			}
+			lines = append(lines, b.helpLine(cmd))
+		}
+
+		if category == categoryWebhook {
+			waifus, catgirls := b.dailyWebhook.Counts()
+			lines = append(lines, fmt.Sprintf("• Sends %d waifu + %d catgirl picture(s) %s", waifus, catgirls, b.scheduler.Schedule()))
+			lines = append(lines, "• Requires `WEBHOOK_URL` or `DAILY_CHANNEL_ID` environment variable")
+		}
+
+		appendHelpFields(embed, fmt.Sprintf("%s %s", categoryEmoji[category], category), lines)
+	}
+
+	return embed
+}
+
+// maxHelpFieldValue is Discord's limit on an embed field value. Lengths are
+// counted in bytes, which never undercounts Discord's characters.
+const maxHelpFieldValue = 1024
+
+// appendHelpFields adds the lines of a category to the help embed, spread
+// over as many fields as needed to keep each one under Discord's limit
+func appendHelpFields(embed *discordgo.MessageEmbed, name string, lines []string) {
+	var value string
+	flush := func() {
+		if value == "" {
+			return
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: name, Value: value})
+		name, value = strings.TrimSuffix(name, " (cont.)")+" (cont.)", ""
+	}
+
+	for _, line := range lines {
+		if len(line) > maxHelpFieldValue {
+			cut := maxHelpFieldValue - len("…")
+			for !utf8.RuneStart(line[cut]) {
+				cut--
+			}
+			line = line[:cut] + "…"
+		}
+		if value != "" && len(value)+len("\n")+len(line) > maxHelpFieldValue {
+			flush()
+		}
+		if value != "" {
+			value += "\n"
+		}
+		value += line
+	}
+	flush()
+}
+
+// helpLine renders a single command entry for the help embed
+func (b *Bot) helpLine(cmd commandInfo) string {
+	if cmd.ContextMenu {
+		return fmt.Sprintf("`Apps › %s` on a message — %s", cmd.Name, cmd.Description)
+	}
+
+	usage := "/" + cmd.Name
+	if cmd.Usage != "" {
+		usage += " " + cmd.Usage
+	}
+
+	line := fmt.Sprintf("`%s`", usage)
+	if cmd.Message {
+		line += fmt.Sprintf(" · `%s%s`", b.prefix, cmd.Name)
+	}
+	return line + " — " + cmd.Description
+}
@@ -0,0 +1,96 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"KawaiiBot/config"
+	"KawaiiBot/scheduler"
+	"KawaiiBot/webhook"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestAppendHelpFields(t *testing.T) {
+	line := func(n int) string { return fmt.Sprintf("`/command-%02d` — %s", n, strings.Repeat("x", 80)) }
+
+	tests := []struct {
+		name  string
+		lines []string
+		names []string
+	}{
+		{"empty", nil, nil},
+		{"fits", []string{line(1), line(2)}, []string{"🛠️ Admin"}},
+		{"split", func() []string {
+			var lines []string
+			for n := range 25 {
+				lines = append(lines, line(n))
+			}
+			return lines
+		}(), []string{"🛠️ Admin", "🛠️ Admin (cont.)", "🛠️ Admin (cont.)"}},
+		{"one huge line", []string{strings.Repeat("ね", 600)}, []string{"🛠️ Admin"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			embed := &discordgo.MessageEmbed{}
+			appendHelpFields(embed, "🛠️ Admin", tt.lines)
+
+			var names, values []string
+			for _, field := range embed.Fields {
+				if len(field.Value) > maxHelpFieldValue {
+					t.Errorf("field %q is %d bytes long", field.Name, len(field.Value))
+				}
+				if !utf8.ValidString(field.Value) {
+					t.Errorf("field %q was cut inside a character", field.Name)
+				}
+				names = append(names, field.Name)
+				values = append(values, field.Value)
+			}
+			if fmt.Sprint(names) != fmt.Sprint(tt.names) {
+				t.Errorf("fields %q, want %q", names, tt.names)
+			}
+			if len(tt.lines) > 0 && len(tt.lines[0]) <= maxHelpFieldValue && strings.Join(values, "\n") != strings.Join(tt.lines, "\n") {
+				t.Error("lines were lost or reordered across the fields")
+			}
+		})
+	}
+}
+
+func TestHelpEmbedFitsDiscordLimits(t *testing.T) {
+	cfg := config.Webhook{Waifus: 1, Catgirls: 1, SendHour: 9, Workers: 1}
+	dw := webhook.New(nil, nil, cfg)
+	b := &Bot{prefix: "!", dailyWebhook: dw, scheduler: scheduler.New(dw, nil, cfg)}
+
+	embed := b.helpEmbed()
+	if len(embed.Fields) > 25 {
+		t.Errorf("%d fields, Discord allows 25", len(embed.Fields))
+	}
+
+	total := len(embed.Title) + len(embed.Description) + len(embed.Footer.Text)
+	for _, field := range embed.Fields {
+		if len(field.Name) > 256 {
+			t.Errorf("field name %q is %d bytes long, Discord allows 256", field.Name, len(field.Name))
+		}
+		if len(field.Value) > 1024 {
+			t.Errorf("field %q is %d bytes long, Discord allows 1024", field.Name, len(field.Value))
+		}
+		total += len(field.Name) + len(field.Value)
+	}
+	if total > 6000 {
+		t.Errorf("embed is %d bytes long, Discord allows 6000", total)
+	}
+
+	// Every command is listed somewhere
+	var all strings.Builder
+	for _, field := range embed.Fields {
+		all.WriteString(field.Value)
+	}
+	for _, cmd := range commands {
+		if !strings.Contains(all.String(), cmd.Name) {
+			t.Errorf("command %s is missing from the help", cmd.Name)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package bot
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	_ "image/png" // register the PNG decoder for compressToLimit
+	"log/slog"
+	"path"
+	"strings"
+)
+
+// jpegQualities are tried in order until a re-encoded image fits the limit
+var jpegQualities = []int{85, 70, 55, 40, 25}
+
+// maxDownscales bounds how often an image is halved once the lowest quality is not enough
+const maxDownscales = 3
+
+// errGIFNotCompressible is returned for GIFs, which can't be re-encoded without losing animation
+var errGIFNotCompressible = errors.New("GIFs can't be compressed")
+
+// compressToLimit re-encodes a JPEG or PNG as a JPEG with decreasing quality,
+// halving its dimensions if needed, until it is at most limit bytes.
+func compressToLimit(data []byte, limit int) ([]byte, error) {
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		if bytes.HasPrefix(data, []byte("GIF8")) {
+			return nil, errGIFNotCompressible
+		}
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	if format != "jpeg" && format != "png" {
+		return nil, fmt.Errorf("unsupported image format %q", format)
+	}
+
+	for scale := 0; scale <= maxDownscales; scale++ {
+		for _, quality := range jpegQualities {
+			var buf bytes.Buffer
+			if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+				return nil, fmt.Errorf("failed to encode image: %w", err)
+			}
+			if buf.Len() <= limit {
+				return buf.Bytes(), nil
+			}
+		}
+		img = halve(img)
+	}
+
+	return nil, fmt.Errorf("image still exceeds %d bytes after compression", limit)
+}
+
+// halve returns a copy of img at half its width and height
+func halve(img image.Image) image.Image {
+	bounds := img.Bounds()
+	width, height := max(bounds.Dx()/2, 1), max(bounds.Dy()/2, 1)
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			dst.Set(x, y, img.At(bounds.Min.X+x*2, bounds.Min.Y+y*2))
+		}
+	}
+	return dst
+}
+
+// shrinkToLimit compresses an oversized image when COMPRESS_IMAGES is enabled.
+// It returns the new data and filename, or false if the image must be skipped.
+func (b *Bot) shrinkToLimit(data []byte, filename string, limit int) ([]byte, string, bool) {
+	if !b.compressImages || strings.EqualFold(path.Ext(filename), ".gif") {
+		return nil, "", false
+	}
+
+	compressed, err := compressToLimit(data, limit)
+	if err != nil {
+		slog.Warn("Failed to compress image", "file", filename, "error", err)
+		return nil, "", false
+	}
+
+	return compressed, strings.TrimSuffix(filename, path.Ext(filename)) + ".jpg", true
+}
+
+// contentTypeFor returns the MIME type of an image based on its file extension
+func contentTypeFor(filename string) string {
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".gif":
+		return "image/gif"
+	case ".png":
+		return "image/png"
+	case ".webp":
+		return "image/webp"
+	default:
+		return "image/jpeg"
+	}
+}
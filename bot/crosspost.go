@@ -0,0 +1,69 @@
+package bot
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Discord lets a channel publish this many messages per crosspostWindow
+const (
+	maxCrossposts   = 10
+	crosspostWindow = time.Hour
+)
+
+// crosspostLimiter keeps the bot within Discord's crosspost rate limit, so
+// a publish is skipped rather than blocking on a long 429 wait
+type crosspostLimiter struct {
+	mutex sync.Mutex
+	sent  map[string][]time.Time // recent crossposts by channel
+}
+
+// allow reports whether a channel may crosspost now, and counts it if so
+func (l *crosspostLimiter) allow(channelID string) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.sent == nil {
+		l.sent = make(map[string][]time.Time)
+	}
+
+	now := time.Now()
+	recent := l.sent[channelID][:0]
+	for _, at := range l.sent[channelID] {
+		if now.Sub(at) < crosspostWindow {
+			recent = append(recent, at)
+		}
+	}
+	if len(recent) >= maxCrossposts {
+		l.sent[channelID] = recent
+		return false
+	}
+	l.sent[channelID] = append(recent, now)
+	return true
+}
+
+// crosspostDaily publishes a daily post made in an announcement channel.
+// Other channel types are left alone. Failures only log, the post itself
+// already went out.
+func (b *Bot) crosspostDaily(msg *discordgo.Message) {
+	ch, err := lookupChannel(b.session, msg.ChannelID)
+	if err != nil {
+		slog.Warn("Failed to look up channel for crossposting", "channel", msg.ChannelID, "error", err)
+		return
+	}
+	if ch.Type != discordgo.ChannelTypeGuildNews {
+		return
+	}
+
+	if !b.crossposts.allow(ch.ID) {
+		slog.Warn("Not crossposting daily post, the hourly limit is reached", "channel", ch.ID, "limit", maxCrossposts)
+		return
+	}
+
+	if _, err := b.session.ChannelMessageCrosspost(ch.ID, msg.ID); err != nil {
+		slog.Warn("Failed to crosspost daily post", "channel", ch.ID, "error", err)
+	}
+}
@@ -0,0 +1,414 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"KawaiiBot/storage"
+	"KawaiiBot/webhook"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// notConfiguredText explains how to configure a daily destination, noting
+// when the saved settings have the webhook enabled regardless
+func (b *Bot) notConfiguredText() string {
+	text := "Daily webhook is not configured. Please set the `WEBHOOK_URL` or `DAILY_CHANNEL_ID` environment variable."
+	if b.webhookStranded() {
+		text += " It is saved as enabled and will start posting once a destination is set."
+	}
+	return text
+}
+
+// webhookStranded reports whether the saved settings enable the daily
+// webhook although it has no destination, which keeps it off
+func (b *Bot) webhookStranded() bool {
+	return b.storage.GetDailyWebhookEnabled() && !b.dailyWebhook.HasDestination()
+}
+
+// syncDailyEnabled enables the daily webhook as saved in storage. A webhook
+// saved as enabled has nowhere to post without a destination, so it stays off
+// until one is configured; the saved state is kept for that restart.
+func syncDailyEnabled(dw *webhook.DailyWebhook, st *storage.Storage) {
+	enabled := st.GetDailyWebhookEnabled()
+	if enabled && !dw.HasDestination() {
+		slog.Warn("The daily webhook is enabled in storage, but neither WEBHOOK_URL nor DAILY_CHANNEL_ID is set; treating it as disabled")
+		enabled = false
+	}
+	dw.SetEnabled(enabled)
+}
+
+// sendDailyToChannel posts the daily payload to a channel through the bot
+// session. A post with pictures gets a reroll button if it fits in a single
+// message, since a reroll only edits the message it was clicked on.
+func (b *Bot) sendDailyToChannel(channelID string, payload webhook.WebhookPayload, whole bool) error {
+	var components []discordgo.MessageComponent
+	if whole && len(payload.Embeds) > 0 {
+		components = dailyRerollComponents()
+	}
+
+	send := &discordgo.MessageSend{
+		Content:    payload.Content,
+		Embeds:     dailyEmbeds(payload, b.thumbnailEmbedsIn(channelID)),
+		Components: components,
+	}
+	if mentions := payload.AllowedMentions; mentions != nil {
+		send.AllowedMentions = &discordgo.MessageAllowedMentions{Roles: mentions.Roles}
+	}
+
+	msg, err := retryRateLimited(&b.rateLimits, nil, func(options ...discordgo.RequestOption) (*discordgo.Message, error) {
+		return b.session.ChannelMessageSendComplex(channelID, send, options...)
+	})
+	if err != nil {
+		return err
+	}
+
+	if b.crosspost {
+		b.crosspostDaily(msg)
+	}
+	return nil
+}
+
+// dailyEmbeds converts the embeds of a daily payload to Discord embeds,
+// showing the pictures as thumbnails if asked to
+func dailyEmbeds(payload webhook.WebhookPayload, thumbnail bool) []*discordgo.MessageEmbed {
+	embeds := make([]*discordgo.MessageEmbed, 0, len(payload.Embeds))
+	for _, e := range payload.Embeds {
+		embed := &discordgo.MessageEmbed{
+			Title:       e.Title,
+			URL:         e.URL,
+			Description: e.Description,
+			Color:       e.Color,
+		}
+		switch {
+		case e.Image == nil:
+		case thumbnail:
+			embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: e.Image.URL}
+		default:
+			embed.Image = &discordgo.MessageEmbedImage{URL: e.Image.URL}
+		}
+		for _, f := range e.Fields {
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+				Name:   f.Name,
+				Value:  f.Value,
+				Inline: f.Inline,
+			})
+		}
+		embeds = append(embeds, embed)
+	}
+	return embeds
+}
+
+// webhookStatusText describes the daily webhook state and its destinations
+func (b *Bot) webhookStatusText(enabled bool) string {
+	status := "disabled"
+	emoji := "🔴"
+	if enabled {
+		status = "enabled"
+		emoji = "🟢"
+	}
+
+	waifus, catgirls := b.dailyWebhook.Counts()
+	lines := []string{
+		fmt.Sprintf("%s Daily webhook is now **%s**!\n", emoji, status),
+		fmt.Sprintf("📅 **Schedule**: %s", b.scheduler.Schedule()),
+		fmt.Sprintf("🌸 **Content**: %d waifu + %d catgirl picture(s)", waifus, catgirls),
+	}
+	if urls := b.dailyWebhook.WebhookURLs(); len(urls) == 1 {
+		lines = append(lines, fmt.Sprintf("🔗 **Webhook URL**: `%s`", urls[0]))
+	} else if len(urls) > 1 {
+		lines = append(lines, fmt.Sprintf("🔗 **Webhook URLs**: %d configured", len(urls)))
+	}
+	if channelID := b.dailyWebhook.GetChannelID(); channelID != "" {
+		lines = append(lines, fmt.Sprintf("💬 **Channel**: <#%s>", channelID))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// handleWebhookPauseSlashCommand handles the /webhook-pause slash command
+func (b *Bot) handleWebhookPauseSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) {
+	if !b.isOwner(i) {
+		b.respondError(s, i, "Only the bot owner can pause the daily webhook.")
+		return
+	}
+
+	duration, err := parsePauseDuration(data.Options[0].StringValue())
+	if err != nil {
+		b.respondError(s, i, err.Error())
+		return
+	}
+
+	var until time.Time
+	if duration > 0 {
+		until = time.Now().Add(duration)
+	}
+	if err := b.storage.SetWebhookPausedUntil(until); err != nil {
+		b.respondError(s, i, fmt.Sprintf("Failed to pause webhook: %v", err))
+		return
+	}
+
+	content := "▶️ Daily webhook resumed."
+	if !until.IsZero() {
+		content = fmt.Sprintf("⏸️ Daily webhook paused until <t:%d:F>. It resumes automatically afterwards.", until.Unix())
+	}
+	slog.Info("Daily webhook pause set", "until", until, "user", interactionUserID(i))
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
+	})
+}
+
+// handleWebhookSkipDaysSlashCommand handles the /webhook-skipdays slash command
+func (b *Bot) handleWebhookSkipDaysSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) {
+	if !b.isOwner(i) {
+		b.respondError(s, i, "Only the bot owner can change the skip days.")
+		return
+	}
+
+	days, err := parseWeekdays(data.Options[0].StringValue())
+	if err != nil {
+		b.respondError(s, i, err.Error())
+		return
+	}
+
+	if err := b.storage.SetWebhookSkipDays(days); err != nil {
+		b.respondError(s, i, fmt.Sprintf("Failed to save skip days: %v", err))
+		return
+	}
+
+	content := "📅 The daily webhook is now sent every day."
+	if len(days) > 0 {
+		names := make([]string, 0, len(days))
+		for _, day := range days {
+			names = append(names, day.String())
+		}
+		content = fmt.Sprintf("📅 The daily webhook is skipped on: **%s**", strings.Join(names, ", "))
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
+	})
+}
+
+// handleWebhookSnoozeSlashCommand handles the /webhook-snooze slash command.
+// It skips only the next daily send.
+func (b *Bot) handleWebhookSnoozeSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !b.isOwner(i) {
+		b.respondError(s, i, "Only the bot owner can snooze the daily webhook.")
+		return
+	}
+
+	next, err := b.scheduler.Snooze()
+	if err != nil {
+		b.respondError(s, i, fmt.Sprintf("Failed to snooze the daily webhook: %v", err))
+		return
+	}
+	slog.Info("Daily webhook snoozed", "user", interactionUserID(i))
+
+	content := "😴 The next daily webhook will be skipped."
+	if !next.IsZero() {
+		content += fmt.Sprintf(" It resumes <t:%d:F>.", next.Unix())
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
+	})
+}
+
+// handleWebhookRetriesSlashCommand handles the /webhook-retries slash command.
+// The new value lasts until the bot restarts.
+func (b *Bot) handleWebhookRetriesSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) {
+	if !b.isOwner(i) {
+		b.respondError(s, i, "Only the bot owner can change the webhook retries.")
+		return
+	}
+
+	retries := int(data.Options[0].IntValue())
+	if err := b.scheduler.SetMaxRetries(retries); err != nil {
+		b.respondError(s, i, err.Error())
+		return
+	}
+	slog.Info("Daily webhook max retries set", "retries", retries, "user", interactionUserID(i))
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("🔁 The daily webhook is now attempted up to **%d** time(s).", retries),
+		},
+	})
+}
+
+// handleWebhookTimeSlashCommand handles the /webhook-time slash command. It
+// moves the daily send time until the next restart.
+func (b *Bot) handleWebhookTimeSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) {
+	if !b.isOwner(i) {
+		b.respondError(s, i, "Only the bot owner can change the webhook time.")
+		return
+	}
+
+	t, err := time.Parse("15:04", strings.TrimSpace(data.Options[0].StringValue()))
+	if err != nil {
+		b.respondError(s, i, "Please give the time as HH:MM, e.g. 08:00.")
+		return
+	}
+	if err := b.scheduler.SetSendTime(t.Hour(), t.Minute()); err != nil {
+		b.respondError(s, i, err.Error())
+		return
+	}
+	slog.Info("Daily webhook send time set", "time", t.Format("15:04"), "user", interactionUserID(i))
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("⏰ The daily webhook is now sent %s. Next send: <t:%d:F>", b.scheduler.Schedule(), b.scheduler.NextSendTime().Unix()),
+		},
+	})
+}
+
+// parsePauseDuration parses a pause duration. On top of time.ParseDuration
+// units it accepts whole days like "3d". Zero resumes the webhook.
+func parsePauseDuration(value string) (time.Duration, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "0" {
+		return 0, nil
+	}
+
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid duration %q, use e.g. 12h or 3d", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		return 0, fmt.Errorf("invalid duration %q, use e.g. 12h or 3d", value)
+	}
+	return duration, nil
+}
+
+// parseWeekdays parses a comma separated list of weekday names or their
+// three letter abbreviations. "none" yields an empty list.
+func parseWeekdays(value string) ([]time.Weekday, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "none" || value == "" {
+		return nil, nil
+	}
+
+	var days []time.Weekday
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		found := false
+		for day := time.Sunday; day <= time.Saturday; day++ {
+			full := strings.ToLower(day.String())
+			if name == full || name == full[:3] {
+				if !slices.Contains(days, day) {
+					days = append(days, day)
+				}
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown weekday %q, use e.g. sat,sun", name)
+		}
+	}
+	return days, nil
+}
+
+// toggleDailyWebhook flips the stored daily webhook state and applies the
+// stored result to the webhook. Concurrent toggles are serialized, so the
+// in-memory flag can't be left behind by a slower caller.
+func (b *Bot) toggleDailyWebhook() (bool, error) {
+	b.toggleMutex.Lock()
+	defer b.toggleMutex.Unlock()
+
+	newState, err := b.storage.ToggleDailyWebhookEnabled()
+	b.dailyWebhook.SetEnabled(newState)
+	return newState, err
+}
+
+// webhookPingCooldown is how often a guild may use /webhook-ping, so the bot
+// can't be used as a webhook spam relay
+const webhookPingCooldown = time.Minute
+
+// allowWebhookPing reports whether a guild is off the /webhook-ping cooldown
+// and starts a new cooldown if it is
+func (b *Bot) allowWebhookPing(guildID string) (bool, time.Duration) {
+	b.pingMutex.Lock()
+	defer b.pingMutex.Unlock()
+
+	if wait := webhookPingCooldown - time.Since(b.lastPing[guildID]); wait > 0 {
+		return false, wait
+	}
+	b.lastPing[guildID] = time.Now()
+	return true, 0
+}
+
+// handleWebhookPingSlashCommand handles the /webhook-ping slash command. It
+// sends a test message to the given URL without saving it.
+func (b *Bot) handleWebhookPingSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) {
+	if i.GuildID == "" || !isGuildAdmin(i) {
+		b.respondError(s, i, "Only server admins can test webhooks.")
+		return
+	}
+
+	url := ""
+	for _, option := range data.Options {
+		if option.Name == "url" {
+			url = strings.TrimSpace(option.StringValue())
+		}
+	}
+
+	if ok, wait := b.allowWebhookPing(i.GuildID); !ok {
+		b.respondError(s, i, b.cooldownText(i.GuildID, wait))
+		return
+	}
+
+	// Defer response, the webhook may be slow to answer
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		slog.Warn("Failed to defer interaction", "error", err)
+		return
+	}
+
+	masked := webhook.MaskURL(url)
+	slog.Info("Webhook ping requested", "url", masked, "user", interactionUserID(i))
+
+	status, err := webhook.Ping(url)
+	if err != nil {
+		if errors.Is(err, webhook.ErrInvalidWebhookURL) {
+			b.editError(s, i, fmt.Sprintf("`%s` is not a valid Discord webhook URL.", masked))
+		} else if status != 0 {
+			b.editError(s, i, fmt.Sprintf("Webhook `%s` answered with HTTP %d.", masked, status))
+		} else {
+			b.editError(s, i, fmt.Sprintf("Couldn't reach webhook `%s`: %v", masked, err))
+		}
+		return
+	}
+
+	content := fmt.Sprintf("✅ Webhook `%s` works (HTTP %d). It was not saved.", masked, status)
+	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: &content,
+	})
+}
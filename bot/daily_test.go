@@ -0,0 +1,87 @@
+package bot
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"KawaiiBot/config"
+	"KawaiiBot/storage"
+	"KawaiiBot/webhook"
+)
+
+func TestToggleDailyWebhookConcurrent(t *testing.T) {
+	st, err := storage.New(filepath.Join(t.TempDir(), "settings.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dw := webhook.New(nil, nil, config.Webhook{URLs: []string{"https://discord.com/api/webhooks/1/token"}})
+	b := &Bot{storage: st, dailyWebhook: dw}
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := b.toggleDailyWebhook(); err != nil {
+				t.Error(err)
+			}
+			// The webhook always follows storage, whatever the interleaving
+			b.toggleMutex.Lock()
+			stored, applied := st.GetDailyWebhookEnabled(), dw.IsEnabled()
+			b.toggleMutex.Unlock()
+			if stored != applied {
+				t.Errorf("storage says %t, the webhook %t", stored, applied)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if st.GetDailyWebhookEnabled() || dw.IsEnabled() {
+		t.Error("an even number of toggles left the webhook enabled")
+	}
+}
+
+func TestSyncDailyEnabled(t *testing.T) {
+	tests := []struct {
+		name       string
+		saved      bool
+		urls       []string
+		channelID  string
+		want       bool
+		noDestHint bool // the not-configured text mentions the saved state
+	}{
+		{"saved enabled with a URL", true, []string{"https://discord.com/api/webhooks/1/token"}, "", true, false},
+		{"saved enabled with a channel", true, nil, "123", true, false},
+		{"saved enabled without a destination", true, nil, "", false, true},
+		{"saved disabled without a destination", false, nil, "", false, false},
+		{"saved disabled with a URL", false, []string{"https://discord.com/api/webhooks/1/token"}, "", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st, err := storage.New(filepath.Join(t.TempDir(), "settings.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := st.SetDailyWebhookEnabled(tt.saved); err != nil {
+				t.Fatal(err)
+			}
+			dw := webhook.New(nil, nil, config.Webhook{URLs: tt.urls, ChannelID: tt.channelID})
+
+			syncDailyEnabled(dw, st)
+
+			if got := dw.IsEnabled(); got != tt.want {
+				t.Errorf("enabled = %t, want %t", got, tt.want)
+			}
+			if got := st.GetDailyWebhookEnabled(); got != tt.saved {
+				t.Errorf("saved state changed to %t", got)
+			}
+			b := &Bot{storage: st, dailyWebhook: dw}
+			if got := strings.Contains(b.notConfiguredText(), "saved as enabled"); got != tt.noDestHint {
+				t.Errorf("not-configured text %q, want the saved state mentioned %t", b.notConfiguredText(), tt.noDestHint)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"KawaiiBot/api"
+	"KawaiiBot/webhook"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// diagTimeout bounds each /diag check
+const diagTimeout = 10 * time.Second
+
+// errDiagSkipped marks a check that doesn't apply to this setup
+var errDiagSkipped = errors.New("skipped")
+
+// diagCheck is one line of the /diag checklist. run returns a short detail
+// on success.
+type diagCheck struct {
+	name string
+	run  func(ctx context.Context) (string, error)
+}
+
+// diagResult is the outcome of a diagCheck
+type diagResult struct {
+	detail string
+	err    error
+}
+
+// diagChecks returns the checks /diag runs, in display order
+func (b *Bot) diagChecks(s *discordgo.Session) []diagCheck {
+	return []diagCheck{
+		{"Discord connection", func(ctx context.Context) (string, error) {
+			if !s.DataReady {
+				return "", errors.New("gateway session not ready")
+			}
+			return fmt.Sprintf("heartbeat %s", s.HeartbeatLatency().Round(time.Millisecond)), nil
+		}},
+		{"Nekos.moe fetch", func(ctx context.Context) (string, error) {
+			images, err := b.nekosAPI.GetRandomImages(1, "safe")
+			if err == nil && len(images) == 0 {
+				err = errors.New("no images returned")
+			}
+			return "", err
+		}},
+		{"Waifu.im fetch", func(ctx context.Context) (string, error) {
+			images, err := b.waifuAPI.GetWaifuImages(api.NSFWModeSFW, 1)
+			if err == nil && len(images) == 0 {
+				err = errors.New("no images returned")
+			}
+			return "", err
+		}},
+		{"Image download", func(ctx context.Context) (string, error) {
+			images, err := b.nekosAPI.GetRandomImages(1, "safe")
+			if err != nil {
+				return "", err
+			}
+			if len(images) == 0 {
+				return "", errors.New("no image to download")
+			}
+			data, err := b.nekosAPI.DownloadImage(images[0].ID)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%d KB", len(data)/1024), nil
+		}},
+		{"Disk writable", func(ctx context.Context) (string, error) {
+			probe, err := os.CreateTemp(picturesDir, ".diag-*")
+			if err != nil {
+				return "", err
+			}
+			probe.Close()
+			return "", os.Remove(probe.Name())
+		}},
+		{"Storage writable", func(ctx context.Context) (string, error) {
+			return "", b.storage.CheckWritable()
+		}},
+		{"Webhook URL reachable", func(ctx context.Context) (string, error) {
+			err := b.dailyWebhook.CheckURL(ctx)
+			if errors.Is(err, webhook.ErrNoWebhookURL) {
+				return "", errDiagSkipped
+			}
+			return "", err
+		}},
+	}
+}
+
+// runDiagChecks runs all checks concurrently, each limited to diagTimeout,
+// and returns their results in the order of checks
+func runDiagChecks(checks []diagCheck) []diagResult {
+	results := make([]diagResult, len(checks))
+
+	var wg sync.WaitGroup
+	for n, check := range checks {
+		wg.Go(func() {
+			ctx, cancel := context.WithTimeout(context.Background(), diagTimeout)
+			defer cancel()
+
+			// Not every check honors ctx, so stop waiting once it expires
+			done := make(chan diagResult, 1)
+			go func() {
+				detail, err := check.run(ctx)
+				done <- diagResult{detail, err}
+			}()
+
+			select {
+			case results[n] = <-done:
+			case <-ctx.Done():
+				results[n] = diagResult{err: fmt.Errorf("timed out after %s", diagTimeout)}
+			}
+		})
+	}
+	wg.Wait()
+	return results
+}
+
+// diagEmbed renders the check results as a ✅/❌ checklist
+func diagEmbed(checks []diagCheck, results []diagResult) *discordgo.MessageEmbed {
+	lines := make([]string, 0, len(checks))
+	failed := 0
+	for n, check := range checks {
+		result := results[n]
+		switch {
+		case errors.Is(result.err, errDiagSkipped):
+			lines = append(lines, fmt.Sprintf("⚪ **%s**: not configured", check.name))
+		case result.err != nil:
+			failed++
+			lines = append(lines, fmt.Sprintf("❌ **%s**: %s", check.name, truncate(result.err.Error(), 200)))
+		case result.detail != "":
+			lines = append(lines, fmt.Sprintf("✅ **%s** (%s)", check.name, result.detail))
+		default:
+			lines = append(lines, fmt.Sprintf("✅ **%s**", check.name))
+		}
+	}
+
+	color := statusColorHealthy
+	if failed > 0 {
+		color = statusColorFailing
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       "🩺 Self-test",
+		Description: strings.Join(lines, "\n"),
+		Color:       color,
+		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("%d of %d checks failed", failed, len(checks))},
+		Timestamp:   time.Now().Format(time.RFC3339),
+	}
+}
+
+// handleDiagSlashCommand handles the /diag slash command. It runs an end to
+// end self-test and reports a checklist to the bot owner.
+func (b *Bot) handleDiagSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !b.isOwner(i) {
+		b.respondError(s, i, "Only the bot owner can run the self-test.")
+		return
+	}
+
+	// Defer response, the checks take a few seconds
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+	if err != nil {
+		slog.Warn("Failed to defer interaction", "error", err)
+		return
+	}
+
+	checks := b.diagChecks(s)
+	results := runDiagChecks(checks)
+	slog.Info("Self-test run", "user", interactionUserID(i))
+
+	embeds := []*discordgo.MessageEmbed{diagEmbed(checks, results)}
+	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Embeds: &embeds})
+}
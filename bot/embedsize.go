@@ -0,0 +1,73 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Values of the embed_size guild setting
+const (
+	embedSizeFull      = "full"
+	embedSizeThumbnail = "thumbnail"
+)
+
+// thumbnailEmbeds reports whether a guild wants the pictures of embeds shown
+// as thumbnails
+func (b *Bot) thumbnailEmbeds(guildID string) bool {
+	return guildID != "" && b.storage.GetGuildSettings(guildID).EmbedSize == embedSizeThumbnail
+}
+
+// thumbnailEmbedsIn is thumbnailEmbeds for the guild a channel belongs to
+func (b *Bot) thumbnailEmbedsIn(channelID string) bool {
+	ch, err := lookupChannel(b.session, channelID)
+	if err != nil {
+		slog.Warn("Failed to look up channel", "channel", channelID, "error", err)
+		return false
+	}
+	return b.thumbnailEmbeds(ch.GuildID)
+}
+
+// handleEmbedSizeSlashCommand handles the /embed-size slash command
+func (b *Bot) handleEmbedSizeSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) {
+	if i.GuildID == "" || !isGuildAdmin(i) {
+		b.respondError(s, i, "Only server admins can change the embed size.")
+		return
+	}
+
+	size := embedSizeFull
+	for _, option := range data.Options {
+		if option.Name == "size" {
+			size = option.StringValue()
+		}
+	}
+	if size != embedSizeFull && size != embedSizeThumbnail {
+		b.respondError(s, i, fmt.Sprintf("Unknown embed size %q.", size))
+		return
+	}
+
+	// Full size is the default and stored as empty
+	stored := size
+	if size == embedSizeFull {
+		stored = ""
+	}
+	if err := b.storage.SetEmbedSize(i.GuildID, stored); err != nil {
+		b.respondError(s, i, fmt.Sprintf("Failed to update the embed size: %v", err))
+		return
+	}
+	slog.Info("Embed size set", "guild", i.GuildID, "size", size, "user", interactionUserID(i))
+
+	content := "🖼️ Daily posts now show their pictures at **full size**."
+	if size == embedSizeThumbnail {
+		content = "🖼️ Daily posts now show their pictures as **thumbnails**."
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
@@ -0,0 +1,68 @@
+package bot
+
+import (
+	"log/slog"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// errorColor is the embed color used for error responses
+const errorColor = 0xE74C3C // Red color
+
+// errorResponse builds the response data for an error message. It renders a
+// red embed or plain text depending on ERROR_EMBEDS. The correlation ID is the
+// ID of the failed interaction or message and is also logged, so a user's
+// report can be matched with the logs.
+func (b *Bot) errorResponse(msg, correlationID string) *discordgo.InteractionResponseData {
+	slog.Warn("Error response sent", "correlation_id", correlationID, "message", msg)
+
+	if !b.errorEmbeds {
+		return &discordgo.InteractionResponseData{
+			Content: "❌ " + msg,
+		}
+	}
+
+	return &discordgo.InteractionResponseData{
+		Embeds: []*discordgo.MessageEmbed{
+			{
+				Title:       "❌ Something went wrong",
+				Description: msg,
+				Color:       errorColor,
+				Footer: &discordgo.MessageEmbedFooter{
+					Text: "Correlation ID: " + correlationID,
+				},
+			},
+		},
+	}
+}
+
+// respondError answers an interaction with an ephemeral error
+func (b *Bot) respondError(s *discordgo.Session, i *discordgo.InteractionCreate, msg string) {
+	data := b.errorResponse(msg, i.ID)
+	data.Flags = discordgo.MessageFlagsEphemeral
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: data,
+	})
+}
+
+// editError replaces a deferred interaction response with an error
+func (b *Bot) editError(s *discordgo.Session, i *discordgo.InteractionCreate, msg string) {
+	data := b.errorResponse(msg, i.ID)
+
+	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: &data.Content,
+		Embeds:  &data.Embeds,
+	})
+}
+
+// sendError sends an error for a message command to its channel
+func (b *Bot) sendError(s *discordgo.Session, m *discordgo.MessageCreate, msg string) {
+	data := b.errorResponse(msg, m.ID)
+
+	s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+		Content: data.Content,
+		Embeds:  data.Embeds,
+	})
+}
@@ -0,0 +1,112 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// Provider names used in served events
+const (
+	providerNekos = "nekos.moe"
+	providerWaifu = "waifu.im"
+)
+
+// servedEventBuffer is how many events may wait to be written. Events beyond
+// it are dropped so a slow writer never holds up sending pictures.
+const servedEventBuffer = 256
+
+// servedEvent records one image the bot tried to post. Success means it was
+// uploaded as an attachment; URL fallbacks count as failures.
+type servedEvent struct {
+	Time     time.Time `json:"time"`
+	GuildID  string    `json:"guild_id,omitempty"` // empty in DMs
+	UserID   string    `json:"user_id"`
+	Provider string    `json:"provider"`
+	ImageID  string    `json:"image_id"`
+	NSFW     bool      `json:"nsfw"`
+	Success  bool      `json:"success"`
+}
+
+// eventEmitter writes served events as JSON lines in the background
+type eventEmitter struct {
+	events chan servedEvent
+	out    io.WriteCloser
+	mutex  sync.Mutex // guards closed against sends after close
+	closed bool
+}
+
+// newEventEmitter opens path for appending, or uses stdout for "-", and starts
+// the writer. An empty path disables events and returns nil.
+func newEventEmitter(path string) (*eventEmitter, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	var out io.WriteCloser = os.Stdout
+	if path != "-" {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open served events file: %w", err)
+		}
+		out = f
+	}
+
+	e := &eventEmitter{
+		events: make(chan servedEvent, servedEventBuffer),
+		out:    out,
+	}
+	go e.run()
+	return e, nil
+}
+
+// emit queues an event without blocking. It is a no-op on a nil emitter.
+func (e *eventEmitter) emit(event servedEvent) {
+	if e == nil {
+		return
+	}
+	event.Time = time.Now().UTC()
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if e.closed {
+		return
+	}
+
+	select {
+	case e.events <- event:
+	default:
+		slog.Warn("Served event buffer full, dropping event", "image", event.ImageID)
+	}
+}
+
+// run writes queued events until the emitter is closed
+func (e *eventEmitter) run() {
+	encoder := json.NewEncoder(e.out)
+	for event := range e.events {
+		if err := encoder.Encode(event); err != nil {
+			slog.Warn("Failed to write served event", "error", err)
+		}
+	}
+	if e.out != os.Stdout {
+		e.out.Close()
+	}
+}
+
+// close stops accepting events and lets the writer finish the queue
+func (e *eventEmitter) close() {
+	if e == nil {
+		return
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if !e.closed {
+		e.closed = true
+		close(e.events)
+	}
+}
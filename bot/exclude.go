@@ -0,0 +1,44 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"KawaiiBot/api"
+)
+
+// excludedTags resolves tags a user wants left out to waifu.im tag slugs.
+// Tags may be given by slug or name; unknown ones, and the included tag,
+// are rejected.
+func (b *Bot) excludedTags(raw []string, included string) ([]string, error) {
+	tags, err := b.waifuTags()
+	if err != nil {
+		slog.Warn("Failed to fetch waifu tags", "error", err)
+		return nil, fmt.Errorf("couldn't load the tag list to check the tags")
+	}
+
+	var slugs []string
+	for _, name := range raw {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		n := slices.IndexFunc(tags, func(tag api.Tag) bool {
+			return strings.EqualFold(tag.Slug, name) || strings.EqualFold(tag.Name, name)
+		})
+		if n < 0 {
+			return nil, fmt.Errorf("unknown tag %q", name)
+		}
+		slug := tags[n].Slug
+		if included != "" && slug == included {
+			return nil, fmt.Errorf("tag %q can't be both included and excluded", name)
+		}
+		if !slices.Contains(slugs, slug) {
+			slugs = append(slugs, slug)
+		}
+	}
+	return slugs, nil
+}
@@ -0,0 +1,48 @@
+package bot
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"KawaiiBot/api"
+)
+
+func TestExcludedTags(t *testing.T) {
+	b := &Bot{}
+	b.tagCache.tags = []api.Tag{
+		{Name: "Maid", Slug: "maid"},
+		{Name: "Raiden Shogun", Slug: "raiden-shogun"},
+		{Name: "Uniform", Slug: "uniform"},
+	}
+	b.tagCache.fetched = time.Now()
+
+	tests := []struct {
+		name     string
+		raw      []string
+		included string
+		want     []string
+		wantErr  bool
+	}{
+		{"by slug", []string{"maid"}, "", []string{"maid"}, false},
+		{"by name", []string{"Raiden Shogun"}, "", []string{"raiden-shogun"}, false},
+		{"any case", []string{"UNIFORM"}, "", []string{"uniform"}, false},
+		{"duplicates", []string{"maid", "Maid"}, "", []string{"maid"}, false},
+		{"blank skipped", []string{" ", "maid"}, "", []string{"maid"}, false},
+		{"with another included tag", []string{"maid"}, "uniform", []string{"maid"}, false},
+		{"unknown", []string{"maid", "nope"}, "", nil, true},
+		{"also included", []string{"Maid"}, "maid", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := b.excludedTags(tt.raw, tt.included)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("excludedTags(%q, %q) error = %v, want error %t", tt.raw, tt.included, err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("excludedTags(%q, %q) = %q, want %q", tt.raw, tt.included, got, tt.want)
+			}
+		})
+	}
+}
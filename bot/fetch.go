@@ -0,0 +1,100 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"path"
+	"strings"
+
+	"KawaiiBot/api"
+)
+
+// maxRefetchAttempts bounds the extra requests made when an API returns fewer
+// images than requested
+const maxRefetchAttempts = 2
+
+// fetchWaifuImages fetches up to count waifu images, retrying a bounded number
+// of times when waifu.im returns fewer than requested. Duplicates across
+// attempts are dropped, as are images whose dominant color doesn't match the
+// requested one.
+func (b *Bot) fetchWaifuImages(opts api.WaifuOptions, count int) ([]api.WaifuImage, error) {
+	batch, err := b.waifuAPI.SearchWaifuImagesContext(b.requests, opts, count)
+	if err != nil {
+		return nil, err
+	}
+
+	images := make([]api.WaifuImage, 0, count)
+	seen := make(map[int64]bool, count)
+
+	// keep adds the matching images of a batch and returns how many were new
+	keep := func(batch []api.WaifuImage) int {
+		added := 0
+		for _, img := range batch {
+			if seen[img.ID] {
+				continue
+			}
+			seen[img.ID] = true
+			added++
+			if img.URL == "" {
+				slog.Warn("Skipping waifu image without a URL", "image", img.ID)
+				continue
+			}
+			if len(images) < count && matchesColor(img, opts) {
+				images = append(images, img)
+			}
+		}
+		return added
+	}
+	keep(batch)
+
+	for attempt := 0; attempt < maxRefetchAttempts && len(batch) > 0 && len(images) < count; attempt++ {
+		batch, err = b.waifuAPI.SearchWaifuImagesContext(b.requests, opts, count-len(images))
+		if err != nil {
+			break
+		}
+
+		// Nothing new means the filters are exhausted
+		if keep(batch) == 0 {
+			break
+		}
+	}
+
+	return images, nil
+}
+
+// waifuExtension returns the file extension of a waifu image including the
+// dot. A missing extension is derived from the URL, falling back to .jpg.
+func waifuExtension(img api.WaifuImage) string {
+	if img.Extension != "" {
+		if !strings.HasPrefix(img.Extension, ".") {
+			return "." + img.Extension
+		}
+		return img.Extension
+	}
+	if u, err := url.Parse(img.URL); err == nil {
+		if ext := path.Ext(u.Path); ext != "" {
+			return ext
+		}
+	}
+	return ".jpg"
+}
+
+// shortfallNote explains that fewer images than requested were found
+func shortfallNote(got, requested int) string {
+	if got >= requested {
+		return ""
+	}
+	return fmt.Sprintf("ℹ️ Only %d image(s) matched your filters.", got)
+}
+
+// joinNotes joins the non-empty notes into a single message
+func joinNotes(notes ...string) string {
+	parts := make([]string, 0, len(notes))
+	for _, note := range notes {
+		if note != "" {
+			parts = append(parts, note)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
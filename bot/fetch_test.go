@@ -0,0 +1,133 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"KawaiiBot/api"
+)
+
+// stubAPI routes every outgoing request of the default transport, which the
+// API clients use, to handler for the rest of the test
+func stubAPI(t *testing.T, handler http.HandlerFunc) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	target, _ := url.Parse(server.URL)
+
+	orig := http.DefaultTransport
+	http.DefaultTransport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		req = req.Clone(req.Context())
+		req.URL.Scheme, req.URL.Host = target.Scheme, target.Host
+		return orig.RoundTrip(req)
+	})
+	t.Cleanup(func() {
+		http.DefaultTransport = orig
+		server.Close()
+	})
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// waifuPage answers a waifu.im search with the images of the given IDs
+func waifuPage(w http.ResponseWriter, ids ...int64) {
+	var page api.WaifuResponse
+	for _, id := range ids {
+		page.Items = append(page.Items, api.WaifuImage{ID: id, URL: fmt.Sprintf("https://cdn.waifu.im/%d.jpg", id)})
+	}
+	json.NewEncoder(w).Encode(page)
+}
+
+func TestFetchWaifuImagesShortfall(t *testing.T) {
+	tests := []struct {
+		name     string
+		batches  [][]int64 // answer to each request, the last one repeats
+		want     int
+		requests int32
+	}{
+		{"enough at once", [][]int64{{1, 2, 3}}, 3, 1},
+		{"refetch fills up", [][]int64{{1}, {2, 3}}, 3, 2},
+		{"filters exhausted", [][]int64{{1, 2}, {1, 2}}, 2, 2},
+		{"nothing matches", [][]int64{{}}, 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			stubAPI(t, func(w http.ResponseWriter, r *http.Request) {
+				n := int(requests.Add(1)) - 1
+				waifuPage(w, tt.batches[min(n, len(tt.batches)-1)]...)
+			})
+
+			b := &Bot{waifuAPI: api.NewWaifuClient("test"), requests: context.Background()}
+			images, err := b.fetchWaifuImages(api.WaifuOptions{}, 3)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(images) != tt.want {
+				t.Errorf("got %d images, want %d", len(images), tt.want)
+			}
+			if got := requests.Load(); got != tt.requests {
+				t.Errorf("made %d requests, want %d", got, tt.requests)
+			}
+
+			note := shortfallNote(len(images), 3)
+			if (note != "") != (tt.want < 3) {
+				t.Errorf("shortfall note %q for %d of 3 images", note, len(images))
+			}
+		})
+	}
+}
+
+func TestWaifuExtension(t *testing.T) {
+	tests := []struct {
+		name string
+		img  api.WaifuImage
+		want string
+	}{
+		{"with dot", api.WaifuImage{Extension: ".png"}, ".png"},
+		{"without dot", api.WaifuImage{Extension: "gif"}, ".gif"},
+		{"from the URL", api.WaifuImage{URL: "https://cdn.waifu.im/123.webp"}, ".webp"},
+		{"URL with a query", api.WaifuImage{URL: "https://cdn.waifu.im/123.png?size=large"}, ".png"},
+		{"nothing to go on", api.WaifuImage{URL: "https://cdn.waifu.im/123"}, ".jpg"},
+		{"empty", api.WaifuImage{}, ".jpg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := waifuExtension(tt.img); got != tt.want {
+				t.Errorf("waifuExtension = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchWaifuImagesSkipsMissingURL(t *testing.T) {
+	stubAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(api.WaifuResponse{Items: []api.WaifuImage{
+			{ID: 1, URL: "https://cdn.waifu.im/1.jpg"},
+			{ID: 2},
+			{ID: 3, URL: "https://cdn.waifu.im/3"},
+		}})
+	})
+
+	b := &Bot{waifuAPI: api.NewWaifuClient("test"), requests: context.Background()}
+	images, err := b.fetchWaifuImages(api.WaifuOptions{}, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []int64
+	for _, img := range images {
+		ids = append(ids, img.ID)
+	}
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 3 {
+		t.Errorf("got images %v, want 1 and 3 without the one lacking a URL", ids)
+	}
+}
@@ -0,0 +1,49 @@
+package bot
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// tooManyFilesText is shown when a guild has reached MAX_FILES_PER_GUILD
+const tooManyFilesText = "Too many images in flight for this server right now. Please try again in a moment."
+
+// archiveDir is the directory under picturesDir that KEEP_IMAGES moves
+// served pictures to, one subdirectory per day
+const archiveDir = "archive"
+
+// trackedFile is a downloaded picture waiting to be deleted
+type trackedFile struct {
+	GuildID string
+	Created time.Time
+}
+
+// guildAtFileCap reports whether a guild has as many files on disk as
+// MAX_FILES_PER_GUILD allows. DMs are not capped.
+func (b *Bot) guildAtFileCap(guildID string) bool {
+	if b.maxFilesPerGuild == 0 || guildID == "" {
+		return false
+	}
+
+	b.fileMutex.Lock()
+	defer b.fileMutex.Unlock()
+
+	count := 0
+	for _, file := range b.activeFiles {
+		if file.GuildID == guildID {
+			count++
+		}
+	}
+	return count >= b.maxFilesPerGuild
+}
+
+// archiveFile moves a served picture from picturesDir into today's archive
+// directory. The file stops counting towards the guild's file cap.
+func archiveFile(filename string) error {
+	dir := filepath.Join(picturesDir, archiveDir, time.Now().Format(time.DateOnly))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	return os.Rename(filepath.Join(picturesDir, filename), filepath.Join(dir, filename))
+}
@@ -0,0 +1,74 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// fullresMenuName is the name of the message context menu entry for
+// full-resolution links
+const fullresMenuName = "Full resolution"
+
+// fullresExpiredText is shown when a picture message isn't indexed anymore
+var fullresExpiredText = fmt.Sprintf("I don't remember the pictures of that message anymore, links are only kept for %.0f hours after posting.", postedMessageTTL.Hours())
+
+// fullresText lists the original URLs of a picture message. Links are
+// wrapped in <> so the reply stays compact.
+func fullresText(posted postedMessage) string {
+	lines := make([]string, 0, len(posted.images)+1)
+	lines = append(lines, "🔍 Full-resolution links:")
+	for _, img := range posted.images {
+		if img.URL == "" {
+			lines = append(lines, fmt.Sprintf("• %s `%s`: no link available", img.Provider, img.ID))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("• %s `%s`: <%s>", img.Provider, img.ID, img.URL))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// respondFullres answers with the links of a picture message, ephemeral
+func (b *Bot) respondFullres(s *discordgo.Session, i *discordgo.InteractionCreate, posted postedMessage) {
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: fullresText(posted),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// handleFullresSlashCommand handles the /fullres slash command. It answers
+// with the links of the latest picture message in the channel.
+func (b *Bot) handleFullresSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	posted, ok := b.posted.latest(i.ChannelID)
+	if !ok {
+		b.respondError(s, i, fmt.Sprintf("I haven't posted any pictures in this channel in the last %.0f hours.", postedMessageTTL.Hours()))
+		return
+	}
+	b.respondFullres(s, i, posted)
+}
+
+// handleFullresMenuCommand handles the full resolution message context menu
+// entry. It answers with the links of the chosen picture message.
+func (b *Bot) handleFullresMenuCommand(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) {
+	posted, ok := b.posted.get(data.TargetID)
+	if ok {
+		b.respondFullres(s, i, posted)
+		return
+	}
+
+	// Tell apart other messages from picture messages that expired or were
+	// evicted from the index
+	var msg *discordgo.Message
+	if data.Resolved != nil {
+		msg = data.Resolved.Messages[data.TargetID]
+	}
+	if msg == nil || msg.Author == nil || msg.Author.ID != s.State.User.ID {
+		b.respondError(s, i, "That message has no pictures from me.")
+		return
+	}
+	b.respondError(s, i, fullresExpiredText)
+}
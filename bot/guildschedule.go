@@ -0,0 +1,87 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"KawaiiBot/storage"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// handleDailyScheduleSlashCommand handles the /daily-schedule slash command.
+// It sets or removes the guild's own daily post to one of its channels.
+func (b *Bot) handleDailyScheduleSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) {
+	if i.GuildID == "" || !isGuildAdmin(i) {
+		b.respondError(s, i, "Only server admins can change the server's daily schedule.")
+		return
+	}
+
+	// Get options - defaults: this channel, the bot's timezone
+	at := ""
+	channelID := i.ChannelID
+	timezone := ""
+
+	for _, option := range data.Options {
+		switch option.Name {
+		case "time":
+			at = strings.ToLower(strings.TrimSpace(option.StringValue()))
+		case "channel":
+			channelID = option.ChannelValue(nil).ID
+		case "timezone":
+			timezone = strings.TrimSpace(option.StringValue())
+		}
+	}
+
+	if at == "off" {
+		if err := b.storage.SetGuildSchedule(i.GuildID, nil); err != nil {
+			b.respondError(s, i, fmt.Sprintf("Failed to remove the daily schedule: %v", err))
+			return
+		}
+		b.scheduler.ReloadGuildSchedules()
+		slog.Info("Daily schedule removed", "guild", i.GuildID, "user", interactionUserID(i))
+		b.respondDailySchedule(s, i, "🔕 This server's daily pictures are turned off.")
+		return
+	}
+
+	t, err := time.Parse("15:04", at)
+	if err != nil {
+		b.respondError(s, i, "Please give the time as HH:MM, e.g. 08:00, or `off`.")
+		return
+	}
+	if timezone != "" {
+		if _, err := time.LoadLocation(timezone); err != nil {
+			b.respondError(s, i, "Unknown timezone, use an IANA name such as Europe/Berlin.")
+			return
+		}
+	}
+
+	schedule := storage.GuildSchedule{
+		ChannelID: channelID,
+		Hour:      t.Hour(),
+		Minute:    t.Minute(),
+		Timezone:  timezone,
+	}
+	if err := b.storage.SetGuildSchedule(i.GuildID, &schedule); err != nil {
+		b.respondError(s, i, fmt.Sprintf("Failed to save the daily schedule: %v", err))
+		return
+	}
+	b.scheduler.ReloadGuildSchedules()
+	slog.Info("Daily schedule set", "guild", i.GuildID, "time", fmt.Sprintf("%02d:%02d", schedule.Hour, schedule.Minute), "channel", channelID, "user", interactionUserID(i))
+
+	next := b.scheduler.GuildNextSend(schedule)
+	b.respondDailySchedule(s, i, fmt.Sprintf("📅 Daily pictures will be posted to <#%s> every day at **%02d:%02d** (%s). Next post: <t:%d:F>",
+		channelID, schedule.Hour, schedule.Minute, next.Location(), next.Unix()))
+}
+
+// respondDailySchedule answers a /daily-schedule command
+func (b *Bot) respondDailySchedule(s *discordgo.Session, i *discordgo.InteractionCreate, content string) {
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
+	})
+}
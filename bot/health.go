@@ -0,0 +1,25 @@
+package bot
+
+import (
+	"log/slog"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// sessionHealthy reports whether the gateway connection is up. Until the
+// first Ready event it is down.
+func (b *Bot) sessionHealthy() bool {
+	return b.connected.Load()
+}
+
+// resumedHandler marks the session healthy again after a resumed connection
+func (b *Bot) resumedHandler(s *discordgo.Session, event *discordgo.Resumed) {
+	b.connected.Store(true)
+}
+
+// disconnectHandler marks the session unhealthy until it is ready again
+func (b *Bot) disconnectHandler(s *discordgo.Session, event *discordgo.Disconnect) {
+	if b.connected.Swap(false) {
+		slog.Warn("Lost the Discord gateway connection, reconnecting")
+	}
+}
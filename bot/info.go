@@ -0,0 +1,143 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	"KawaiiBot/api"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// nsfwModeFor maps a content option value to an NSFWMode, defaulting to SFW
+func nsfwModeFor(contentMode string) api.NSFWMode {
+	switch contentMode {
+	case "nsfw":
+		return api.NSFWModeNSFW
+	case "all":
+		return api.NSFWModeAll
+	default:
+		return api.NSFWModeSFW
+	}
+}
+
+// handleWaifuInfoSlashCommand handles the /waifu-info slash command. It shows
+// a waifu picture's metadata without downloading or uploading the image.
+func (b *Bot) handleWaifuInfoSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) {
+	contentMode := "sfw"
+	parser := b.optionParser(i.GuildID)
+	for _, option := range data.Options {
+		if option.Name == "content" {
+			contentMode = parser.choice(option, "sfw", "nsfw", "all")
+		}
+	}
+	if parser.problem != "" {
+		b.respondError(s, i, parser.problem)
+		return
+	}
+	mode := nsfwModeFor(contentMode)
+
+	// DMs have no age restriction, so NSFW there is opt-in
+	if mode != api.NSFWModeSFW && b.nsfwBlockedInDM(i.GuildID) {
+		b.respondError(s, i, nsfwDMText)
+		return
+	}
+
+	// Servers may require a role for NSFW requests
+	if mode != api.NSFWModeSFW {
+		if roleID := b.missingRole(i.GuildID, i.Member, roleGateNSFW); roleID != "" {
+			b.respondRoleGateInteraction(s, i, roleID)
+			return
+		}
+	}
+
+	// Ask for confirmation first if the guild gates NSFW content
+	if mode != api.NSFWModeSFW && b.nsfwGated(i.GuildID, interactionUserID(i)) {
+		b.respondNSFWGateInteraction(s, i)
+		return
+	}
+
+	images, err := b.waifuAPI.GetWaifuImagesContext(b.requests, mode, 1)
+	if err != nil {
+		b.respondError(s, i, b.fetchFailedText(i.GuildID, "waifu", err))
+		return
+	}
+	if len(images) == 0 || images[0].URL == "" {
+		b.respondError(s, i, b.noImagesText(i.GuildID, "waifu"))
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{waifuInfoEmbed(images[0])},
+		},
+	})
+}
+
+// waifuInfoEmbed renders the metadata of a waifu image
+func waifuInfoEmbed(img api.WaifuImage) *discordgo.MessageEmbed {
+	tags := make([]string, 0, len(img.Tags))
+	for _, tag := range img.Tags {
+		tags = append(tags, tag.Name)
+	}
+	if len(tags) == 0 {
+		tags = append(tags, "None")
+	}
+
+	return &discordgo.MessageEmbed{
+		Title: fmt.Sprintf("💜 Waifu #%d", img.ID),
+		URL:   img.URL,
+		Color: 0x9B59B6, // Purple color
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:   "Dimensions",
+				Value:  fmt.Sprintf("%d × %d", img.Width, img.Height),
+				Inline: true,
+			},
+			{
+				Name:   "File size",
+				Value:  formatBytes(img.ByteSize),
+				Inline: true,
+			},
+			{
+				Name:   "Format",
+				Value:  strings.TrimPrefix(waifuExtension(img), "."),
+				Inline: true,
+			},
+			{
+				Name:   "NSFW",
+				Value:  fmt.Sprintf("%t", img.IsNSFW),
+				Inline: true,
+			},
+			{
+				Name:   "Animated",
+				Value:  fmt.Sprintf("%t", img.IsAnimated),
+				Inline: true,
+			},
+			{
+				Name:  "Artist",
+				Value: truncate(api.CreditArtists(img.Artists), 1024),
+			},
+			{
+				Name:  "Tags",
+				Value: truncate(strings.Join(tags, ", "), 1024),
+			},
+		},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Click the title to open the full image",
+		},
+	}
+}
+
+// formatBytes renders a byte count in human readable units
+func formatBytes(n int) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
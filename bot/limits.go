@@ -0,0 +1,73 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// countLimits returns the most SFW and NSFW pictures a guild allows per
+// command. Unset limits and DMs use maxCount.
+func (b *Bot) countLimits(guildID string) (sfw, nsfw int) {
+	sfw, nsfw = maxCount, maxCount
+	if guildID == "" {
+		return sfw, nsfw
+	}
+
+	guild := b.storage.GetGuildSettings(guildID)
+	if guild.MaxSFWCount > 0 {
+		sfw = guild.MaxSFWCount
+	}
+	if guild.MaxNSFWCount > 0 {
+		nsfw = guild.MaxNSFWCount
+	}
+	return sfw, nsfw
+}
+
+// applyCountLimit clamps count to the guild's limit for the rating and
+// returns a note for the user if it had to
+func (b *Bot) applyCountLimit(guildID string, count int, nsfw bool) (int, string) {
+	sfwLimit, nsfwLimit := b.countLimits(guildID)
+	limit, rating := sfwLimit, "SFW"
+	if nsfw {
+		limit, rating = nsfwLimit, "NSFW"
+	}
+
+	if count <= limit {
+		return count, ""
+	}
+	return limit, fmt.Sprintf("ℹ️ This server allows at most %d %s picture(s) per command.", limit, rating)
+}
+
+// handleCountLimitsSlashCommand handles the /count-limits slash command
+func (b *Bot) handleCountLimitsSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) {
+	if i.GuildID == "" || !isGuildAdmin(i) {
+		b.respondError(s, i, "Only server admins can change the picture limits.")
+		return
+	}
+
+	sfw, nsfw := b.countLimits(i.GuildID)
+	for _, option := range data.Options {
+		switch option.Name {
+		case "sfw":
+			sfw = int(option.IntValue())
+		case "nsfw":
+			nsfw = int(option.IntValue())
+		}
+	}
+
+	if err := b.storage.SetCountLimits(i.GuildID, sfw, nsfw); err != nil {
+		b.respondError(s, i, fmt.Sprintf("Failed to save the picture limits: %v", err))
+		return
+	}
+	slog.Info("Count limits set", "guild", i.GuildID, "sfw", sfw, "nsfw", nsfw, "user", interactionUserID(i))
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("🔢 Commands now post at most **%d** SFW and **%d** NSFW picture(s).", sfw, nsfw),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
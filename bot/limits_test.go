@@ -0,0 +1,53 @@
+package bot
+
+import (
+	"path/filepath"
+	"testing"
+
+	"KawaiiBot/storage"
+)
+
+func TestApplyCountLimit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+	st, err := storage.New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.SetCountLimits("strict", 10, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	// The limits survive a restart
+	st, err = storage.New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &Bot{storage: st}
+
+	tests := []struct {
+		name    string
+		guildID string
+		count   int
+		nsfw    bool
+		want    int
+		note    bool
+	}{
+		{"SFW within the limit", "strict", 10, false, 10, false},
+		{"NSFW within the limit", "strict", 3, true, 3, false},
+		{"NSFW over the limit", "strict", 5, true, 3, true},
+		{"unset limits", "other", 10, true, 10, false},
+		{"DM", "", 10, true, 10, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, note := b.applyCountLimit(tt.guildID, tt.count, tt.nsfw)
+			if got != tt.want {
+				t.Errorf("count = %d, want %d", got, tt.want)
+			}
+			if (note != "") != tt.note {
+				t.Errorf("note = %q, want a note %t", note, tt.note)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"KawaiiBot/logging"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// isOwner returns whether the interaction user is the configured bot owner
+func (b *Bot) isOwner(i *discordgo.InteractionCreate) bool {
+	return b.ownerID != "" && interactionUserID(i) == b.ownerID
+}
+
+// handleLogLevelSlashCommand handles the /loglevel slash command. It changes
+// the level of the running logger until the next restart.
+func (b *Bot) handleLogLevelSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) {
+	if !b.isOwner(i) {
+		b.respondError(s, i, "Only the bot owner can change the log level.")
+		return
+	}
+
+	level, err := logging.ParseLevel(data.Options[0].StringValue())
+	if err != nil {
+		b.respondError(s, i, "Unknown log level, use debug, info, warn or error.")
+		return
+	}
+
+	previous := logging.Level()
+	logging.SetLevel(level)
+	slog.Info("Log level changed", "from", previous, "to", level, "user", interactionUserID(i))
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("📝 Log level is now **%s** (was %s).", strings.ToLower(level.String()), strings.ToLower(previous.String())),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
@@ -0,0 +1,95 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"KawaiiBot/api"
+)
+
+// maxCompressSource caps the download of an oversized image that may still be
+// compressed to fit the upload limit
+const maxCompressSource = 64 << 20
+
+// imageMemory accounts for the image bytes held in memory while they are
+// downloaded and uploaded. Once the budget is spent, further pictures are
+// linked instead of downloaded, so a burst of large requests can't exhaust a
+// small host.
+type imageMemory struct {
+	mutex  sync.Mutex
+	limit  int // IMAGE_MEMORY_MB in bytes, 0 means no cap
+	held   int
+	peak   int
+	linked int // pictures linked because the budget was spent
+}
+
+// reserve accounts for n more bytes, reporting false without reserving if
+// they don't fit in the budget
+func (mem *imageMemory) reserve(n int) bool {
+	mem.mutex.Lock()
+	defer mem.mutex.Unlock()
+
+	if mem.limit > 0 && mem.held+n > mem.limit {
+		mem.linked++
+		return false
+	}
+	mem.held += n
+	mem.peak = max(mem.peak, mem.held)
+	return true
+}
+
+// downloadCap returns the most bytes read for an image uploaded under limit.
+// Without compression a bigger image would be skipped anyway.
+func (b *Bot) downloadCap(limit int) int {
+	if b.compressImages {
+		return max(limit, maxCompressSource)
+	}
+	return limit
+}
+
+// downloadReserved runs download with the image's memory reserved before the
+// body is read. It returns the data and the bytes reserved for it, which the
+// caller releases once the image is sent; nothing stays reserved on error.
+func (b *Bot) downloadReserved(download func(api.Reserve) ([]byte, error)) ([]byte, int, error) {
+	reserved := 0
+	data, err := download(func(size int) bool {
+		if !b.memory.reserve(size) {
+			return false
+		}
+		reserved = size
+		return true
+	})
+	if err != nil {
+		b.memory.release(reserved)
+		return nil, 0, err
+	}
+
+	// Without a Content-Length the whole download cap was reserved
+	b.memory.release(reserved - len(data))
+	return data, len(data), nil
+}
+
+// release gives back n reserved bytes
+func (mem *imageMemory) release(n int) {
+	mem.mutex.Lock()
+	defer mem.mutex.Unlock()
+	mem.held -= n
+}
+
+// snapshot returns the bytes held now, the most held at once, the budget and
+// how many pictures were linked for lack of it
+func (mem *imageMemory) snapshot() (held, peak, limit, linked int) {
+	mem.mutex.Lock()
+	defer mem.mutex.Unlock()
+	return mem.held, mem.peak, mem.limit, mem.linked
+}
+
+// linkedNote lists the pictures posted as links because image memory was
+// short
+func linkedNote(urls []string) string {
+	if len(urls) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("🔗 I'm busy right now, so %d picture(s) are linked instead of uploaded:\n%s", len(urls), strings.Join(urls, "\n"))
+}
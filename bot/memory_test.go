@@ -0,0 +1,48 @@
+package bot
+
+import (
+	"errors"
+	"testing"
+
+	"KawaiiBot/api"
+)
+
+func TestDownloadReserved(t *testing.T) {
+	b := &Bot{memory: imageMemory{limit: 100}}
+
+	// An unannounced size reserves the cap, then keeps only what was read
+	data, held, err := b.downloadReserved(func(reserve api.Reserve) ([]byte, error) {
+		if !reserve(80) {
+			return nil, api.ErrNotReserved
+		}
+		return make([]byte, 30), nil
+	})
+	if err != nil || len(data) != 30 || held != 30 {
+		t.Fatalf("downloadReserved = %d bytes, %d held, %v; want 30, 30, nil", len(data), held, err)
+	}
+
+	// The budget refuses what no longer fits before anything is read
+	_, _, err = b.downloadReserved(func(reserve api.Reserve) ([]byte, error) {
+		if !reserve(80) {
+			return nil, api.ErrNotReserved
+		}
+		t.Error("downloaded an image that doesn't fit in image memory")
+		return nil, nil
+	})
+	if !errors.Is(err, api.ErrNotReserved) {
+		t.Fatalf("error = %v, want ErrNotReserved", err)
+	}
+
+	// A failed download gives its reservation back
+	_, _, err = b.downloadReserved(func(reserve api.Reserve) ([]byte, error) {
+		reserve(50)
+		return nil, errors.New("boom")
+	})
+	if err == nil {
+		t.Fatal("download error was lost")
+	}
+
+	if held, _, _, linked := b.memory.snapshot(); held != 30 || linked != 1 {
+		t.Errorf("memory holds %d bytes with %d linked, want 30 and 1", held, linked)
+	}
+}
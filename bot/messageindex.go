@@ -0,0 +1,164 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"KawaiiBot/api"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxPostedMessages bounds how many picture messages are remembered at once
+const maxPostedMessages = 10000
+
+// postedImage is one picture in a posted message
+type postedImage struct {
+	Provider string // providerNekos or providerWaifu
+	ID       string
+	URL      string
+}
+
+// postedMessage is a picture message the bot posted
+type postedMessage struct {
+	userID    string // who asked for the pictures
+	channelID string
+	images    []postedImage
+	postedAt  time.Time
+}
+
+// messageIndex maps the IDs of posted picture messages to the pictures they
+// contain. Entries expire after ttl; when the index is full, expired entries
+// are swept and then the oldest entry makes room. It is safe for concurrent
+// use.
+type messageIndex struct {
+	mutex      sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]postedMessage
+}
+
+// newMessageIndex creates an index keeping entries for ttl, holding at most
+// maxEntries at once
+func newMessageIndex(ttl time.Duration, maxEntries int) *messageIndex {
+	return &messageIndex{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]postedMessage),
+	}
+}
+
+// put stores the entry of a message, replacing any earlier one
+func (x *messageIndex) put(messageID string, entry postedMessage) {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+
+	if _, ok := x.entries[messageID]; !ok && len(x.entries) >= x.maxEntries {
+		x.makeRoom(entry.postedAt)
+	}
+	x.entries[messageID] = entry
+}
+
+// len returns the number of entries, expired ones included until swept
+func (x *messageIndex) len() int {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+	return len(x.entries)
+}
+
+// makeRoom drops expired entries, and the oldest one if none had expired.
+// The caller holds the mutex.
+func (x *messageIndex) makeRoom(now time.Time) {
+	oldestID := ""
+	var oldest time.Time
+	for id, entry := range x.entries {
+		if now.Sub(entry.postedAt) > x.ttl {
+			delete(x.entries, id)
+			continue
+		}
+		if oldestID == "" || entry.postedAt.Before(oldest) {
+			oldestID, oldest = id, entry.postedAt
+		}
+	}
+	if len(x.entries) >= x.maxEntries {
+		delete(x.entries, oldestID)
+	}
+}
+
+// get returns the entry of a message, if it is indexed and not expired
+func (x *messageIndex) get(messageID string) (postedMessage, bool) {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+
+	entry, ok := x.entries[messageID]
+	if !ok {
+		return postedMessage{}, false
+	}
+	if time.Since(entry.postedAt) > x.ttl {
+		delete(x.entries, messageID)
+		return postedMessage{}, false
+	}
+	return entry, true
+}
+
+// latest returns the most recently posted unexpired entry in a channel
+func (x *messageIndex) latest(channelID string) (postedMessage, bool) {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+
+	var found postedMessage
+	ok := false
+	for _, entry := range x.entries {
+		if entry.channelID != channelID || time.Since(entry.postedAt) > x.ttl {
+			continue
+		}
+		if !ok || entry.postedAt.After(found.postedAt) {
+			found, ok = entry, true
+		}
+	}
+	return found, ok
+}
+
+// remove forgets a message
+func (x *messageIndex) remove(messageID string) {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+	delete(x.entries, messageID)
+}
+
+// trackPosted indexes a picture message with its invoker and pictures, so
+// features like trash reactions can look them up later
+func (b *Bot) trackPosted(msg *discordgo.Message, userID string, images []postedImage) {
+	if msg == nil || userID == "" {
+		return
+	}
+	b.posted.put(msg.ID, postedMessage{userID: userID, channelID: msg.ChannelID, images: images, postedAt: time.Now()})
+}
+
+// nekosPosted describes nekos.moe images for the message index
+func nekosPosted(images []api.Image) []postedImage {
+	posted := make([]postedImage, 0, len(images))
+	for _, img := range images {
+		posted = append(posted, postedImage{
+			Provider: providerNekos,
+			ID:       img.ID,
+			URL:      fmt.Sprintf("https://nekos.moe/image/%s.jpg", img.ID),
+		})
+	}
+	return posted
+}
+
+// waifuPosted describes waifu.im images for the message index
+func waifuPosted(images []api.WaifuImage) []postedImage {
+	posted := make([]postedImage, 0, len(images))
+	for _, img := range images {
+		posted = append(posted, postedImage{
+			Provider: providerWaifu,
+			ID:       strconv.FormatInt(img.ID, 10),
+			URL:      img.URL,
+		})
+	}
+	return posted
+}
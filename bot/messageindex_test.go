@@ -0,0 +1,128 @@
+package bot
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMessageIndexExpiry(t *testing.T) {
+	x := newMessageIndex(time.Hour, 10)
+	now := time.Now()
+	x.put("fresh", postedMessage{channelID: "c", postedAt: now.Add(-59 * time.Minute)})
+	x.put("stale", postedMessage{channelID: "c", postedAt: now.Add(-61 * time.Minute)})
+
+	if _, ok := x.get("fresh"); !ok {
+		t.Error("an entry younger than the TTL expired")
+	}
+	if _, ok := x.get("stale"); ok {
+		t.Error("an entry older than the TTL was returned")
+	}
+	if got := x.len(); got != 1 {
+		t.Errorf("%d entries left, want the stale one dropped on lookup", got)
+	}
+
+	x.put("stale in d", postedMessage{channelID: "d", postedAt: now.Add(-2 * time.Hour)})
+	if _, ok := x.latest("d"); ok {
+		t.Error("latest returned an expired entry")
+	}
+	x.remove("fresh")
+	if _, ok := x.latest("c"); ok {
+		t.Error("latest returned a removed entry")
+	}
+}
+
+func TestMessageIndexLatest(t *testing.T) {
+	x := newMessageIndex(time.Hour, 10)
+	now := time.Now()
+	x.put("older", postedMessage{userID: "older", channelID: "c", postedAt: now.Add(-2 * time.Minute)})
+	x.put("newer", postedMessage{userID: "newer", channelID: "c", postedAt: now.Add(-time.Minute)})
+	x.put("elsewhere", postedMessage{userID: "elsewhere", channelID: "d", postedAt: now})
+
+	entry, ok := x.latest("c")
+	if !ok || entry.userID != "newer" {
+		t.Errorf("latest = %q, %t; want the newer entry of the channel", entry.userID, ok)
+	}
+}
+
+func TestMessageIndexCapacity(t *testing.T) {
+	now := time.Now()
+
+	t.Run("evicts the oldest", func(t *testing.T) {
+		x := newMessageIndex(time.Hour, 3)
+		for n := range 3 {
+			x.put(fmt.Sprint(n), postedMessage{postedAt: now.Add(time.Duration(n) * time.Second)})
+		}
+		x.put("3", postedMessage{postedAt: now.Add(3 * time.Second)})
+
+		if got := x.len(); got != 3 {
+			t.Errorf("%d entries, want at most 3", got)
+		}
+		if _, ok := x.get("0"); ok {
+			t.Error("the oldest entry survived")
+		}
+		for _, id := range []string{"1", "2", "3"} {
+			if _, ok := x.get(id); !ok {
+				t.Errorf("entry %s was evicted instead of the oldest", id)
+			}
+		}
+	})
+
+	t.Run("sweeps expired entries first", func(t *testing.T) {
+		x := newMessageIndex(time.Hour, 3)
+		x.put("expired 1", postedMessage{postedAt: now.Add(-2 * time.Hour)})
+		x.put("expired 2", postedMessage{postedAt: now.Add(-2 * time.Hour)})
+		x.put("live", postedMessage{postedAt: now.Add(-time.Minute)})
+		x.put("new", postedMessage{postedAt: now})
+
+		if got := x.len(); got != 2 {
+			t.Errorf("%d entries, want both expired ones swept", got)
+		}
+		if _, ok := x.get("live"); !ok {
+			t.Error("a live entry was evicted although expired ones made room")
+		}
+	})
+
+	t.Run("replacing doesn't evict", func(t *testing.T) {
+		x := newMessageIndex(time.Hour, 2)
+		x.put("a", postedMessage{postedAt: now})
+		x.put("b", postedMessage{postedAt: now})
+		x.put("a", postedMessage{postedAt: now, userID: "again"})
+
+		if got := x.len(); got != 2 {
+			t.Errorf("%d entries, want 2", got)
+		}
+		if _, ok := x.get("b"); !ok {
+			t.Error("replacing an entry evicted another one")
+		}
+	})
+}
+
+func TestMessageIndexConcurrent(t *testing.T) {
+	const writers, perWriter, maxEntries = 8, 200, 100
+	x := newMessageIndex(time.Hour, maxEntries)
+
+	var wg sync.WaitGroup
+	for w := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			channelID := fmt.Sprint(w)
+			for n := range perWriter {
+				id := fmt.Sprintf("%d-%d", w, n)
+				x.put(id, postedMessage{channelID: channelID, postedAt: time.Now()})
+				x.get(id)
+				x.latest(channelID)
+				if n%3 == 0 {
+					x.remove(id)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := x.len(); got > maxEntries {
+		t.Errorf("%d entries, over the limit of %d", got, maxEntries)
+	}
+}
@@ -0,0 +1,120 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Names of the bot messages a guild can customize
+const (
+	msgNoImages    = "no-images"
+	msgFetchFailed = "fetch-failed"
+	msgCooldown    = "cooldown"
+)
+
+// maxTemplateLength bounds a custom message template
+const maxTemplateLength = 500
+
+// messageTemplate is a customizable bot message with its default text and
+// the placeholders it can use
+type messageTemplate struct {
+	name         string
+	text         string
+	placeholders []string
+}
+
+var messageTemplates = []messageTemplate{
+	{msgNoImages, "Sorry, no {kind} images found!", []string{"{kind}"}},
+	{msgFetchFailed, "Sorry, I couldn't fetch {kind} images: {error}", []string{"{kind}", "{error}"}},
+	{msgCooldown, "Please wait {wait} before testing another webhook.", []string{"{wait}"}},
+}
+
+// findMessageTemplate returns the template with the given name
+func findMessageTemplate(name string) (messageTemplate, bool) {
+	n := slices.IndexFunc(messageTemplates, func(t messageTemplate) bool { return t.name == name })
+	if n < 0 {
+		return messageTemplate{}, false
+	}
+	return messageTemplates[n], true
+}
+
+// message renders a bot message for a guild, using the guild's template if
+// it set one. vars are placeholder and value pairs such as "{kind}", "waifu".
+func (b *Bot) message(guildID, name string, vars ...string) string {
+	template, _ := findMessageTemplate(name)
+	text := template.text
+	if guildID != "" {
+		if custom := b.storage.GetGuildSettings(guildID).Messages[name]; custom != "" {
+			text = custom
+		}
+	}
+	return strings.NewReplacer(vars...).Replace(text)
+}
+
+// noImagesText is shown when a provider returned no pictures
+func (b *Bot) noImagesText(guildID, kind string) string {
+	return b.message(guildID, msgNoImages, "{kind}", kind)
+}
+
+// fetchFailedText is shown when fetching pictures from a provider failed
+func (b *Bot) fetchFailedText(guildID, kind string, err error) string {
+	return b.message(guildID, msgFetchFailed, "{kind}", kind, "{error}", err.Error())
+}
+
+// cooldownText is shown while a command is on cooldown
+func (b *Bot) cooldownText(guildID string, wait time.Duration) string {
+	return b.message(guildID, msgCooldown, "{wait}", wait.Round(time.Second).String())
+}
+
+// handleMessageTemplateSlashCommand handles the /message-template slash
+// command. It sets or, without text, resets one of the guild's messages.
+func (b *Bot) handleMessageTemplateSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) {
+	if i.GuildID == "" || !isGuildAdmin(i) {
+		b.respondError(s, i, "Only server admins can change the bot's messages.")
+		return
+	}
+
+	name, text := "", ""
+	for _, option := range data.Options {
+		switch option.Name {
+		case "message":
+			name = option.StringValue()
+		case "text":
+			text = strings.TrimSpace(option.StringValue())
+		}
+	}
+
+	template, ok := findMessageTemplate(name)
+	if !ok {
+		b.respondError(s, i, "Unknown message, pick one from the list.")
+		return
+	}
+	if len([]rune(text)) > maxTemplateLength {
+		b.respondError(s, i, fmt.Sprintf("Messages can be at most %d characters long.", maxTemplateLength))
+		return
+	}
+
+	if err := b.storage.SetMessageTemplate(i.GuildID, name, text); err != nil {
+		b.respondError(s, i, fmt.Sprintf("Failed to update the message: %v", err))
+		return
+	}
+	slog.Info("Message template changed", "template", name, "guild", i.GuildID, "user", interactionUserID(i))
+
+	content := fmt.Sprintf("💬 The **%s** message is back to the default:\n> %s", name, template.text)
+	if text != "" {
+		content = fmt.Sprintf("💬 The **%s** message is now:\n> %s\nPlaceholders: %s", name, text, strings.Join(template.placeholders, ", "))
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
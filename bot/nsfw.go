@@ -0,0 +1,145 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// nsfwConfirmPrefix prefixes the custom ID of the NSFW gate button. The ID of
+// the user the button belongs to follows the prefix.
+const nsfwConfirmPrefix = "nsfw_confirm:"
+
+// nsfwGateText is shown to users who have not yet passed the NSFW gate
+const nsfwGateText = "🔞 This server requires a one-time confirmation before serving NSFW content."
+
+// nsfwDMText is shown when NSFW is requested in a DM without ALLOW_NSFW_DM
+const nsfwDMText = "🔞 NSFW content is not available in direct messages."
+
+// nsfwBlockedInDM returns whether an NSFW request must be refused because it
+// came from a DM. Unlike guild channels, DMs carry no age-restricted flag the
+// bot could check, so serving NSFW there is an explicit operator decision.
+func (b *Bot) nsfwBlockedInDM(guildID string) bool {
+	return guildID == "" && !b.allowNSFWDM
+}
+
+// nsfwGated returns whether a user must confirm before receiving NSFW content in a guild
+func (b *Bot) nsfwGated(guildID, userID string) bool {
+	if guildID == "" {
+		return false
+	}
+	return b.storage.GetGuildSettings(guildID).NSFWGate && !b.storage.IsNSFWConfirmed(guildID, userID)
+}
+
+// nsfwConfirmComponents builds the confirmation button for a user
+func nsfwConfirmComponents(userID string) []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "I confirm I'm 18+ and this channel allows NSFW",
+					Style:    discordgo.DangerButton,
+					CustomID: nsfwConfirmPrefix + userID,
+				},
+			},
+		},
+	}
+}
+
+// respondNSFWGateMessage asks a message command user to pass the NSFW gate
+func (b *Bot) respondNSFWGateMessage(s *discordgo.Session, m *discordgo.MessageCreate) {
+	s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+		Content:    fmt.Sprintf("<@%s> %s", m.Author.ID, nsfwGateText),
+		Components: nsfwConfirmComponents(m.Author.ID),
+		AllowedMentions: &discordgo.MessageAllowedMentions{
+			Users: []string{m.Author.ID},
+		},
+	})
+}
+
+// respondNSFWGateInteraction asks a slash command user to pass the NSFW gate
+func (b *Bot) respondNSFWGateInteraction(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content:    nsfwGateText,
+			Components: nsfwConfirmComponents(interactionUserID(i)),
+			Flags:      discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// handleNSFWConfirmComponent records a user's NSFW confirmation
+func (b *Bot) handleNSFWConfirmComponent(s *discordgo.Session, i *discordgo.InteractionCreate, customID string) {
+	userID := interactionUserID(i)
+	if strings.TrimPrefix(customID, nsfwConfirmPrefix) != userID {
+		b.respondError(s, i, "This confirmation belongs to someone else.")
+		return
+	}
+
+	if err := b.storage.ConfirmNSFW(i.GuildID, userID); err != nil {
+		b.respondError(s, i, fmt.Sprintf("Failed to save your confirmation: %v", err))
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    "✅ Thanks for confirming! Run your command again to get your pictures.",
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+}
+
+// handleNSFWGateSlashCommand handles the /nsfw-gate slash command
+func (b *Bot) handleNSFWGateSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) {
+	if i.GuildID == "" || !isGuildAdmin(i) {
+		b.respondError(s, i, "Only server admins can change the NSFW gate.")
+		return
+	}
+
+	enabled := false
+	for _, option := range data.Options {
+		if option.Name == "state" {
+			enabled = option.StringValue() == "on"
+		}
+	}
+
+	if err := b.storage.SetNSFWGate(i.GuildID, enabled); err != nil {
+		b.respondError(s, i, fmt.Sprintf("Failed to update NSFW gate: %v", err))
+		return
+	}
+
+	content := "🔓 NSFW confirmation gate is now **off**."
+	if enabled {
+		content = "🔞 NSFW confirmation gate is now **on**. Users must confirm once before receiving NSFW content."
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// interactionUserID returns the ID of the user who triggered an interaction
+func interactionUserID(i *discordgo.InteractionCreate) string {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User.ID
+	}
+	if i.User != nil {
+		return i.User.ID
+	}
+	return ""
+}
+
+// isGuildAdmin returns whether the interaction user can manage the guild
+func isGuildAdmin(i *discordgo.InteractionCreate) bool {
+	if i.Member == nil {
+		return false
+	}
+	return i.Member.Permissions&(discordgo.PermissionAdministrator|discordgo.PermissionManageGuild) != 0
+}
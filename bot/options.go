@@ -0,0 +1,97 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// slashCount reads the count option of a picture command. A missing or zero
+// count means one picture.
+func slashCount(options []*discordgo.ApplicationCommandInteractionDataOption) int {
+	for _, option := range options {
+		if option.Name == "count" {
+			return max(int(option.IntValue()), 1)
+		}
+	}
+	return 1
+}
+
+// optionParser reads the string options of a slash command. Discord only
+// checks their type, so a value outside the choices can still arrive, e.g.
+// from a stale command registration. By default such a value falls back like
+// a missing option; guilds with strict options get an error instead.
+type optionParser struct {
+	strict  bool
+	problem string // first invalid value, only recorded in strict mode
+}
+
+// optionParser returns the option parser for an interaction in a guild
+func (b *Bot) optionParser(guildID string) *optionParser {
+	return &optionParser{strict: guildID != "" && b.storage.GetGuildSettings(guildID).StrictOptions}
+}
+
+// yesNo reads a yes/no option such as nsfw, see parseYesNo
+func (p *optionParser) yesNo(option *discordgo.ApplicationCommandInteractionDataOption) bool {
+	yes, ok := yesNo(option.StringValue())
+	if !ok {
+		p.invalid(option, "y", "n")
+	}
+	return yes
+}
+
+// choice reads an option that must be one of choices, returning it trimmed
+// and lowercased. In lenient mode an unknown value is returned as well, so
+// the caller's fallback for it applies.
+func (p *optionParser) choice(option *discordgo.ApplicationCommandInteractionDataOption, choices ...string) string {
+	value := strings.ToLower(strings.TrimSpace(option.StringValue()))
+	if !slices.Contains(choices, value) {
+		p.invalid(option, choices...)
+	}
+	return value
+}
+
+// invalid records an invalid value of option in strict mode
+func (p *optionParser) invalid(option *discordgo.ApplicationCommandInteractionDataOption, valid ...string) {
+	if !p.strict || p.problem != "" {
+		return
+	}
+	p.problem = fmt.Sprintf("Invalid value %q for `%s`, expected one of: %s.", option.StringValue(), option.Name, strings.Join(valid, ", "))
+}
+
+// handleStrictOptionsSlashCommand handles the /strict-options slash command
+func (b *Bot) handleStrictOptionsSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) {
+	if i.GuildID == "" || !isGuildAdmin(i) {
+		b.respondError(s, i, "Only server admins can change how command options are checked.")
+		return
+	}
+
+	strict := false
+	for _, option := range data.Options {
+		if option.Name == "state" {
+			strict = option.StringValue() == "on"
+		}
+	}
+
+	if err := b.storage.SetStrictOptions(i.GuildID, strict); err != nil {
+		b.respondError(s, i, fmt.Sprintf("Failed to update strict options: %v", err))
+		return
+	}
+	slog.Info("Strict options set", "guild", i.GuildID, "strict", strict, "user", interactionUserID(i))
+
+	content := "🧩 Invalid command options now fall back to their defaults again."
+	if strict {
+		content = "🧩 Strict options are **on**, commands with an invalid option value are rejected."
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
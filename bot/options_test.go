@@ -0,0 +1,109 @@
+package bot
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"KawaiiBot/storage"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestSlashCount(t *testing.T) {
+	countOption := func(n float64) *discordgo.ApplicationCommandInteractionDataOption {
+		return &discordgo.ApplicationCommandInteractionDataOption{Name: "count", Type: discordgo.ApplicationCommandOptionInteger, Value: n}
+	}
+	nsfw := &discordgo.ApplicationCommandInteractionDataOption{Name: "nsfw", Type: discordgo.ApplicationCommandOptionString, Value: "y"}
+
+	tests := []struct {
+		name    string
+		options []*discordgo.ApplicationCommandInteractionDataOption
+		want    int
+	}{
+		{"no options", nil, 1},
+		{"count omitted", []*discordgo.ApplicationCommandInteractionDataOption{nsfw}, 1},
+		{"zero", []*discordgo.ApplicationCommandInteractionDataOption{countOption(0)}, 1},
+		{"negative", []*discordgo.ApplicationCommandInteractionDataOption{countOption(-4)}, 1},
+		{"given", []*discordgo.ApplicationCommandInteractionDataOption{nsfw, countOption(5)}, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := slashCount(tt.options); got != tt.want {
+				t.Errorf("slashCount = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOptionParser(t *testing.T) {
+	option := func(name, value string) *discordgo.ApplicationCommandInteractionDataOption {
+		return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionString, Value: value}
+	}
+
+	tests := []struct {
+		name    string
+		strict  bool
+		read    func(p *optionParser) any
+		want    any
+		problem bool
+	}{
+		{"valid yes/no", true, func(p *optionParser) any { return p.yesNo(option("nsfw", "Yes")) }, true, false},
+		{"invalid yes/no, lenient", false, func(p *optionParser) any { return p.yesNo(option("nsfw", "maybe")) }, false, false},
+		{"invalid yes/no, strict", true, func(p *optionParser) any { return p.yesNo(option("nsfw", "maybe")) }, false, true},
+		{"valid choice", true, func(p *optionParser) any { return p.choice(option("content", " NSFW "), "sfw", "nsfw", "all") }, "nsfw", false},
+		{"invalid choice, lenient", false, func(p *optionParser) any { return p.choice(option("content", "lewd"), "sfw", "nsfw") }, "lewd", false},
+		{"invalid choice, strict", true, func(p *optionParser) any { return p.choice(option("content", "lewd"), "sfw", "nsfw") }, "lewd", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &optionParser{strict: tt.strict}
+			if got := tt.read(p); got != tt.want {
+				t.Errorf("read %v, want %v", got, tt.want)
+			}
+			if (p.problem != "") != tt.problem {
+				t.Errorf("problem = %q, want a problem %t", p.problem, tt.problem)
+			}
+		})
+	}
+
+	t.Run("keeps the first problem", func(t *testing.T) {
+		p := &optionParser{strict: true}
+		p.yesNo(option("nsfw", "maybe"))
+		p.choice(option("content", "lewd"), "sfw", "nsfw")
+		if !strings.Contains(p.problem, "`nsfw`") {
+			t.Errorf("problem = %q, want the first invalid option", p.problem)
+		}
+	})
+}
+
+func TestStrictOptionsPerGuild(t *testing.T) {
+	st, err := storage.New(filepath.Join(t.TempDir(), "settings.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.SetStrictOptions("strict", true); err != nil {
+		t.Fatal(err)
+	}
+	b := &Bot{storage: st}
+	data := discordgo.ApplicationCommandInteractionData{Name: "catgirl", Options: []*discordgo.ApplicationCommandInteractionDataOption{
+		{Name: "nsfw", Type: discordgo.ApplicationCommandOptionString, Value: "maybe"},
+	}}
+
+	if !b.optionParser("strict").strict || b.optionParser("lenient").strict || b.optionParser("").strict {
+		t.Fatal("strict options don't follow the guild settings")
+	}
+
+	posts := stubDiscord(t)
+	s, _ := discordgo.New("Bot test")
+	i := &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{ID: "i", Token: "token", GuildID: "strict",
+		Member: &discordgo.Member{User: &discordgo.User{ID: "u"}}}}
+	b.handleCatgirlSlashCommand(s, i, data)
+
+	got := posts()
+	if len(got) != 1 || !strings.Contains(got[0].Data.Content, "Invalid value \"maybe\" for `nsfw`") {
+		t.Errorf("posted %+v, want the invalid option rejected", got)
+	}
+}
@@ -0,0 +1,49 @@
+package bot
+
+import (
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// fallbackFlags returns the message flags for URL fallback messages in a guild.
+// Link previews are kept unless the guild turned them off.
+func (b *Bot) fallbackFlags(guildID string) discordgo.MessageFlags {
+	if guildID != "" && b.storage.GetGuildSettings(guildID).SuppressLinkEmbeds {
+		return discordgo.MessageFlagsSuppressEmbeds
+	}
+	return 0
+}
+
+// handleLinkPreviewsSlashCommand handles the /link-previews slash command
+func (b *Bot) handleLinkPreviewsSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) {
+	if i.GuildID == "" || !isGuildAdmin(i) {
+		b.respondError(s, i, "Only server admins can change link previews.")
+		return
+	}
+
+	enabled := true
+	for _, option := range data.Options {
+		if option.Name == "state" {
+			enabled = option.StringValue() == "on"
+		}
+	}
+
+	if err := b.storage.SetSuppressLinkEmbeds(i.GuildID, !enabled); err != nil {
+		b.respondError(s, i, fmt.Sprintf("Failed to update link previews: %v", err))
+		return
+	}
+
+	content := "🔗 Link previews are now **on** when pictures are posted as URLs."
+	if !enabled {
+		content = "🔗 Link previews are now **off**, URL fallbacks stay compact."
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
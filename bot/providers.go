@@ -0,0 +1,67 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	"KawaiiBot/api"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// providerFeatures are the rows of the /providers matrix
+var providerFeatures = []struct {
+	name string
+	has  func(api.Capabilities) bool
+}{
+	{"NSFW", func(c api.Capabilities) bool { return c.NSFW }},
+	{"Mixed SFW + NSFW", func(c api.Capabilities) bool { return c.Mixed }},
+	{"GIFs", func(c api.Capabilities) bool { return c.Animated }},
+	{"Tag search", func(c api.Capabilities) bool { return c.Tags }},
+	{"Artist search", func(c api.Capabilities) bool { return c.Artists }},
+	{"Most liked", func(c api.Capabilities) bool { return c.Popular }},
+	{"Color filter", func(c api.Capabilities) bool { return c.DominantColor }},
+}
+
+// providers lists the image sources the bot uses
+func (b *Bot) providers() []api.Provider {
+	return []api.Provider{b.nekosAPI, b.waifuAPI}
+}
+
+// providersEmbed renders the feature matrix, one column per provider
+func providersEmbed(providers []api.Provider) *discordgo.MessageEmbed {
+	fields := make([]*discordgo.MessageEmbedField, 0, len(providers))
+	for _, provider := range providers {
+		capabilities := provider.Capabilities()
+		lines := make([]string, 0, len(providerFeatures))
+		for _, feature := range providerFeatures {
+			mark := "❌"
+			if feature.has(capabilities) {
+				mark = "✅"
+			}
+			lines = append(lines, fmt.Sprintf("%s %s", mark, feature.name))
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   capabilities.Name,
+			Value:  strings.Join(lines, "\n"),
+			Inline: true,
+		})
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:  "🖼️ Image providers",
+		Color:  0x3498DB, // Blue color
+		Fields: fields,
+	}
+}
+
+// handleProvidersSlashCommand handles the /providers slash command
+func (b *Bot) handleProvidersSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{providersEmbed(b.providers())},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
@@ -0,0 +1,65 @@
+package bot
+
+import (
+	"log/slog"
+	"math/rand/v2"
+
+	"KawaiiBot/api"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// pickWaifu decides whether a /random pick is served by Waifu.im
+func (b *Bot) pickWaifu() bool {
+	return rand.IntN(100) < b.randomWaifuWeight
+}
+
+// handleRandomSlashCommand handles the /random slash command. It serves one
+// SFW picture from a provider picked by RANDOM_WAIFU_WEIGHT.
+func (b *Bot) handleRandomSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if b.guildAtFileCap(i.GuildID) {
+		b.respondError(s, i, tooManyFilesText)
+		return
+	}
+
+	// Defer response to avoid timeout
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		slog.Warn("Failed to defer interaction", "error", err)
+		return
+	}
+
+	// Show typing indicator
+	s.ChannelTyping(i.ChannelID)
+
+	if b.pickWaifu() {
+		images, err := b.fetchWaifuImages(api.WaifuOptions{Mode: api.NSFWModeSFW}, 1)
+		if err != nil {
+			b.editError(s, i, b.fetchFailedText(i.GuildID, "waifu", err))
+			return
+		}
+		if len(images) == 0 {
+			b.editError(s, i, b.noImagesText(i.GuildID, "waifu"))
+			return
+		}
+
+		b.recordServed(i.GuildID, len(images))
+		b.sendWaifuImagesInteraction(s, i, images, "")
+		return
+	}
+
+	images, err := b.nekosAPI.GetRandomImagesContext(b.requests, 1, "safe")
+	if err != nil {
+		b.editError(s, i, b.fetchFailedText(i.GuildID, "catgirl", err))
+		return
+	}
+	if len(images) == 0 {
+		b.editError(s, i, b.noImagesText(i.GuildID, "catgirl"))
+		return
+	}
+
+	b.recordServed(i.GuildID, len(images))
+	b.sendImagesInteraction(s, i, images, "")
+}
@@ -0,0 +1,101 @@
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Rate limit retry tuning
+const (
+	maxRateLimitRetries = 3
+	maxRateLimitWait    = 30 * time.Second // longer waits fail rather than hold up the command
+)
+
+// rateLimits counts the Discord rate limits the bot ran into
+type rateLimits struct {
+	mutex   sync.Mutex
+	hits    int
+	lastURL string
+	lastHit time.Time
+}
+
+// record counts a rate limit hit on url
+func (r *rateLimits) record(url string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.hits++
+	r.lastURL = url
+	r.lastHit = time.Now()
+}
+
+// snapshot returns the hit count and the most recent hit
+func (r *rateLimits) snapshot() (hits int, lastURL string, lastHit time.Time) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.hits, r.lastURL, r.lastHit
+}
+
+// rateLimitHandler counts the rate limits discordgo waits out by itself
+func (b *Bot) rateLimitHandler(s *discordgo.Session, r *discordgo.RateLimit) {
+	b.rateLimits.record(r.URL)
+	slog.Warn("Rate limited by Discord", "url", r.URL, "retry_in", r.RetryAfter)
+}
+
+// rateLimitDelay reports whether err is a Discord rate limit, and if so how
+// long to wait before retrying and which URL was limited
+func rateLimitDelay(err error) (time.Duration, string, bool) {
+	var limitErr *discordgo.RateLimitError
+	if errors.As(err, &limitErr) {
+		return limitErr.RetryAfter, limitErr.URL, true
+	}
+
+	var restErr *discordgo.RESTError
+	if !errors.As(err, &restErr) || restErr.Response == nil || restErr.Response.StatusCode != http.StatusTooManyRequests {
+		return 0, "", false
+	}
+
+	body := discordgo.TooManyRequests{RetryAfter: time.Second}
+	if err := json.Unmarshal(restErr.ResponseBody, &body); err != nil {
+		body.RetryAfter = time.Second
+	}
+	url := ""
+	if restErr.Request != nil {
+		url = restErr.Request.URL.String()
+	}
+	return body.RetryAfter, url, true
+}
+
+// retryRateLimited calls send again after the indicated delay while it fails
+// with a 429, up to maxRateLimitRetries times. send must pass the request
+// options on: they turn off discordgo's own retry, which would otherwise wait
+// out any 429 itself, however long. Seekable file readers are rewound before
+// each retry so uploads go out in full.
+func retryRateLimited[T any](limits *rateLimits, files []*discordgo.File, send func(options ...discordgo.RequestOption) (T, error)) (T, error) {
+	noRetry := discordgo.WithRetryOnRatelimit(false)
+	result, err := send(noRetry)
+	for range maxRateLimitRetries {
+		delay, url, limited := rateLimitDelay(err)
+		if !limited || delay > maxRateLimitWait {
+			break
+		}
+
+		limits.record(url)
+		slog.Warn("Send rate limited by Discord", "retry_in", delay)
+		time.Sleep(delay)
+
+		for _, file := range files {
+			if seeker, ok := file.Reader.(io.Seeker); ok {
+				seeker.Seek(0, io.SeekStart)
+			}
+		}
+		result, err = send(noRetry)
+	}
+	return result, err
+}
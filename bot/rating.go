@@ -0,0 +1,108 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Ratings a guild can pick as the default of its picture commands
+const (
+	ratingSafe     = "safe"
+	ratingExplicit = "explicit"
+	ratingMixed    = "mixed" // SFW and NSFW pictures alike
+)
+
+var ratings = []string{ratingSafe, ratingExplicit, ratingMixed}
+
+// resolveRating picks the rating of a picture request. An explicit choice by
+// the user wins; otherwise the guild default applies, but only in an
+// age-restricted channel. Anything else is safe.
+func resolveRating(requested, guildDefault string, ageRestricted bool) string {
+	if requested != "" {
+		return requested
+	}
+	if ageRestricted && (guildDefault == ratingExplicit || guildDefault == ratingMixed) {
+		return guildDefault
+	}
+	return ratingSafe
+}
+
+// requestRating resolves the rating of a request in a channel, looking the
+// channel up only when the guild default needs an age-restricted one
+func (b *Bot) requestRating(s *discordgo.Session, guildID, channelID, requested string) string {
+	guildDefault := ""
+	if requested == "" && guildID != "" {
+		guildDefault = b.storage.GetGuildSettings(guildID).DefaultRating
+	}
+
+	ageRestricted := false
+	if requested == "" && guildDefault != "" && guildDefault != ratingSafe {
+		ch, err := lookupChannel(s, channelID)
+		if err != nil {
+			slog.Warn("Failed to look up channel", "channel", channelID, "error", err)
+		}
+		ageRestricted = err == nil && ch.NSFW
+	}
+	return resolveRating(requested, guildDefault, ageRestricted)
+}
+
+// nekosRating maps a rating to GetRandomImages, where mixed is no rating
+func nekosRating(rating string) string {
+	if rating == ratingMixed {
+		return ""
+	}
+	return rating
+}
+
+// contentModeFor maps a rating to a waifu content mode
+func contentModeFor(rating string) string {
+	switch rating {
+	case ratingExplicit:
+		return "nsfw"
+	case ratingMixed:
+		return "all"
+	default:
+		return "sfw"
+	}
+}
+
+// handleDefaultRatingSlashCommand handles the /default-rating slash command
+func (b *Bot) handleDefaultRatingSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) {
+	if i.GuildID == "" || !isGuildAdmin(i) {
+		b.respondError(s, i, "Only server admins can change the default rating.")
+		return
+	}
+
+	rating := ratingSafe
+	for _, option := range data.Options {
+		if option.Name == "rating" {
+			rating = option.StringValue()
+		}
+	}
+	if !slices.Contains(ratings, rating) {
+		b.respondError(s, i, fmt.Sprintf("Unknown rating %q.", rating))
+		return
+	}
+
+	if err := b.storage.SetDefaultRating(i.GuildID, rating); err != nil {
+		b.respondError(s, i, fmt.Sprintf("Failed to update the default rating: %v", err))
+		return
+	}
+	slog.Info("Default rating set", "guild", i.GuildID, "rating", rating, "user", interactionUserID(i))
+
+	content := "🛡️ Picture commands default to **safe** pictures again."
+	if rating != ratingSafe {
+		content = fmt.Sprintf("🔞 Picture commands now default to **%s** pictures in age-restricted channels; other channels stay safe.", rating)
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
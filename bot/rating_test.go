@@ -0,0 +1,99 @@
+package bot
+
+import (
+	"path/filepath"
+	"testing"
+
+	"KawaiiBot/storage"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestResolveRating(t *testing.T) {
+	tests := []struct {
+		name          string
+		requested     string
+		guildDefault  string
+		ageRestricted bool
+		want          string
+	}{
+		{"nothing set", "", "", true, ratingSafe},
+		{"user asks for safe", ratingSafe, ratingMixed, true, ratingSafe},
+		{"user asks for explicit", ratingExplicit, "", false, ratingExplicit},
+		{"explicit default, age-restricted", "", ratingExplicit, true, ratingExplicit},
+		{"mixed default, age-restricted", "", ratingMixed, true, ratingMixed},
+		{"explicit default, regular channel", "", ratingExplicit, false, ratingSafe},
+		{"mixed default, regular channel", "", ratingMixed, false, ratingSafe},
+		{"safe default", "", ratingSafe, true, ratingSafe},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveRating(tt.requested, tt.guildDefault, tt.ageRestricted); got != tt.want {
+				t.Errorf("resolveRating(%q, %q, %t) = %q, want %q",
+					tt.requested, tt.guildDefault, tt.ageRestricted, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequestRating(t *testing.T) {
+	st, err := storage.New(filepath.Join(t.TempDir(), "settings.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.SetDefaultRating("g", ratingMixed); err != nil {
+		t.Fatal(err)
+	}
+
+	s, _ := discordgo.New("Bot test")
+	s.State.GuildAdd(&discordgo.Guild{ID: "g", Channels: []*discordgo.Channel{
+		{ID: "adult", GuildID: "g", NSFW: true},
+		{ID: "general", GuildID: "g"},
+	}})
+	b := &Bot{storage: st}
+
+	tests := []struct {
+		name               string
+		guildID, channelID string
+		requested          string
+		want               string
+	}{
+		{"default in an age-restricted channel", "g", "adult", "", ratingMixed},
+		{"default in a regular channel", "g", "general", "", ratingSafe},
+		{"user choice wins", "g", "adult", ratingSafe, ratingSafe},
+		{"DM", "", "dm", "", ratingSafe},
+		{"guild without a default", "other", "adult", "", ratingSafe},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := b.requestRating(s, tt.guildID, tt.channelID, tt.requested); got != tt.want {
+				t.Errorf("requestRating = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRatingProviderMapping(t *testing.T) {
+	tests := []struct {
+		rating string
+		nekos  string
+		mode   string
+	}{
+		{ratingSafe, "safe", "sfw"},
+		{ratingExplicit, "explicit", "nsfw"},
+		{ratingMixed, "", "all"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rating, func(t *testing.T) {
+			if got := nekosRating(tt.rating); got != tt.nekos {
+				t.Errorf("nekosRating = %q, want %q", got, tt.nekos)
+			}
+			if got := contentModeFor(tt.rating); got != tt.mode {
+				t.Errorf("contentModeFor = %q, want %q", got, tt.mode)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// dailyRerollID is the custom ID of the reroll button on daily posts
+const dailyRerollID = "daily_reroll"
+
+// maxDailyRerolls is how often a channel's daily post may be rerolled per day
+const maxDailyRerolls = 3
+
+// dailyRerollComponents builds the reroll button shown under daily posts
+func dailyRerollComponents() []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "Reroll",
+					Emoji:    &discordgo.ComponentEmoji{Name: "🎲"},
+					Style:    discordgo.SecondaryButton,
+					CustomID: dailyRerollID,
+				},
+			},
+		},
+	}
+}
+
+// rerollLimiter counts the daily post rerolls of each channel per day
+type rerollLimiter struct {
+	mutex sync.Mutex
+	day   string         // day the counts belong to, in the scheduler's timezone
+	used  map[string]int // rerolls by channel
+}
+
+// take uses up one of a channel's rerolls for day, reporting false if none
+// are left
+func (l *rerollLimiter) take(channelID, day string) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.day != day {
+		l.day, l.used = day, make(map[string]int)
+	}
+	if l.used[channelID] >= maxDailyRerolls {
+		return false
+	}
+	l.used[channelID]++
+	return true
+}
+
+// refund gives back a reroll that didn't go through
+func (l *rerollLimiter) refund(channelID, day string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.day == day && l.used[channelID] > 0 {
+		l.used[channelID]--
+	}
+}
+
+// handleDailyRerollComponent replaces the pictures of a daily post with new
+// ones. Only server admins may reroll, a limited number of times per day.
+func (b *Bot) handleDailyRerollComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isGuildAdmin(i) {
+		b.respondError(s, i, "Only server admins can reroll the daily pictures.")
+		return
+	}
+
+	day := b.scheduler.Now().Format(time.DateOnly)
+	if !b.rerolls.take(i.ChannelID, day) {
+		b.respondError(s, i, fmt.Sprintf("Today's %d rerolls are used up, try again tomorrow.", maxDailyRerolls))
+		return
+	}
+
+	// Fetching new pictures takes a moment
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+	if err != nil {
+		slog.Warn("Failed to defer interaction", "error", err)
+		b.rerolls.refund(i.ChannelID, day)
+		return
+	}
+
+	// Never reroll into a picture the post already shows
+	var shown []string
+	for _, embed := range i.Message.Embeds {
+		if embed.Image != nil {
+			shown = append(shown, embed.Image.URL)
+		}
+		if embed.Thumbnail != nil {
+			shown = append(shown, embed.Thumbnail.URL)
+		}
+	}
+
+	payload, err := b.dailyWebhook.Reroll(shown)
+	if err != nil {
+		b.rerolls.refund(i.ChannelID, day)
+		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: fmt.Sprintf("Sorry, I couldn't reroll the daily pictures: %v", err),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		})
+		return
+	}
+
+	embeds := dailyEmbeds(payload, b.thumbnailEmbeds(i.GuildID))
+	components := dailyRerollComponents()
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content:    &payload.Content,
+		Embeds:     &embeds,
+		Components: &components,
+	}); err != nil {
+		slog.Warn("Failed to edit rerolled daily post", "message", i.Message.ID, "error", err)
+		b.rerolls.refund(i.ChannelID, day)
+		return
+	}
+	slog.Info("Daily post rerolled", "message", i.Message.ID, "channel", i.ChannelID, "user", interactionUserID(i))
+
+	// /today shows what the global daily channel shows now
+	if i.ChannelID == b.dailyWebhook.GetChannelID() {
+		b.recordDailyPost(payload, time.Now())
+	}
+}
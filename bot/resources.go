@@ -0,0 +1,105 @@
+package bot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// trackedFileUsage returns how many picture files are tracked for cleanup
+// and their total size on disk. Files already gone count without a size.
+func (b *Bot) trackedFileUsage() (count int, size int64) {
+	b.fileMutex.Lock()
+	names := make([]string, 0, len(b.activeFiles))
+	for name := range b.activeFiles {
+		names = append(names, name)
+	}
+	b.fileMutex.Unlock()
+
+	for _, name := range names {
+		if info, err := os.Stat(filepath.Join(picturesDir, name)); err == nil {
+			size += info.Size()
+		}
+	}
+	return len(names), size
+}
+
+// megabytes renders a byte count in MB
+func megabytes[T int | int64 | uint64](n T) string {
+	return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+}
+
+// resourcesEmbed renders the bot's current resource usage
+func (b *Bot) resourcesEmbed() *discordgo.MessageEmbed {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	files, fileSize := b.trackedFileUsage()
+	held, _, _, _ := b.memory.snapshot()
+
+	b.tagCache.mutex.Lock()
+	tags := len(b.tagCache.tags)
+	b.tagCache.mutex.Unlock()
+
+	return &discordgo.MessageEmbed{
+		Title: "📊 Resource usage",
+		Color: 0x3498DB, // Blue color
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:   "Heap in use",
+				Value:  megabytes(mem.HeapInuse),
+				Inline: true,
+			},
+			{
+				Name:   "From the OS",
+				Value:  megabytes(mem.Sys),
+				Inline: true,
+			},
+			{
+				Name:   "GC cycles",
+				Value:  fmt.Sprintf("%d", mem.NumGC),
+				Inline: true,
+			},
+			{
+				Name:   "Goroutines",
+				Value:  fmt.Sprintf("%d", runtime.NumGoroutine()),
+				Inline: true,
+			},
+			{
+				Name:   "Tracked files",
+				Value:  fmt.Sprintf("%d (%s)", files, megabytes(fileSize)),
+				Inline: true,
+			},
+			{
+				Name:   "Image buffers",
+				Value:  megabytes(held),
+				Inline: true,
+			},
+			{
+				Name:  "Caches",
+				Value: fmt.Sprintf("Posted messages: %d\nWaifu tags: %d", b.posted.len(), tags),
+			},
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+}
+
+// handleResourcesSlashCommand handles the /resources slash command
+func (b *Bot) handleResourcesSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !b.isOwner(i) {
+		b.respondError(s, i, "Only the bot owner can view the resource usage.")
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{b.resourcesEmbed()},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
@@ -0,0 +1,126 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// roleGateNSFW is the role gate target covering every NSFW request
+const roleGateNSFW = "nsfw"
+
+// roleGateTargets lists what /role-gate can restrict: NSFW requests and the
+// picture commands
+var roleGateTargets = []string{roleGateNSFW, "catgirl", "waifu", "top", "by-artist", "collage", "random", "surprise"}
+
+// missingRole returns the role a member needs for target but lacks, or "" if
+// they may use it. DMs are never gated.
+func (b *Bot) missingRole(guildID string, member *discordgo.Member, target string) string {
+	if guildID == "" {
+		return ""
+	}
+
+	roleID := b.storage.GetGuildSettings(guildID).RoleGates[target]
+	if roleID == "" || (member != nil && slices.Contains(member.Roles, roleID)) {
+		return ""
+	}
+	return roleID
+}
+
+// roleGateText tells a user which role they are missing, by name if the role
+// is cached
+func roleGateText(s *discordgo.Session, guildID, roleID string) string {
+	name := fmt.Sprintf("<@&%s>", roleID)
+	if role, err := s.State.Role(guildID, roleID); err == nil {
+		name = "**" + role.Name + "**"
+	}
+	return fmt.Sprintf("🔒 You need the %s role to use this.", name)
+}
+
+// respondRoleGateMessage tells a message command user which role they lack
+func (b *Bot) respondRoleGateMessage(s *discordgo.Session, m *discordgo.MessageCreate, roleID string) {
+	s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+		Content: fmt.Sprintf("<@%s> %s", m.Author.ID, roleGateText(s, m.GuildID, roleID)),
+		AllowedMentions: &discordgo.MessageAllowedMentions{
+			Users: []string{m.Author.ID},
+		},
+	})
+}
+
+// respondRoleGateInteraction tells a slash command user which role they lack
+func (b *Bot) respondRoleGateInteraction(s *discordgo.Session, i *discordgo.InteractionCreate, roleID string) {
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content:         roleGateText(s, i.GuildID, roleID),
+			Flags:           discordgo.MessageFlagsEphemeral,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+}
+
+// roleGateLines renders a guild's role gates for /config
+func roleGateLines(gates map[string]string) string {
+	if len(gates) == 0 {
+		return "none"
+	}
+
+	lines := make([]string, 0, len(gates))
+	for _, target := range roleGateTargets {
+		if roleID := gates[target]; roleID != "" {
+			lines = append(lines, fmt.Sprintf("`%s`: <@&%s>", target, roleID))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// handleRoleGateSlashCommand handles the /role-gate slash command. It sets
+// or, without a role, removes the role required for a command.
+func (b *Bot) handleRoleGateSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) {
+	if i.GuildID == "" || !isGuildAdmin(i) {
+		b.respondError(s, i, "Only server admins can change the role gates.")
+		return
+	}
+
+	target, roleID := "", ""
+	for _, option := range data.Options {
+		switch option.Name {
+		case "command":
+			target = option.StringValue()
+		case "role":
+			roleID = option.RoleValue(nil, "").ID
+		}
+	}
+
+	if !slices.Contains(roleGateTargets, target) {
+		b.respondError(s, i, fmt.Sprintf("Unknown command %q.", target))
+		return
+	}
+
+	if err := b.storage.SetRoleGate(i.GuildID, target, roleID); err != nil {
+		b.respondError(s, i, fmt.Sprintf("Failed to save the role gate: %v", err))
+		return
+	}
+	slog.Info("Role gate set", "target", target, "guild", i.GuildID, "role", roleID, "user", interactionUserID(i))
+
+	what := fmt.Sprintf("`/%s`", target)
+	if target == roleGateNSFW {
+		what = "NSFW requests"
+	}
+	content := fmt.Sprintf("🔓 No role is required for %s anymore.", what)
+	if roleID != "" {
+		content = fmt.Sprintf("🔒 The <@&%s> role is now required for %s.", roleID, what)
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content:         content,
+			Flags:           discordgo.MessageFlagsEphemeral,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+}
@@ -0,0 +1,138 @@
+package bot
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+
+	"KawaiiBot/webhook"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// handleConfigSlashCommand handles the /config slash command. It shows the
+// effective configuration for the current guild with secrets masked.
+func (b *Bot) handleConfigSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isGuildAdmin(i) {
+		b.respondError(s, i, "Only server admins can view the configuration.")
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{b.configEmbed(i.GuildID)},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// configEmbed renders the effective configuration for a guild
+func (b *Bot) configEmbed(guildID string) *discordgo.MessageEmbed {
+	guild := b.storage.GetGuildSettings(guildID)
+	sfwLimit, nsfwLimit := b.countLimits(guildID)
+
+	enabled, _ := b.dailyWebhook.GetStatus()
+	enabledLine := fmt.Sprintf("Enabled: **%t**", enabled)
+	if b.webhookStranded() {
+		enabledLine += " (saved as enabled, waiting for a destination)"
+	}
+	webhookLines := []string{
+		enabledLine,
+		webhookURLsLine(b.dailyWebhook.WebhookURLs()),
+	}
+	if channelID := b.dailyWebhook.GetChannelID(); channelID != "" {
+		webhookLines = append(webhookLines, fmt.Sprintf("Channel: <#%s>", channelID))
+	}
+	if schedule := guild.DailySchedule; schedule != nil {
+		line := fmt.Sprintf("Server schedule: <#%s> at %02d:%02d", schedule.ChannelID, schedule.Hour, schedule.Minute)
+		if schedule.Timezone != "" {
+			line += fmt.Sprintf(" (%s)", schedule.Timezone)
+		}
+		webhookLines = append(webhookLines, line)
+	}
+
+	uploadLine := fmt.Sprintf("Upload limit: **%d MB**", b.uploadLimitForGuild(guildID)>>20)
+	if b.compressImages {
+		uploadLine += " (oversized images are compressed)"
+	}
+	uploadLine += fmt.Sprintf("\nEmbed size: **%s**", cmp.Or(guild.EmbedSize, embedSizeFull))
+
+	return &discordgo.MessageEmbed{
+		Title: "⚙️ Effective configuration",
+		Color: 0x3498DB, // Blue color
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:   "Prefix",
+				Value:  fmt.Sprintf("`%s`", b.prefix),
+				Inline: true,
+			},
+			{
+				Name:   "Default count",
+				Value:  "1",
+				Inline: true,
+			},
+			{
+				Name:   "Max count",
+				Value:  fmt.Sprintf("%d SFW / %d NSFW", sfwLimit, nsfwLimit),
+				Inline: true,
+			},
+			{
+				Name:   "Providers",
+				Value:  "Nekos.moe, Waifu.im",
+				Inline: true,
+			},
+			{
+				Name:  "Daily webhook",
+				Value: strings.Join(webhookLines, "\n"),
+			},
+			{
+				Name:  "Schedule",
+				Value: b.scheduler.Schedule(),
+			},
+			{
+				Name:  "NSFW policy",
+				Value: fmt.Sprintf("Default: **%s** (in age-restricted channels)\nConfirmation gate: **%s**\nStrict options: **%s**", cmp.Or(guild.DefaultRating, ratingSafe), onOff(guild.NSFWGate), onOff(guild.StrictOptions)),
+			},
+			{
+				Name:  "Role gates",
+				Value: roleGateLines(guild.RoleGates),
+			},
+			{
+				Name:  "Images",
+				Value: uploadLine,
+			},
+		},
+	}
+}
+
+// onOff renders a boolean setting
+func onOff(enabled bool) string {
+	if enabled {
+		return "on"
+	}
+	return "off"
+}
+
+// orNone renders an empty value as "none"
+func orNone(value string) string {
+	if value == "" {
+		return "none"
+	}
+	return value
+}
+
+// webhookURLsLine renders the masked webhook URLs of the daily post
+func webhookURLsLine(urls []string) string {
+	switch len(urls) {
+	case 0:
+		return "URL: `none`"
+	case 1:
+		return fmt.Sprintf("URL: `%s`", webhook.MaskURL(urls[0]))
+	}
+	masked := make([]string, len(urls))
+	for n, url := range urls {
+		masked[n] = "`" + webhook.MaskURL(url) + "`"
+	}
+	return "URLs: " + strings.Join(masked, ", ")
+}
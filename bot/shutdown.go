@@ -0,0 +1,75 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// shutdownStep is one subsystem wound down by Stop
+type shutdownStep struct {
+	name string
+	stop func(ctx context.Context) error
+	// critical steps get their full budget even after the shutdown deadline,
+	// so an earlier slow step can't leave events unflushed or the session open
+	critical bool
+}
+
+// shutdownSteps lists the subsystems in the order they are stopped: inbound
+// work first, the Discord session last so in-flight replies can still go out
+func (b *Bot) shutdownSteps() []shutdownStep {
+	return []shutdownStep{
+		{name: "HTTP server", stop: func(ctx context.Context) error {
+			if b.httpServer == nil {
+				return nil
+			}
+			return b.httpServer.Shutdown(ctx)
+		}},
+		{name: "scheduler", stop: func(context.Context) error {
+			return b.scheduler.Stop()
+		}},
+		{name: "image requests", stop: func(context.Context) error {
+			b.cancelRequests()
+			return nil
+		}},
+		{name: "commands", stop: func(context.Context) error {
+			return b.unregisterCommands()
+		}},
+		{name: "files", stop: func(context.Context) error {
+			b.cleanupAllFiles()
+			return nil
+		}},
+		{name: "served events", critical: true, stop: func(context.Context) error {
+			b.events.close()
+			return nil
+		}},
+		{name: "stats", critical: true, stop: func(context.Context) error {
+			return b.storage.Flush()
+		}},
+		{name: "session", critical: true, stop: func(context.Context) error {
+			return b.session.Close()
+		}},
+	}
+}
+
+// runShutdownStep stops one subsystem within budget, cut short if parent
+// ends first unless the step is critical. A step that overruns is left behind
+// so the next one can start.
+func runShutdownStep(parent context.Context, step shutdownStep, budget time.Duration) error {
+	if step.critical {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(parent, budget)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- step.stop(ctx) }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("exceeded its shutdown budget after %s", time.Since(start).Round(time.Millisecond))
+	}
+}
@@ -0,0 +1,28 @@
+package bot
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRunShutdownStepAfterDeadline(t *testing.T) {
+	parent, cancel := context.WithCancel(context.Background())
+	cancel() // an earlier step used up the whole shutdown deadline
+
+	slow := func(ctx context.Context) error {
+		select {
+		case <-time.After(10 * time.Millisecond):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if err := runShutdownStep(parent, shutdownStep{name: "files", stop: slow}, time.Second); err == nil {
+		t.Error("a regular step ran past the shutdown deadline")
+	}
+	if err := runShutdownStep(parent, shutdownStep{name: "session", stop: slow, critical: true}, time.Second); err != nil {
+		t.Errorf("critical step was cut short: %v", err)
+	}
+}
@@ -0,0 +1,112 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Custom ID prefixes of the /stats-reset buttons. The ID of the admin who ran
+// the command follows the prefix.
+const (
+	statsResetConfirmPrefix = "stats_reset_confirm:"
+	statsResetCancelPrefix  = "stats_reset_cancel:"
+)
+
+// statsFlushInterval is how often served request counters are saved
+const statsFlushInterval = time.Minute
+
+// recordServed counts a served image request towards the statistics
+func (b *Bot) recordServed(guildID string, images int) {
+	b.storage.RecordServed(guildID, images)
+}
+
+// statsFlushRoutine saves the served request counters every
+// statsFlushInterval until ctx ends. Shutdown saves the rest.
+func (b *Bot) statsFlushRoutine(ctx context.Context) {
+	ticker := time.NewTicker(statsFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := b.storage.Flush(); err != nil {
+				slog.Warn("Failed to save stats", "error", err)
+			}
+		}
+	}
+}
+
+// handleStatsResetSlashCommand handles the /stats-reset slash command. The
+// reset only happens once the admin confirms with the button.
+func (b *Bot) handleStatsResetSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" || !isGuildAdmin(i) {
+		b.respondError(s, i, "Only server admins can reset the statistics.")
+		return
+	}
+
+	stats := b.storage.GetGuildSettings(i.GuildID).Stats
+	userID := interactionUserID(i)
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("⚠️ Reset this server's statistics? **%d** request(s) and **%d** image(s) will be cleared. Lifetime totals are kept.",
+				stats.Requests, stats.Images),
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.Button{
+							Label:    "Reset statistics",
+							Style:    discordgo.DangerButton,
+							CustomID: statsResetConfirmPrefix + userID,
+						},
+						discordgo.Button{
+							Label:    "Cancel",
+							Style:    discordgo.SecondaryButton,
+							CustomID: statsResetCancelPrefix + userID,
+						},
+					},
+				},
+			},
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// handleStatsResetComponent handles the /stats-reset confirmation buttons
+func (b *Bot) handleStatsResetComponent(s *discordgo.Session, i *discordgo.InteractionCreate, customID string) {
+	userID := interactionUserID(i)
+	confirmed := strings.HasPrefix(customID, statsResetConfirmPrefix)
+	owner := strings.TrimPrefix(strings.TrimPrefix(customID, statsResetConfirmPrefix), statsResetCancelPrefix)
+	if owner != userID || !isGuildAdmin(i) {
+		b.respondError(s, i, "This confirmation belongs to someone else.")
+		return
+	}
+
+	content := "Statistics were not reset."
+	if confirmed {
+		previous, err := b.storage.ResetGuildStats(i.GuildID)
+		if err != nil {
+			b.respondError(s, i, fmt.Sprintf("Failed to reset statistics: %v", err))
+			return
+		}
+		slog.Info("Stats reset", "guild", i.GuildID, "user", userID,
+			"requests", previous.Requests, "images", previous.Images)
+		content = "🧹 This server's statistics have been reset."
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    content,
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+}
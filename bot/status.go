@@ -0,0 +1,151 @@
+package bot
+
+import (
+	"fmt"
+	"time"
+
+	"KawaiiBot/api"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Provider health colors for the status embed
+const (
+	statusColorHealthy  = 0x2ECC71 // Green color
+	statusColorDegraded = 0xF1C40F // Yellow color
+	statusColorFailing  = 0xE74C3C // Red color
+)
+
+// handleProviderStatusSlashCommand handles the /provider-status slash command
+func (b *Bot) handleProviderStatusSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !isGuildAdmin(i) {
+		b.respondError(s, i, "Only server admins can view provider status.")
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{
+				providerStatusEmbed("🐱 Nekos.moe", b.nekosAPI.Stats()),
+				providerStatusEmbed("💜 Waifu.im", b.waifuAPI.Stats()),
+				b.rateLimitEmbed(),
+				b.imageMemoryEmbed(),
+			},
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// providerStatusEmbed renders the recent request statistics of a provider
+func providerStatusEmbed(name string, stats api.StatsSnapshot) *discordgo.MessageEmbed {
+	rate := stats.SuccessRate()
+
+	color := statusColorHealthy
+	switch {
+	case rate < 0.5:
+		color = statusColorFailing
+	case rate < 0.9:
+		color = statusColorDegraded
+	}
+
+	lastError := "None"
+	if stats.LastError != "" {
+		lastError = fmt.Sprintf("%s\n<t:%d:R>", stats.LastError, stats.LastErrorAt.Unix())
+	}
+
+	return &discordgo.MessageEmbed{
+		Title: name,
+		Color: color,
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:   "Success rate",
+				Value:  fmt.Sprintf("%.0f%% (%d/%d recent requests)", rate*100, stats.Successes, stats.Requests),
+				Inline: true,
+			},
+			{
+				Name:  "Last error",
+				Value: truncate(lastError, 1024),
+			},
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+}
+
+// rateLimitEmbed renders how often Discord rate limited the bot since startup
+func (b *Bot) rateLimitEmbed() *discordgo.MessageEmbed {
+	hits, lastURL, lastHit := b.rateLimits.snapshot()
+
+	color := statusColorHealthy
+	lastLimit := "None"
+	if hits > 0 {
+		color = statusColorDegraded
+		lastLimit = fmt.Sprintf("%s\n<t:%d:R>", lastURL, lastHit.Unix())
+	}
+
+	return &discordgo.MessageEmbed{
+		Title: "⏳ Discord rate limits",
+		Color: color,
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:   "Hits since startup",
+				Value:  fmt.Sprintf("%d", hits),
+				Inline: true,
+			},
+			{
+				Name:  "Last hit",
+				Value: truncate(lastLimit, 1024),
+			},
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+}
+
+// imageMemoryEmbed renders how much image data the bot holds for uploads
+func (b *Bot) imageMemoryEmbed() *discordgo.MessageEmbed {
+	held, peak, limit, linked := b.memory.snapshot()
+
+	budget := "no cap"
+	color := statusColorHealthy
+	if limit > 0 {
+		budget = fmt.Sprintf("%d MB", limit>>20)
+		if peak > limit*9/10 {
+			color = statusColorDegraded
+		}
+	}
+	if linked > 0 {
+		color = statusColorDegraded
+	}
+
+	return &discordgo.MessageEmbed{
+		Title: "🧠 Image memory",
+		Color: color,
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:   "Held now",
+				Value:  fmt.Sprintf("%.1f MB of %s", float64(held)/(1<<20), budget),
+				Inline: true,
+			},
+			{
+				Name:   "Peak",
+				Value:  fmt.Sprintf("%.1f MB", float64(peak)/(1<<20)),
+				Inline: true,
+			},
+			{
+				Name:   "Linked instead of uploaded",
+				Value:  fmt.Sprintf("%d since startup", linked),
+				Inline: true,
+			},
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+}
+
+// truncate shortens s to at most limit runes
+func truncate(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit-1]) + "…"
+}
@@ -0,0 +1,146 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	"KawaiiBot/api"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// /surprise tuning
+const (
+	tagCacheTTL    = 6 * time.Hour
+	surpriseRolls  = 3 // tags tried before giving up
+	surpriseNoTags = "Sorry, I couldn't load the waifu tag list right now."
+)
+
+// tagCache holds the waifu.im tag list between /surprise calls
+type tagCache struct {
+	mutex   sync.Mutex
+	tags    []api.Tag
+	fetched time.Time
+}
+
+// waifuTags returns the cached tag list, refreshing it once it is older than
+// tagCacheTTL. A stale list is kept if the refresh fails.
+func (b *Bot) waifuTags() ([]api.Tag, error) {
+	b.tagCache.mutex.Lock()
+	defer b.tagCache.mutex.Unlock()
+
+	if b.tagCache.tags != nil && time.Since(b.tagCache.fetched) < tagCacheTTL {
+		return b.tagCache.tags, nil
+	}
+
+	tags, err := b.waifuAPI.GetTagsContext(b.requests)
+	if err != nil {
+		if b.tagCache.tags != nil {
+			slog.Warn("Failed to refresh waifu tags, using cached list", "error", err)
+			return b.tagCache.tags, nil
+		}
+		return nil, err
+	}
+
+	b.tagCache.tags = tags
+	b.tagCache.fetched = time.Now()
+	return tags, nil
+}
+
+// channelAllowsNSFW reports whether NSFW pictures may be rolled in the
+// interaction's channel: an age-restricted guild channel, or a DM when
+// ALLOW_NSFW_DM is set
+func (b *Bot) channelAllowsNSFW(s *discordgo.Session, i *discordgo.InteractionCreate) bool {
+	if i.GuildID == "" {
+		return b.allowNSFWDM
+	}
+
+	ch, err := lookupChannel(s, i.ChannelID)
+	if err != nil {
+		slog.Warn("Failed to look up channel", "channel", i.ChannelID, "error", err)
+		return false
+	}
+	return ch.NSFW
+}
+
+// lookupChannel returns a channel from the state cache, asking Discord if it
+// isn't cached
+func lookupChannel(s *discordgo.Session, channelID string) (*discordgo.Channel, error) {
+	if ch, err := s.State.Channel(channelID); err == nil {
+		return ch, nil
+	}
+	return s.Channel(channelID)
+}
+
+// handleSurpriseSlashCommand handles the /surprise slash command. It rolls a
+// random waifu.im tag and posts a picture for it, rerolling tags that have
+// no pictures.
+func (b *Bot) handleSurpriseSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	// Keep one guild from filling the disk
+	if b.guildAtFileCap(i.GuildID) {
+		b.respondError(s, i, tooManyFilesText)
+		return
+	}
+
+	// Defer response, the tag list may need fetching first
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		slog.Warn("Failed to defer interaction", "error", err)
+		return
+	}
+
+	// Show typing indicator
+	s.ChannelTyping(i.ChannelID)
+
+	tags, err := b.waifuTags()
+	if err != nil {
+		slog.Warn("Failed to fetch waifu tags", "error", err)
+		b.editError(s, i, surpriseNoTags)
+		return
+	}
+
+	// NSFW tags are only rolled where NSFW is allowed and the user has
+	// passed the guild's NSFW gate and role gate
+	allowNSFW := b.channelAllowsNSFW(s, i) && !b.nsfwGated(i.GuildID, interactionUserID(i)) &&
+		b.missingRole(i.GuildID, i.Member, roleGateNSFW) == ""
+	pool := slices.DeleteFunc(slices.Clone(tags), func(tag api.Tag) bool {
+		return tag.Slug == "" || (tag.IsNSFW && !allowNSFW)
+	})
+	if len(pool) == 0 {
+		b.editError(s, i, surpriseNoTags)
+		return
+	}
+
+	for range min(surpriseRolls, len(pool)) {
+		n := rand.IntN(len(pool))
+		tag := pool[n]
+		pool = slices.Delete(pool, n, n+1)
+
+		mode := api.NSFWModeSFW
+		if tag.IsNSFW {
+			mode = api.NSFWModeNSFW
+		}
+
+		images, err := b.fetchWaifuImages(api.WaifuOptions{Mode: mode, Tag: tag.Slug}, 1)
+		if err != nil {
+			b.editError(s, i, b.fetchFailedText(i.GuildID, "waifu", err))
+			return
+		}
+		if len(images) == 0 {
+			slog.Warn("/surprise rolled a tag without pictures, rerolling", "tag", tag.Slug)
+			continue
+		}
+
+		b.recordServed(i.GuildID, len(images))
+		b.sendWaifuImagesInteraction(s, i, images, fmt.Sprintf("🎲 You rolled **%s**!", tag.Name))
+		return
+	}
+
+	b.editError(s, i, "Sorry, none of the tags I rolled had pictures. Try again!")
+}
@@ -0,0 +1,53 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"KawaiiBot/storage"
+	"KawaiiBot/webhook"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// recordDailyPost persists a delivered daily payload so /today can show it
+func (b *Bot) recordDailyPost(payload webhook.WebhookPayload, sentAt time.Time) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		slog.Warn("Failed to encode daily post", "error", err)
+		return
+	}
+	if err := b.storage.SetLastDailyPost(storage.DailyPost{SentAt: sentAt, Payload: data}); err != nil {
+		slog.Warn("Failed to save daily post", "error", err)
+	}
+}
+
+// handleTodaySlashCommand handles the /today slash command. It reposts the
+// pictures of today's daily post instead of fetching new ones.
+func (b *Bot) handleTodaySlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	now := b.scheduler.Now()
+	post := b.storage.GetLastDailyPost()
+
+	// Compare calendar days in the scheduler's timezone
+	if post == nil || post.SentAt.In(now.Location()).Format(time.DateOnly) != now.Format(time.DateOnly) {
+		b.respondError(s, i, fmt.Sprintf("The daily post hasn't gone out yet today. 📅 Schedule: %s", b.scheduler.Schedule()))
+		return
+	}
+
+	var payload webhook.WebhookPayload
+	if err := json.Unmarshal(post.Payload, &payload); err != nil {
+		slog.Warn("Failed to decode the saved daily post", "error", err)
+		b.respondError(s, i, "Sorry, I couldn't load today's daily post.")
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("📬 Today's daily post, sent at %s:\n%s", post.SentAt.In(now.Location()).Format("15:04"), payload.Content),
+			Embeds:  dailyEmbeds(payload, b.thumbnailEmbeds(i.GuildID)),
+		},
+	})
+}
@@ -0,0 +1,128 @@
+package bot
+
+import (
+	"cmp"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"KawaiiBot/api"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// topSearchPool is how many search results are ranked for /top
+const topSearchPool = 50
+
+// topImages returns the n images with the most likes, breaking ties by
+// favorites. The input is left untouched.
+func topImages(images []api.Image, n int) []api.Image {
+	ranked := slices.Clone(images)
+	slices.SortStableFunc(ranked, func(a, b api.Image) int {
+		if c := cmp.Compare(b.Likes, a.Likes); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.Favorites, a.Favorites)
+	})
+	return ranked[:min(n, len(ranked))]
+}
+
+// handleTopSlashCommand handles the /top slash command. It posts the most
+// liked nekos.moe images for a tag.
+func (b *Bot) handleTopSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) {
+	// Get options - defaults: count=3, SFW
+	tag := ""
+	count := 3
+	nsfw := false
+
+	parser := b.optionParser(i.GuildID)
+	for _, option := range data.Options {
+		switch option.Name {
+		case "tag":
+			tag = strings.ToLower(strings.TrimSpace(option.StringValue()))
+		case "count":
+			count = int(option.IntValue())
+		case "nsfw":
+			nsfw = parser.yesNo(option)
+		}
+	}
+	if parser.problem != "" {
+		b.respondError(s, i, parser.problem)
+		return
+	}
+
+	if err := validateArgs([]string{tag}); err != nil || tag == "" {
+		b.respondError(s, i, "Please give a single tag made of letters, digits, '-' or '_'.")
+		return
+	}
+	if count < 1 {
+		count = 1
+	}
+
+	// DMs have no age restriction, so NSFW there is opt-in
+	if nsfw && b.nsfwBlockedInDM(i.GuildID) {
+		b.respondError(s, i, nsfwDMText)
+		return
+	}
+
+	// Servers may require a role for NSFW requests
+	if nsfw {
+		if roleID := b.missingRole(i.GuildID, i.Member, roleGateNSFW); roleID != "" {
+			b.respondRoleGateInteraction(s, i, roleID)
+			return
+		}
+	}
+
+	// Ask for confirmation first if the guild gates NSFW content
+	if nsfw && b.nsfwGated(i.GuildID, interactionUserID(i)) {
+		b.respondNSFWGateInteraction(s, i)
+		return
+	}
+
+	// Clamp to the guild's limit for the resolved rating
+	count, limitNote := b.applyCountLimit(i.GuildID, count, nsfw)
+
+	// Keep one guild from filling the disk
+	if b.guildAtFileCap(i.GuildID) {
+		b.respondError(s, i, tooManyFilesText)
+		return
+	}
+
+	// Defer response to avoid timeout
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		slog.Warn("Failed to defer interaction", "error", err)
+		return
+	}
+
+	// Show typing indicator
+	s.ChannelTyping(i.ChannelID)
+
+	rating := "safe"
+	if nsfw {
+		rating = "explicit"
+	}
+
+	results, err := b.nekosAPI.SearchImagesContext(b.requests, []string{tag}, topSearchPool, rating)
+	if err != nil {
+		b.editError(s, i, fmt.Sprintf("Sorry, I couldn't search for %q: %v", tag, err))
+		return
+	}
+
+	// Never let NSFW results through a SFW search
+	results = slices.DeleteFunc(results, func(img api.Image) bool {
+		return img.NSFW != nsfw
+	})
+
+	if len(results) == 0 {
+		b.editError(s, i, fmt.Sprintf("No images found for the tag %q.", tag))
+		return
+	}
+
+	images := topImages(results, count)
+	b.recordServed(i.GuildID, len(images))
+	b.sendImagesInteraction(s, i, images, joinNotes(fmt.Sprintf("🏆 Top %d for **%s** by likes", len(images), tag), limitNote))
+}
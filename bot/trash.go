@@ -0,0 +1,88 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// trashEmoji is the reaction that deletes a picture message. Discord sends
+// it with or without the emoji variation selector.
+const (
+	trashEmoji     = "🗑️"
+	trashEmojiBare = "🗑"
+)
+
+// postedMessageTTL is how long a picture message can be deleted by reaction
+const postedMessageTTL = 24 * time.Hour
+
+// reactionAddHandler deletes a picture message when its invoker, or someone
+// allowed to manage messages, reacts with the trash emoji. In guilds this is
+// opt-in through /trash-reactions.
+func (b *Bot) reactionAddHandler(s *discordgo.Session, r *discordgo.MessageReactionAdd) {
+	if r.Emoji.Name != trashEmoji && r.Emoji.Name != trashEmojiBare {
+		return
+	}
+	if r.UserID == s.State.User.ID {
+		return
+	}
+	if r.GuildID != "" && !b.storage.GetGuildSettings(r.GuildID).TrashReactions {
+		return
+	}
+
+	posted, ok := b.posted.get(r.MessageID)
+	if !ok {
+		return
+	}
+	if r.UserID != posted.userID && !canManageMessages(s, r.UserID, r.ChannelID) {
+		return
+	}
+
+	if err := s.ChannelMessageDelete(r.ChannelID, r.MessageID); err != nil {
+		slog.Warn("Failed to delete message on trash reaction", "message", r.MessageID, "error", err)
+		return
+	}
+
+	b.posted.remove(r.MessageID)
+}
+
+// canManageMessages returns whether a user may manage messages in a channel
+func canManageMessages(s *discordgo.Session, userID, channelID string) bool {
+	perms, err := s.UserChannelPermissions(userID, channelID)
+	return err == nil && perms&discordgo.PermissionManageMessages != 0
+}
+
+// handleTrashReactionsSlashCommand handles the /trash-reactions slash command
+func (b *Bot) handleTrashReactionsSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) {
+	if i.GuildID == "" || !isGuildAdmin(i) {
+		b.respondError(s, i, "Only server admins can change trash reactions.")
+		return
+	}
+
+	enabled := false
+	for _, option := range data.Options {
+		if option.Name == "state" {
+			enabled = option.StringValue() == "on"
+		}
+	}
+
+	if err := b.storage.SetTrashReactions(i.GuildID, enabled); err != nil {
+		b.respondError(s, i, fmt.Sprintf("Failed to update trash reactions: %v", err))
+		return
+	}
+
+	content := fmt.Sprintf("🗑️ Trash reactions are now **on**. React with %s on a picture to delete it; only the requester and members who can manage messages can.", trashEmoji)
+	if !enabled {
+		content = "🗑️ Trash reactions are now **off**."
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
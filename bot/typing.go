@@ -0,0 +1,39 @@
+package bot
+
+import (
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Discord shows a typing indicator for about 10 seconds
+const (
+	typingInterval = 8 * time.Second
+	typingTimeout  = 2 * time.Minute // longest a single command keeps typing
+)
+
+// keepTyping shows the typing indicator in a channel and renews it until the
+// returned stop function is called or typingTimeout passes. Message commands
+// have no deferred response that would show the bot is still working.
+func keepTyping(s *discordgo.Session, channelID string) (stop func()) {
+	s.ChannelTyping(channelID)
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(typingInterval)
+		defer ticker.Stop()
+		timeout := time.After(typingTimeout)
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-timeout:
+				return
+			case <-ticker.C:
+				s.ChannelTyping(channelID)
+			}
+		}
+	}()
+	return func() { close(done) }
+}
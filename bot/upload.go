@@ -0,0 +1,71 @@
+package bot
+
+import (
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Discord upload limits by server boost tier
+const (
+	uploadLimitDefault = 8 << 20
+	uploadLimitTier1   = 25 << 20
+	uploadLimitTier2   = 50 << 20
+)
+
+// noDownloadsText is shown when none of the requested images could be
+// downloaded or linked
+const noDownloadsText = "Sorry, I couldn't fetch any images. Try again later."
+
+// uploadLimitForGuild returns the effective upload limit in bytes for a guild.
+// DMs and guilds missing from the state cache get the default limit. The
+// result is capped by MAX_FILE_SIZE_MB when configured.
+func (b *Bot) uploadLimitForGuild(guildID string) int {
+	limit := uploadLimitDefault
+
+	if guildID != "" {
+		if guild, err := b.session.State.Guild(guildID); err == nil {
+			switch guild.PremiumTier {
+			case discordgo.PremiumTier1:
+				limit = uploadLimitTier1
+			case discordgo.PremiumTier2, discordgo.PremiumTier3:
+				limit = uploadLimitTier2
+			}
+		}
+	}
+
+	if b.maxFileSize > 0 && b.maxFileSize < limit {
+		limit = b.maxFileSize
+	}
+	return limit
+}
+
+// oversizedNote tells the user how many images were skipped for being too large
+func oversizedNote(skipped, limit int) string {
+	if skipped == 0 {
+		return ""
+	}
+	return fmt.Sprintf("⚠️ Skipped %d image(s) larger than the %d MB upload limit.", skipped, limit>>20)
+}
+
+// sendNoFilesMessage answers a message command none of whose images were
+// attached. The notes explain skipped or linked images; without any, nothing
+// could be fetched.
+func (b *Bot) sendNoFilesMessage(s *discordgo.Session, m *discordgo.MessageCreate, message, notes string) {
+	if notes != "" {
+		s.ChannelMessageSend(m.ChannelID, joinNotes(message, notes))
+		return
+	}
+	b.sendError(s, m, noDownloadsText)
+}
+
+// sendNoFilesInteraction is sendNoFilesMessage for deferred interactions
+func (b *Bot) sendNoFilesInteraction(s *discordgo.Session, i *discordgo.InteractionCreate, message, notes string) {
+	if notes != "" {
+		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: joinNotes(message, notes),
+		})
+		return
+	}
+	b.editError(s, i, noDownloadsText)
+}
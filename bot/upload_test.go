@@ -0,0 +1,90 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"KawaiiBot/api"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// discordPost is a message the bot sent to Discord
+type discordPost struct {
+	Method  string
+	Path    string
+	Content string                    `json:"content"`
+	Embeds  []*discordgo.MessageEmbed `json:"embeds"`
+	Data    struct {
+		Content string `json:"content"`
+	} `json:"data"` // interaction responses
+}
+
+// stubDiscord answers every Discord API call with an empty message and fails
+// every image download, returning the posts made to Discord
+func stubDiscord(t *testing.T) func() []discordPost {
+	t.Helper()
+	var mutex sync.Mutex
+	var posts []discordPost
+	stubAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/api/v"+discordgo.APIVersion+"/") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		post := discordPost{Method: r.Method, Path: r.URL.Path}
+		json.NewDecoder(r.Body).Decode(&post)
+		mutex.Lock()
+		posts = append(posts, post)
+		mutex.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": "1", "channel_id": "c"}`))
+	})
+	return func() []discordPost {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return posts
+	}
+}
+
+func TestSendersReportTotalDownloadFailure(t *testing.T) {
+	catgirls := []api.Image{{ID: "a"}, {ID: "b"}}
+	waifus := []api.WaifuImage{{ID: 1, URL: "https://cdn.waifu.im/1.jpg"}, {ID: 2, URL: "https://cdn.waifu.im/2.jpg"}}
+	m := &discordgo.MessageCreate{Message: &discordgo.Message{ID: "m", ChannelID: "c", Author: &discordgo.User{ID: "u"}}}
+	i := &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{ID: "i", AppID: "app", Token: "token", User: &discordgo.User{ID: "u"}}}
+
+	tests := []struct {
+		name string
+		send func(b *Bot, s *discordgo.Session)
+		path string // where the error is posted
+	}{
+		{"catgirl message", func(b *Bot, s *discordgo.Session) { b.sendImagesMessage(s, m, catgirls, "Here you go") }, "/channels/c/messages"},
+		{"waifu message", func(b *Bot, s *discordgo.Session) { b.sendWaifuImagesMessage(s, m, waifus, "Here you go") }, "/channels/c/messages"},
+		{"catgirl interaction", func(b *Bot, s *discordgo.Session) { b.sendImagesInteraction(s, i, catgirls, "Here you go") }, "/webhooks/app/token/messages/@original"},
+		{"waifu interaction", func(b *Bot, s *discordgo.Session) { b.sendWaifuImagesInteraction(s, i, waifus, "Here you go") }, "/webhooks/app/token/messages/@original"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			posts := stubDiscord(t)
+			s, _ := discordgo.New("Bot test")
+			b := &Bot{nekosAPI: api.New("test"), waifuAPI: api.NewWaifuClient("test"), requests: context.Background()}
+
+			tt.send(b, s)
+
+			got := posts()
+			if len(got) != 1 {
+				t.Fatalf("posted %+v, want a single error", got)
+			}
+			if !strings.HasSuffix(got[0].Path, tt.path) {
+				t.Errorf("posted to %s, want %s", got[0].Path, tt.path)
+			}
+			if got[0].Content != "❌ "+noDownloadsText {
+				t.Errorf("posted %q, want the no-downloads error", got[0].Content)
+			}
+		})
+	}
+}
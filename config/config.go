@@ -0,0 +1,348 @@
+// Package config loads the bot configuration from the environment
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"KawaiiBot/cron"
+	"KawaiiBot/logging"
+)
+
+// Defaults for optional settings
+const (
+	DefaultUserAgent         = "KawaiiBot (kawaiibot, v1.0.0)"
+	DefaultStatus            = "Looking at anime girls"
+	DefaultStoragePath       = "settings/bot_settings.json"
+	DefaultPrefix            = "!"
+	DefaultConnectAttempts   = 5
+	DefaultConnectBackoff    = 2 * time.Second
+	DefaultCleanupInterval   = 1 * time.Minute
+	MinCleanupInterval       = 10 * time.Second
+	DefaultRandomWaifuWeight = 50
+	DefaultSendHour          = 6
+	DefaultSendMinute        = 0
+	DefaultMaxRetries        = 3
+	DefaultDailyCount        = 1
+	DefaultFanoutWorkers     = 4
+	MaxDailyCount            = 5
+	DefaultMaxFilesPerGuild  = 50
+	DefaultShutdownTimeout   = 10 * time.Second
+	DefaultShutdownStep      = 3 * time.Second
+	DefaultProviderAlert     = time.Hour
+	DefaultImageMemoryMB     = 256
+)
+
+// Config is the resolved bot configuration. It is loaded once at startup.
+type Config struct {
+	Token             string        // DISCORD_BOT_TOKEN, required
+	UserAgent         string        // USER_AGENT
+	StoragePath       string        // STORAGE_PATH
+	Timezone          string        // LOCATION_ENV
+	Prefix            string        // COMMAND_PREFIX
+	MaxFileSize       int           // MAX_FILE_SIZE_MB in bytes, 0 means the Discord limit
+	ErrorEmbeds       bool          // ERROR_EMBEDS
+	CompressImages    bool          // COMPRESS_IMAGES
+	ConnectAttempts   int           // DISCORD_CONNECT_ATTEMPTS
+	ConnectBackoff    time.Duration // DISCORD_CONNECT_BACKOFF
+	CleanupInterval   time.Duration // CLEANUP_INTERVAL
+	ShutdownTimeout   time.Duration // SHUTDOWN_TIMEOUT, total budget for a graceful shutdown
+	ShutdownStep      time.Duration // SHUTDOWN_STEP_TIMEOUT, budget of each subsystem within it
+	MaxFilesPerGuild  int           // MAX_FILES_PER_GUILD, 0 means no cap
+	ImageMemory       int           // IMAGE_MEMORY_MB in bytes, 0 means no cap
+	RandomWaifuWeight int           // RANDOM_WAIFU_WEIGHT
+	RejectBadCounts   bool          // COUNT_OUT_OF_RANGE=reject, the default clamps
+	AllowNSFWDM       bool          // ALLOW_NSFW_DM
+	KeepImages        bool          // KEEP_IMAGES, archive served pictures instead of deleting them
+	Crosspost         bool          // DAILY_CROSSPOST, publish daily posts in announcement channels
+	ServedEventsPath  string        // SERVED_EVENTS_PATH, "-" for stdout, empty disables
+	AlertChannelID    string        // ALERT_CHANNEL_ID
+	AlertWebhookURL   string        // ALERT_WEBHOOK_URL
+	ProviderAlert     time.Duration // PROVIDER_ALERT_AFTER, failure streak that alerts about a provider
+	HTTPAddr          string        // HTTP_ADDR, empty disables the HTTP API
+	HTTPToken         string        // HTTP_TOKEN, shared secret for the HTTP API
+	LogLevel          slog.Level    // LOG_LEVEL
+	OwnerID           string        // BOT_OWNER_ID, user allowed to run owner commands
+	Status            string        // BOT_STATUS
+	StatusType        string        // BOT_STATUS_TYPE, lowercased; the bot validates it
+	Webhook           Webhook
+}
+
+// Webhook is the configuration of the daily webhook
+type Webhook struct {
+	URLs        []string          // WEBHOOK_URL, comma separated
+	ChannelID   string            // DAILY_CHANNEL_ID
+	ShowTags    bool              // WEBHOOK_SHOW_TAGS
+	EmptyNotice bool              // WEBHOOK_EMPTY_NOTICE
+	NoGreeting  bool              // WEBHOOK_NO_GREETING, post the embeds only
+	Greetings   []string          // WEBHOOK_GREETINGS or WEBHOOK_GREETINGS_FILE
+	Occasions   map[string]string // WEBHOOK_DATE_GREETINGS, keyed by MM-DD or YYYY-MM-DD
+	AllowedTags []string          // WEBHOOK_ALLOWED_TAGS, lowercased; empty allows every tag
+	MentionRole string            // DAILY_MENTION_ROLE_ID, pinged by the daily post
+	Waifus      int               // WEBHOOK_WAIFU_COUNT, 0 leaves waifus out
+	Catgirls    int               // WEBHOOK_CATGIRL_COUNT, 0 leaves catgirls out
+	Workers     int               // DAILY_FANOUT_WORKERS, daily destinations sent to at once
+	SendHour    int               // WEBHOOK_SEND_TIME hour
+	SendMinute  int               // WEBHOOK_SEND_TIME minute
+	Cron        *cron.Schedule    // WEBHOOK_CRON, overrides the send time when set
+	MaxRetries  int               // WEBHOOK_MAX_RETRIES
+}
+
+// Load reads the configuration from the environment, applying defaults and
+// validating every value. All invalid values are reported together.
+func Load() (Config, error) {
+	cfg := Config{
+		Token:            os.Getenv("DISCORD_BOT_TOKEN"),
+		UserAgent:        envOr("USER_AGENT", DefaultUserAgent),
+		StoragePath:      envOr("STORAGE_PATH", DefaultStoragePath),
+		Timezone:         os.Getenv("LOCATION_ENV"),
+		Prefix:           envOr("COMMAND_PREFIX", DefaultPrefix),
+		ErrorEmbeds:      os.Getenv("ERROR_EMBEDS") != "false",
+		CompressImages:   os.Getenv("COMPRESS_IMAGES") == "true",
+		AllowNSFWDM:      os.Getenv("ALLOW_NSFW_DM") == "true",
+		KeepImages:       os.Getenv("KEEP_IMAGES") == "true",
+		Crosspost:        os.Getenv("DAILY_CROSSPOST") == "true",
+		ServedEventsPath: os.Getenv("SERVED_EVENTS_PATH"),
+		AlertChannelID:   os.Getenv("ALERT_CHANNEL_ID"),
+		AlertWebhookURL:  os.Getenv("ALERT_WEBHOOK_URL"),
+		HTTPAddr:         os.Getenv("HTTP_ADDR"),
+		HTTPToken:        os.Getenv("HTTP_TOKEN"),
+		OwnerID:          os.Getenv("BOT_OWNER_ID"),
+		Status:           envOr("BOT_STATUS", DefaultStatus),
+		StatusType:       strings.ToLower(strings.TrimSpace(os.Getenv("BOT_STATUS_TYPE"))),
+		Webhook: Webhook{
+			URLs:        urlList(os.Getenv("WEBHOOK_URL")),
+			ChannelID:   os.Getenv("DAILY_CHANNEL_ID"),
+			ShowTags:    os.Getenv("WEBHOOK_SHOW_TAGS") == "true",
+			EmptyNotice: os.Getenv("WEBHOOK_EMPTY_NOTICE") == "true",
+			NoGreeting:  os.Getenv("WEBHOOK_NO_GREETING") == "true",
+			AllowedTags: tagList(os.Getenv("WEBHOOK_ALLOWED_TAGS")),
+			MentionRole: strings.TrimSpace(os.Getenv("DAILY_MENTION_ROLE_ID")),
+		},
+	}
+
+	var errs []error
+	if cfg.Token == "" {
+		errs = append(errs, errors.New("DISCORD_BOT_TOKEN is required"))
+	}
+	if cfg.HTTPAddr != "" && cfg.HTTPToken == "" {
+		errs = append(errs, errors.New("HTTP_TOKEN is required when HTTP_ADDR is set"))
+	}
+
+	maxFileSizeMB, err := intEnv("MAX_FILE_SIZE_MB", 0, 1, 0)
+	errs = append(errs, err)
+	cfg.MaxFileSize = maxFileSizeMB << 20
+
+	cfg.ConnectAttempts, err = intEnv("DISCORD_CONNECT_ATTEMPTS", DefaultConnectAttempts, 1, 0)
+	errs = append(errs, err)
+	cfg.ConnectBackoff, err = durationEnv("DISCORD_CONNECT_BACKOFF", DefaultConnectBackoff, time.Nanosecond)
+	errs = append(errs, err)
+	cfg.CleanupInterval, err = durationEnv("CLEANUP_INTERVAL", DefaultCleanupInterval, MinCleanupInterval)
+	errs = append(errs, err)
+	cfg.ShutdownTimeout, err = durationEnv("SHUTDOWN_TIMEOUT", DefaultShutdownTimeout, time.Second)
+	errs = append(errs, err)
+	cfg.ShutdownStep, err = durationEnv("SHUTDOWN_STEP_TIMEOUT", DefaultShutdownStep, 100*time.Millisecond)
+	errs = append(errs, err)
+	cfg.ProviderAlert, err = durationEnv("PROVIDER_ALERT_AFTER", DefaultProviderAlert, time.Minute)
+	errs = append(errs, err)
+	imageMemoryMB, err := intEnv("IMAGE_MEMORY_MB", DefaultImageMemoryMB, 0, 0)
+	errs = append(errs, err)
+	cfg.ImageMemory = imageMemoryMB << 20
+	cfg.MaxFilesPerGuild, err = intEnv("MAX_FILES_PER_GUILD", DefaultMaxFilesPerGuild, 0, 0)
+	errs = append(errs, err)
+	cfg.RandomWaifuWeight, err = intEnv("RANDOM_WAIFU_WEIGHT", DefaultRandomWaifuWeight, 0, 100)
+	errs = append(errs, err)
+	cfg.Webhook.MaxRetries, err = intEnv("WEBHOOK_MAX_RETRIES", DefaultMaxRetries, 1, 0)
+	errs = append(errs, err)
+	cfg.Webhook.SendHour, cfg.Webhook.SendMinute, err = sendTimeEnv("WEBHOOK_SEND_TIME")
+	errs = append(errs, err)
+	cfg.Webhook.Workers, err = intEnv("DAILY_FANOUT_WORKERS", DefaultFanoutWorkers, 1, 0)
+	errs = append(errs, err)
+	cfg.Webhook.Waifus, err = intEnv("WEBHOOK_WAIFU_COUNT", DefaultDailyCount, 0, MaxDailyCount)
+	errs = append(errs, err)
+	cfg.Webhook.Catgirls, err = intEnv("WEBHOOK_CATGIRL_COUNT", DefaultDailyCount, 0, MaxDailyCount)
+	errs = append(errs, err)
+	if cfg.Webhook.Waifus == 0 && cfg.Webhook.Catgirls == 0 {
+		errs = append(errs, errors.New("WEBHOOK_WAIFU_COUNT and WEBHOOK_CATGIRL_COUNT can't both be 0"))
+	}
+	if role := cfg.Webhook.MentionRole; role != "" && !isSnowflake(role) {
+		errs = append(errs, fmt.Errorf("invalid DAILY_MENTION_ROLE_ID %q: must be a Discord role ID", role))
+	}
+	switch value := os.Getenv("COUNT_OUT_OF_RANGE"); value {
+	case "", "clamp":
+	case "reject":
+		cfg.RejectBadCounts = true
+	default:
+		errs = append(errs, fmt.Errorf("invalid COUNT_OUT_OF_RANGE %q: must be clamp or reject", value))
+	}
+	if value := os.Getenv("LOG_LEVEL"); value != "" {
+		if cfg.LogLevel, err = logging.ParseLevel(value); err != nil {
+			errs = append(errs, fmt.Errorf("invalid LOG_LEVEL %q: must be debug, info, warn or error", value))
+		}
+	}
+	cfg.Webhook.Cron, err = cronEnv("WEBHOOK_CRON")
+	errs = append(errs, err)
+	cfg.Webhook.Greetings, err = loadGreetings()
+	errs = append(errs, err)
+	cfg.Webhook.Occasions, err = loadOccasions()
+	errs = append(errs, err)
+
+	return cfg, errors.Join(errs...)
+}
+
+// envOr returns the value of an environment variable, or fallback if unset
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// intEnv parses an integer environment variable within [lo, hi]. A hi of
+// zero means no upper bound.
+func intEnv(key string, fallback, lo, hi int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < lo || (hi > 0 && n > hi) {
+		if hi > 0 {
+			return fallback, fmt.Errorf("invalid %s %q: must be a number from %d to %d", key, value, lo, hi)
+		}
+		return fallback, fmt.Errorf("invalid %s %q: must be a number of at least %d", key, value, lo)
+	}
+	return n, nil
+}
+
+// isSnowflake reports whether value looks like a Discord ID: 17 to 20 digits
+func isSnowflake(value string) bool {
+	if len(value) < 17 || len(value) > 20 {
+		return false
+	}
+	_, err := strconv.ParseUint(value, 10, 64)
+	return err == nil
+}
+
+// durationEnv parses a duration environment variable of at least lo
+func durationEnv(key string, fallback, lo time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d < lo {
+		return fallback, fmt.Errorf("invalid %s %q: must be a duration like 2s, at least %s", key, value, lo)
+	}
+	return d, nil
+}
+
+// sendTimeEnv parses a HH:MM time of day environment variable
+func sendTimeEnv(key string) (int, int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return DefaultSendHour, DefaultSendMinute, nil
+	}
+
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return DefaultSendHour, DefaultSendMinute, fmt.Errorf("invalid %s %q: must be a time like 06:00", key, value)
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// cronEnv parses a cron expression environment variable. Unset yields nil.
+func cronEnv(key string) (*cron.Schedule, error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return nil, nil
+	}
+
+	schedule, err := cron.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return schedule, nil
+}
+
+// loadGreetings reads the greetings rotated through by the daily post. They
+// come from WEBHOOK_GREETINGS_FILE, one per line, or from WEBHOOK_GREETINGS
+// separated by '|'. Blank entries are ignored.
+func loadGreetings() ([]string, error) {
+	raw := os.Getenv("WEBHOOK_GREETINGS")
+	sep := "|"
+	if path := os.Getenv("WEBHOOK_GREETINGS_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read WEBHOOK_GREETINGS_FILE: %w", err)
+		}
+		raw, sep = string(data), "\n"
+	}
+
+	var greetings []string
+	for _, greeting := range strings.Split(raw, sep) {
+		if greeting = strings.TrimSpace(greeting); greeting != "" {
+			greetings = append(greetings, greeting)
+		}
+	}
+	return greetings, nil
+}
+
+// loadOccasions reads WEBHOOK_DATE_GREETINGS, date=greeting pairs separated
+// by '|' such as "01-01=Happy new year!|2025-06-01=Happy birthday, server!".
+// A MM-DD date repeats every year, a YYYY-MM-DD date matches once.
+func loadOccasions() (map[string]string, error) {
+	raw := os.Getenv("WEBHOOK_DATE_GREETINGS")
+	if raw == "" {
+		return nil, nil
+	}
+
+	occasions := make(map[string]string)
+	for _, entry := range strings.Split(raw, "|") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		date, greeting, ok := strings.Cut(entry, "=")
+		date, greeting = strings.TrimSpace(date), strings.TrimSpace(greeting)
+		if !ok || greeting == "" {
+			return nil, fmt.Errorf("invalid WEBHOOK_DATE_GREETINGS entry %q: expected date=greeting", entry)
+		}
+		if _, err := time.Parse(time.DateOnly, date); err != nil {
+			if _, err := time.Parse("01-02", date); err != nil {
+				return nil, fmt.Errorf("invalid WEBHOOK_DATE_GREETINGS date %q: must be MM-DD or YYYY-MM-DD", date)
+			}
+		}
+		occasions[date] = greeting
+	}
+	return occasions, nil
+}
+
+// urlList splits a comma separated URL list, dropping blank entries
+func urlList(raw string) []string {
+	var urls []string
+	for _, url := range strings.Split(raw, ",") {
+		if url = strings.TrimSpace(url); url != "" {
+			urls = append(urls, url)
+		}
+	}
+	return urls
+}
+
+// tagList splits a comma separated tag list, lowercasing the tags and
+// dropping blank entries
+func tagList(raw string) []string {
+	var tags []string
+	for _, tag := range strings.Split(raw, ",") {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadDailyCounts(t *testing.T) {
+	tests := []struct {
+		name             string
+		waifus, catgirls string
+		wantWaifus       int
+		wantCatgirls     int
+		wantErr          bool
+	}{
+		{"defaults", "", "", DefaultDailyCount, DefaultDailyCount, false},
+		{"no catgirls", "2", "0", 2, 0, false},
+		{"no waifus", "0", "3", 0, 3, false},
+		{"neither", "0", "0", 0, 0, true},
+		{"over the limit", "99", "1", DefaultDailyCount, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DISCORD_BOT_TOKEN", "test")
+			t.Setenv("WEBHOOK_WAIFU_COUNT", tt.waifus)
+			t.Setenv("WEBHOOK_CATGIRL_COUNT", tt.catgirls)
+
+			cfg, err := Load()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load = %v, want error %t", err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "WEBHOOK_") {
+				t.Errorf("error %q doesn't name the count variables", err)
+			}
+			if cfg.Webhook.Waifus != tt.wantWaifus || cfg.Webhook.Catgirls != tt.wantCatgirls {
+				t.Errorf("counts = %d waifus, %d catgirls; want %d, %d",
+					cfg.Webhook.Waifus, cfg.Webhook.Catgirls, tt.wantWaifus, tt.wantCatgirls)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+// Package cron parses standard five field cron expressions
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// searchYears bounds how far ahead Next looks for a matching time. It covers
+// the eight year gap between leap years around 2100 for "29 Feb" schedules.
+const searchYears = 10
+
+// field describes the allowed range of one cron field
+type field struct {
+	name   string
+	lo, hi int
+}
+
+var fields = [5]field{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 6},
+}
+
+// Schedule is a parsed cron expression
+type Schedule struct {
+	spec    string
+	minute  uint64
+	hour    uint64
+	dom     uint64
+	month   uint64
+	dow     uint64
+	domStar bool
+	dowStar bool
+}
+
+// Parse parses a cron expression of the form "minute hour day-of-month month
+// day-of-week". Each field accepts *, numbers, ranges like 1-5, lists like
+// 1,15 and steps like */15. Day of week runs from 0 (Sunday) to 6; 7 is
+// accepted as Sunday too.
+func Parse(spec string) (*Schedule, error) {
+	parts := strings.Fields(spec)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("expected 5 fields (minute hour day month weekday), got %d", len(parts))
+	}
+
+	var sets [5]uint64
+	for i, part := range parts {
+		hi := fields[i].hi
+		if i == 4 {
+			hi = 7
+		}
+		set, err := parseField(part, fields[i].lo, hi)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", fields[i].name, part, err)
+		}
+		sets[i] = set
+	}
+
+	// Fold Sunday as 7 onto 0
+	if sets[4]&(1<<7) != 0 {
+		sets[4] = sets[4]&^(1<<7) | 1
+	}
+
+	s := &Schedule{
+		spec:    strings.Join(parts, " "),
+		minute:  sets[0],
+		hour:    sets[1],
+		dom:     sets[2],
+		month:   sets[3],
+		dow:     sets[4],
+		domStar: strings.HasPrefix(parts[2], "*"),
+		dowStar: strings.HasPrefix(parts[4], "*"),
+	}
+
+	if s.Next(time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)).IsZero() {
+		return nil, fmt.Errorf("%q never matches a date", s.spec)
+	}
+	return s, nil
+}
+
+// parseField parses one comma separated field into a bit set
+func parseField(value string, lo, hi int) (uint64, error) {
+	var set uint64
+	for _, item := range strings.Split(value, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(item, "/")
+
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("bad step %q", stepPart)
+			}
+			step = n
+		}
+
+		start, end := lo, hi
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			from, to, _ := strings.Cut(rangePart, "-")
+			var err error
+			if start, err = strconv.Atoi(from); err != nil {
+				return 0, fmt.Errorf("bad number %q", from)
+			}
+			if end, err = strconv.Atoi(to); err != nil {
+				return 0, fmt.Errorf("bad number %q", to)
+			}
+		default:
+			n, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return 0, fmt.Errorf("bad number %q", rangePart)
+			}
+			start = n
+			if !hasStep {
+				end = n
+			}
+		}
+
+		if start < lo || end > hi || start > end {
+			return 0, fmt.Errorf("must be within %d-%d", lo, hi)
+		}
+		for n := start; n <= end; n += step {
+			set |= 1 << n
+		}
+	}
+	return set, nil
+}
+
+// String returns the normalized cron expression
+func (s *Schedule) String() string {
+	return s.spec
+}
+
+// Next returns the first matching time strictly after t, in t's location. It
+// returns the zero time if nothing matches within the search window.
+//
+// Matching happens on the wall clock: a time in the hour skipped when
+// daylight saving time starts runs an hour later, and the hour repeated when
+// it ends only runs once.
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+
+	// Search the wall clock in UTC, which has no gaps or repeats, and map
+	// each match back to loc until one lies after t
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+	for {
+		if wall = s.nextWall(wall); wall.IsZero() {
+			return wall
+		}
+		if next := wallTime(wall, loc); next.After(t) {
+			return next
+		}
+	}
+}
+
+// wallTime maps a wall clock time, given in UTC, to loc. time.Date resolves a
+// time skipped by a daylight saving change either way depending on the zone,
+// so such a time is moved forward by the gap here, to when the clock has
+// passed it.
+func wallTime(wall time.Time, loc *time.Location) time.Time {
+	t := time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), 0, 0, loc)
+	if t.Hour() == wall.Hour() && t.Minute() == wall.Minute() {
+		return t
+	}
+
+	// Read the wall clock with the offset in effect before the gap
+	_, before := t.Add(-6 * time.Hour).Zone()
+	return wall.Add(-time.Duration(before) * time.Second).In(loc)
+}
+
+// nextWall returns the first matching minute strictly after t, a wall clock
+// time in UTC, or the zero time if none is within the search window
+func (s *Schedule) nextWall(t time.Time) time.Time {
+	t = t.Add(time.Minute)
+	limit := t.AddDate(searchYears, 0, 0)
+
+	// Advance by the largest unit that doesn't match. time.Date normalizes
+	// overflowing fields, so this also steps across months and years.
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<int(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+		case s.hour&(1<<t.Hour()) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, time.UTC)
+		case s.minute&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches applies the cron day rule: when both day fields are restricted,
+// a date matches if either of them does
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<t.Day()) != 0
+	dow := s.dow&(1<<int(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
@@ -0,0 +1,146 @@
+package cron
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		spec string
+		want string // part of the error
+	}{
+		{"", "expected 5 fields"},
+		{"0 9 * *", "expected 5 fields"},
+		{"0 9 * * * *", "expected 5 fields"},
+		{"60 9 * * *", "invalid minute"},
+		{"0 24 * * *", "invalid hour"},
+		{"0 9 0 * *", "invalid day of month"},
+		{"0 9 32 * *", "invalid day of month"},
+		{"0 9 * 13 *", "invalid month"},
+		{"0 9 * * 8", "invalid day of week"},
+		{"a 9 * * *", "bad number"},
+		{"0 5-3 * * *", "must be within"},
+		{"0 1- * * *", "bad number"},
+		{"*/0 9 * * *", "bad step"},
+		{"*/x 9 * * *", "bad step"},
+		{"0 9 30 2 *", "never matches"},
+		{"0 0 31 4,6,9,11 *", "never matches"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			_, err := Parse(tt.spec)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse(%q) = %v, want an error containing %q", tt.spec, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseString(t *testing.T) {
+	s, err := Parse("  0   9 * *  1-5 ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.String(); got != "0 9 * * 1-5" {
+		t.Errorf("String = %q, want the normalized expression", got)
+	}
+}
+
+func TestNext(t *testing.T) {
+	utc := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	// 2026-03-02 is a Monday
+	tests := []struct {
+		name string
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{"later today", "0 9 * * *", utc(time.March, 2, 8, 0), utc(time.March, 2, 9, 0)},
+		{"strictly after", "0 9 * * *", utc(time.March, 2, 9, 0), utc(time.March, 3, 9, 0)},
+		{"seconds are dropped", "0 9 * * *", utc(time.March, 2, 8, 59).Add(30 * time.Second), utc(time.March, 2, 9, 0)},
+		{"range", "0 9-11 * * *", utc(time.March, 2, 9, 30), utc(time.March, 2, 10, 0)},
+		{"list", "15,45 * * * *", utc(time.March, 2, 9, 20), utc(time.March, 2, 9, 45)},
+		{"step", "*/20 * * * *", utc(time.March, 2, 9, 41), utc(time.March, 2, 10, 0)},
+		{"stepped range", "0 8-18/5 * * *", utc(time.March, 2, 14, 0), utc(time.March, 2, 18, 0)},
+		{"stepped start", "0 3/12 * * *", utc(time.March, 2, 4, 0), utc(time.March, 2, 15, 0)},
+		{"weekdays", "0 9 * * 1-5", utc(time.March, 6, 10, 0), utc(time.March, 9, 9, 0)},
+		{"Sunday as 0", "0 9 * * 0", utc(time.March, 2, 10, 0), utc(time.March, 8, 9, 0)},
+		{"Sunday as 7", "0 9 * * 7", utc(time.March, 2, 10, 0), utc(time.March, 8, 9, 0)},
+		{"day of month", "0 0 15 * *", utc(time.March, 15, 0, 0), utc(time.April, 15, 0, 0)},
+		{"day of month or day of week", "0 0 13 * 5", utc(time.March, 2, 0, 0), utc(time.March, 6, 0, 0)},
+		{"day of week or day of month", "0 0 3 * 5", utc(time.March, 2, 0, 0), utc(time.March, 3, 0, 0)},
+		{"day of month with starred weekday", "0 0 13 * *", utc(time.March, 2, 0, 0), utc(time.March, 13, 0, 0)},
+		{"starred weekday step needs both", "0 0 13 * */7", utc(time.March, 2, 0, 0), utc(time.September, 13, 0, 0)},
+		{"month", "0 0 1 6 *", utc(time.March, 2, 0, 0), utc(time.June, 1, 0, 0)},
+		{"across the year", "0 0 1 1 *", utc(time.March, 2, 0, 0), time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"leap day", "0 0 29 2 *", utc(time.March, 2, 0, 0), time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := s.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%v) = %v, want %v", tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextAcrossDST(t *testing.T) {
+	tests := []struct {
+		name     string
+		location string
+		spec     string
+		from     string
+		want     string // with the offset it should be in
+	}{
+		// Clocks go from 02:00 EST to 03:00 EDT on 2026-03-08
+		{"daily before spring forward", "America/New_York", "0 9 * * *", "2026-03-08T00:30:00-05:00", "2026-03-08T09:00:00-04:00"},
+		{"into the skipped hour", "America/New_York", "30 2 * * *", "2026-03-07T03:00:00-05:00", "2026-03-08T03:30:00-04:00"},
+		{"skipped hour east of UTC", "Europe/Berlin", "30 2 * * *", "2026-03-28T03:00:00+01:00", "2026-03-29T03:30:00+02:00"},
+		{"after spring forward", "America/New_York", "30 2 * * *", "2026-03-08T03:30:00-04:00", "2026-03-09T02:30:00-04:00"},
+		{"every hour over spring forward", "America/New_York", "0 * * * *", "2026-03-08T01:30:00-05:00", "2026-03-08T03:00:00-04:00"},
+		// Clocks go from 02:00 EDT back to 01:00 EST on 2026-11-01
+		{"before fall back", "America/New_York", "30 2 * * *", "2026-10-31T03:00:00-04:00", "2026-11-01T02:30:00-05:00"},
+		{"repeated hour runs once", "America/New_York", "30 1 * * *", "2026-11-01T01:30:00-04:00", "2026-11-02T01:30:00-05:00"},
+		{"inside the repeated hour", "America/New_York", "45 1 * * *", "2026-11-01T01:10:00-05:00", "2026-11-02T01:45:00-05:00"},
+		{"every hour over fall back", "America/New_York", "0 * * * *", "2026-11-01T01:30:00-04:00", "2026-11-01T02:00:00-05:00"},
+		{"east of UTC", "Europe/Berlin", "0 9 * * *", "2026-03-29T00:30:00+01:00", "2026-03-29T09:00:00+02:00"},
+		{"half hour offset", "Asia/Kolkata", "0 * * * *", "2026-03-02T10:15:00+05:30", "2026-03-02T11:00:00+05:30"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc, err := time.LoadLocation(tt.location)
+			if err != nil {
+				t.Skipf("no timezone data: %v", err)
+			}
+			s, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			from, _ := time.Parse(time.RFC3339, tt.from)
+			want, _ := time.Parse(time.RFC3339, tt.want)
+
+			done := make(chan time.Time, 1)
+			go func() { done <- s.Next(from.In(loc)) }()
+			select {
+			case got := <-done:
+				if !got.Equal(want) || got.Location() != loc {
+					t.Errorf("Next(%s) = %s, want %s", tt.from, got.Format(time.RFC3339), tt.want)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("Next(%s) didn't return", tt.from)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+// Package logging sets up the process wide logger and its runtime level
+package logging
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// level is the minimum level logged. It can be changed while running.
+var level = new(slog.LevelVar)
+
+// Setup installs a default logger that honors the runtime level. The log
+// package is routed through it at info level, so warn or error hides the
+// regular log output.
+func Setup(initial slog.Level) {
+	level.Set(initial)
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})))
+}
+
+// Level returns the current minimum level
+func Level() slog.Level {
+	return level.Level()
+}
+
+// SetLevel changes the minimum level of the running logger
+func SetLevel(l slog.Level) {
+	level.Set(l)
+}
+
+// ParseLevel parses one of debug, info, warn or error, ignoring case
+func ParseLevel(value string) (slog.Level, error) {
+	var l slog.Level
+	err := l.UnmarshalText([]byte(strings.TrimSpace(value)))
+	return l, err
+}
@@ -2,41 +2,76 @@ package main
 
 import (
 	"context"
+	"errors"
+	"io/fs"
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
-	"time"
 
 	"KawaiiBot/bot"
+	"KawaiiBot/config"
+	"KawaiiBot/logging"
 
 	"github.com/joho/godotenv"
 )
 
+// envFiles returns the .env files to load, highest precedence first:
+// .env.local, then .env.{KAWAIIBOT_ENV} if set, then .env
+func envFiles() []string {
+	files := []string{".env.local"}
+	if env := os.Getenv("KAWAIIBOT_ENV"); env != "" {
+		files = append(files, ".env."+env)
+	}
+	return append(files, ".env")
+}
+
+// loadEnvFiles loads the existing .env files and returns their names.
+// godotenv.Load never overrides a variable that is already set, so loading
+// in precedence order lets earlier files win over later ones while the
+// process environment still wins over all of them.
+func loadEnvFiles() []string {
+	var loaded []string
+	for _, file := range envFiles() {
+		if err := godotenv.Load(file); err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				log.Printf("Failed to load %s: %v", file, err)
+			}
+			continue
+		}
+		loaded = append(loaded, file)
+	}
+	return loaded
+}
+
 func main() {
 	// Load environment variables
-	if err := godotenv.Load(); err != nil {
+	if loaded := loadEnvFiles(); len(loaded) == 0 {
 		log.Println("No .env file found, using environment variables")
+	} else {
+		log.Printf("Loaded environment from %s", strings.Join(loaded, ", "))
 	}
 
-	// Get bot token
-	token := os.Getenv("DISCORD_BOT_TOKEN")
-	if token == "" {
-		log.Fatal("Please set DISCORD_BOT_TOKEN environment variable")
+	// Load and validate the configuration once
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
 	}
+	logging.Setup(cfg.LogLevel)
 
 	// Create context for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	// Create bot instance
-	discordBot, err := bot.New(token)
+	discordBot, err := bot.New(cfg)
 	if err != nil {
 		log.Fatalf("Error creating bot: %v", err)
 	}
-	locEnv := os.Getenv("LOCATION_ENV")
+
 	// Start bot
-	if err := discordBot.Start(ctx, locEnv); err != nil {
+	if err := discordBot.Start(ctx); err != nil {
 		log.Fatalf("Error starting bot: %v", err)
 	}
 
@@ -50,7 +85,7 @@ func main() {
 	log.Println("Shutting down gracefully...")
 
 	// Create shutdown context with timeout
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 	defer shutdownCancel()
 
 	if err := discordBot.Stop(shutdownCtx); err != nil {
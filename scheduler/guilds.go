@@ -0,0 +1,84 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"time"
+
+	"KawaiiBot/storage"
+)
+
+// guildTimer is the daily timer of a guild with its own schedule
+type guildTimer struct {
+	guildID  string
+	schedule storage.GuildSchedule
+	timer    *time.Timer
+}
+
+// ReloadGuildSchedules makes the running scheduler pick up changed guild
+// schedules. Calls while a reload is pending are merged.
+func (s *Scheduler) ReloadGuildSchedules() {
+	select {
+	case s.reload <- struct{}{}:
+	default:
+	}
+}
+
+// GuildNextSend returns the next time a guild schedule fires. A schedule
+// without a valid timezone uses the scheduler's timezone.
+func (s *Scheduler) GuildNextSend(schedule storage.GuildSchedule) time.Time {
+	now := getTime()
+	if schedule.Timezone != "" {
+		if loc, err := time.LoadLocation(schedule.Timezone); err == nil {
+			now = now.In(loc)
+		}
+	}
+	return nextDailyAt(now, schedule.Hour, schedule.Minute)
+}
+
+// syncGuildTimers brings the running guild timers in line with storage:
+// timers of removed or changed schedules are stopped, new ones are armed.
+// Only the scheduling routine calls it, so the map needs no lock.
+func (s *Scheduler) syncGuildTimers(ctx context.Context, stopChan <-chan struct{}, timers map[string]*guildTimer, fired chan<- *guildTimer) {
+	schedules := s.storage.GetGuildSchedules()
+
+	for guildID, gt := range timers {
+		if schedule, ok := schedules[guildID]; !ok || schedule != gt.schedule {
+			gt.timer.Stop()
+			delete(timers, guildID)
+		}
+	}
+
+	for guildID, schedule := range schedules {
+		if _, ok := timers[guildID]; ok {
+			continue
+		}
+
+		gt := &guildTimer{guildID: guildID, schedule: schedule}
+		next := s.GuildNextSend(schedule)
+		gt.timer = time.AfterFunc(time.Until(next), func() {
+			select {
+			case fired <- gt:
+			case <-stopChan:
+			case <-ctx.Done():
+			}
+		})
+		timers[guildID] = gt
+		log.Printf("[SCHEDULER] Guild %s daily post scheduled for %s", guildID, next.Format("2006-01-02 15:04 MST"))
+	}
+}
+
+// sendGuildDaily posts the daily pictures to a guild's own channel. Guilds
+// sharing a send time queue for one of the DAILY_FANOUT_WORKERS slots.
+func (s *Scheduler) sendGuildDaily(guildID string, schedule storage.GuildSchedule) {
+	s.guildSlots <- struct{}{}
+	defer func() { <-s.guildSlots }()
+
+	log.Printf("[SCHEDULER] Sending daily pictures for guild %s to channel %s...", guildID, schedule.ChannelID)
+	if err := s.dailyWebhook.SendToChannel(schedule.ChannelID); err != nil {
+		slog.Error("[SCHEDULER] Failed to send daily pictures", "guild", guildID, "error", err)
+		return
+	}
+	log.Printf("[SCHEDULER] Daily pictures sent for guild %s", guildID)
+}
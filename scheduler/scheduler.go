@@ -3,43 +3,145 @@ package scheduler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"log/slog"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	_ "time/tzdata"
+	_ "time/tzdata" // embed the timezone database so zones resolve in minimal images
 
+	"KawaiiBot/config"
+	"KawaiiBot/cron"
+	"KawaiiBot/storage"
 	"KawaiiBot/webhook"
 )
 
-var location *time.Location
+// location is the scheduler's timezone. A restarted scheduler replaces it
+// while the routine of the previous run may still be reading it.
+var location atomic.Pointer[time.Location]
+
+// Alerter notifies operators that the daily webhook could not be sent
+type Alerter func(message string) error
+
+// ErrSendRunning is returned by ForceSend while a daily send is still running
+var ErrSendRunning = errors.New("a daily webhook send is already running")
+
+// HealthCheck reports whether the Discord session is connected
+type HealthCheck func() bool
+
+// While the session is unhealthy, the daily send is rechecked every
+// healthRecheck and sent anyway after maxHealthDeferral
+const (
+	healthRecheck     = 30 * time.Second
+	maxHealthDeferral = 10 * time.Minute
+)
 
 // Scheduler handles scheduled tasks
 type Scheduler struct {
 	dailyWebhook *webhook.DailyWebhook
-	ticker       *time.Ticker
+	storage      *storage.Storage
+	alerter      Alerter
+	healthy      HealthCheck
+	maxRetries   int
+	sendHour     int
+	sendMinute   int
+	cron         *cron.Schedule
 	mutex        sync.Mutex
 	running      bool
 	stopChan     chan struct{}
+	reload       chan struct{} // asks the routine to re-read the guild schedules
+	reschedule   chan struct{} // asks the routine to re-arm the daily timer
+	armedAt      time.Time     // when the running daily timer fires
+	sending      atomic.Bool   // a global daily send, with its retries, is running
+	guildSlots   chan struct{} // bounds how many guild daily posts run at once
 }
 
 // New creates a new Scheduler instance
-func New(dailyWebhook *webhook.DailyWebhook) *Scheduler {
+func New(dailyWebhook *webhook.DailyWebhook, storage *storage.Storage, cfg config.Webhook) *Scheduler {
 	return &Scheduler{
 		dailyWebhook: dailyWebhook,
+		storage:      storage,
+		maxRetries:   cfg.MaxRetries,
+		sendHour:     cfg.SendHour,
+		sendMinute:   cfg.SendMinute,
+		cron:         cfg.Cron,
 		stopChan:     make(chan struct{}),
+		reload:       make(chan struct{}, 1),
+		reschedule:   make(chan struct{}, 1),
+		guildSlots:   make(chan struct{}, max(cfg.Workers, 1)),
+	}
+}
+
+// SetAlerter sets the function used to report a daily webhook that failed
+// every attempt. Without one, failures are only logged.
+func (s *Scheduler) SetAlerter(alerter Alerter) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.alerter = alerter
+}
+
+// SetHealthCheck sets the function telling whether Discord is reachable.
+// Without one, the daily webhook is always sent right away.
+func (s *Scheduler) SetHealthCheck(healthy HealthCheck) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.healthy = healthy
+}
+
+// SetMaxRetries sets how often a failing daily webhook is attempted
+func (s *Scheduler) SetMaxRetries(maxRetries int) error {
+	if maxRetries < 1 {
+		return fmt.Errorf("max retries must be at least 1, got %d", maxRetries)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.maxRetries = maxRetries
+	return nil
+}
+
+// MaxRetries returns how often a failing daily webhook is attempted
+func (s *Scheduler) MaxRetries() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.maxRetries
+}
+
+// SetSendTime changes the daily send time until the next restart. It
+// replaces a WEBHOOK_CRON schedule and re-arms the running timer.
+func (s *Scheduler) SetSendTime(hour, minute int) error {
+	if hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+		return fmt.Errorf("invalid send time %02d:%02d", hour, minute)
+	}
+
+	s.mutex.Lock()
+	s.sendHour, s.sendMinute, s.cron = hour, minute, nil
+	s.mutex.Unlock()
+
+	s.Reschedule()
+	return nil
+}
+
+// Reschedule makes the running routine recompute its next send from the
+// current settings. It never blocks; calls made before the routine wakes up
+// collapse into one.
+func (s *Scheduler) Reschedule() {
+	select {
+	case s.reschedule <- struct{}{}:
+	default:
 	}
 }
 
 // Start starts the scheduler
 func (s *Scheduler) Start(ctx context.Context, locEnv string) error {
-	var locErr error
-	location, locErr = time.LoadLocation(locEnv)
-	if locErr != nil {
-		return fmt.Errorf("failed to load timezone %q: %w", locEnv, locErr)
-	}
-	log.Printf("Timezone set to: %s", location)
+	loc := loadLocation(locEnv)
+	location.Store(loc)
+	log.Printf("Timezone set to: %s", loc)
+	s.dailyWebhook.SetLocation(loc)
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
@@ -47,23 +149,54 @@ func (s *Scheduler) Start(ctx context.Context, locEnv string) error {
 		return fmt.Errorf("scheduler is already running")
 	}
 
-	// Check if daily webhook is configured
+	// The loop always runs and checks enablement when it fires, so enabling
+	// the webhook at runtime takes effect without a restart
 	if !s.dailyWebhook.IsEnabled() {
-		log.Println("Daily webhook is not configured or disabled, scheduler will not start")
-		return nil
+		log.Println("Daily webhook is not configured or disabled yet, sends will be skipped until it is enabled")
 	}
 
 	s.running = true
 
 	// Start the scheduling routine
-	go s.schedulingRoutine(ctx)
+	go s.schedulingRoutine(ctx, s.stopChan)
 
 	log.Println("Scheduler started successfully")
 	return nil
 }
 
-func getTime() time.Time {
-	return time.Now().In(location)
+// loadLocation loads the configured timezone. An unknown zone falls back to
+// UTC rather than failing, as the local zone of a container is rarely right.
+func loadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		// The tzdata database is embedded via the time/tzdata import, so a
+		// failure here almost always means a misspelled zone name
+		slog.Warn("[SCHEDULER] Unknown timezone, falling back to UTC. "+
+			"Use an IANA zone name such as Europe/Berlin; if you removed the time/tzdata import, install the tzdata package.",
+			"timezone", name, "error", err)
+		return time.UTC
+	}
+	return loc
+}
+
+// getTime returns the current time in the scheduler's timezone. Tests
+// replace it with a fixed clock.
+var getTime = func() time.Time {
+	return time.Now().In(currentLocation())
+}
+
+// currentLocation returns the scheduler's timezone. Interactions can ask for
+// the time before Start has loaded it; they get UTC, the default timezone.
+func currentLocation() *time.Location {
+	if loc := location.Load(); loc != nil {
+		return loc
+	}
+	return time.UTC
+}
+
+// Now returns the current time in the scheduler's timezone
+func (s *Scheduler) Now() time.Time {
+	return getTime()
 }
 
 // Stop stops the scheduler
@@ -75,70 +208,183 @@ func (s *Scheduler) Stop() error {
 		return fmt.Errorf("scheduler is not running")
 	}
 
+	// The routine owns its timer; a fresh channel lets Start run it again
 	close(s.stopChan)
+	s.stopChan = make(chan struct{})
 	s.running = false
 
-	if s.ticker != nil {
-		s.ticker.Stop()
-	}
-
 	log.Println("Scheduler stopped")
 	return nil
 }
 
-// schedulingRoutine runs the main scheduling loop
-func (s *Scheduler) schedulingRoutine(ctx context.Context) {
-	// Calculate time until next midnight
-	timeUntilNextSend := s.getTimeUntilNextSend()
+// schedulingRoutine runs the main scheduling loop. It owns the timer of the
+// global daily webhook and one timer per guild with its own schedule.
+func (s *Scheduler) schedulingRoutine(ctx context.Context, stopChan <-chan struct{}) {
+	// Calculate time until the first send
+	now := getTime()
+	timeUntilNextSend := s.getTimeUntilNextSend(now)
 
 	log.Printf("First daily webhook will be sent in %v", timeUntilNextSend)
 
-	// Create timer for first execution at midnight
+	// Create timer for the first execution
 	timer := time.NewTimer(timeUntilNextSend)
 	defer timer.Stop()
+	s.setArmed(now.Add(timeUntilNextSend))
+
+	// Guild timers report to the loop, which sends and re-arms them
+	guildTimers := make(map[string]*guildTimer)
+	fired := make(chan *guildTimer)
+	defer func() {
+		for _, gt := range guildTimers {
+			gt.timer.Stop()
+		}
+	}()
+	s.syncGuildTimers(ctx, stopChan, guildTimers, fired)
 
 	for {
 		select {
 		case <-ctx.Done():
 			log.Println("Scheduler context cancelled")
 			return
-		case <-s.stopChan:
+		case <-stopChan:
 			log.Println("Scheduler stopped by request")
 			return
+		case <-s.reload:
+			s.syncGuildTimers(ctx, stopChan, guildTimers, fired)
+		case <-s.reschedule:
+			now := getTime()
+			timeUntilNextSend := s.getTimeUntilNextSend(now)
+			log.Printf("[SCHEDULER] Schedule changed, next daily webhook will be sent in %v", timeUntilNextSend)
+			timer.Reset(timeUntilNextSend)
+			s.setArmed(now.Add(timeUntilNextSend))
+		case gt := <-fired:
+			// Ignore timers replaced by a reload while they were firing
+			if guildTimers[gt.guildID] != gt {
+				continue
+			}
+			go s.sendGuildDaily(gt.guildID, gt.schedule)
+			gt.timer.Reset(time.Until(s.GuildNextSend(gt.schedule)))
 		case <-timer.C:
-			// It's midnight! Send the daily webhook
-			s.sendDailyWebhook()
-
-			// Reset timer for next midnight (24 hours from now)
-			// Calculate time until next midnight and reset timer
-			timeUntilNextSend := s.getTimeUntilNextSend()
+			// It's time! Send the daily webhook unless it is paused or a skip day
+			if reason := s.skipReason(getTime()); reason != "" {
+				log.Printf("[SCHEDULER] Skipping daily webhook: %s", reason)
+			} else if s.takeSnooze() {
+				log.Println("[SCHEDULER] Skipping daily webhook: snoozed for this send")
+			} else {
+				// Retries can take a while, keep the guild timers and
+				// reschedules going meanwhile
+				go s.sendDailyWebhook(ctx, stopChan)
+			}
+
+			// Calculate time until the next send and reset timer
+			now := getTime()
+			timeUntilNextSend := s.getTimeUntilNextSend(now)
 			log.Printf("Next daily webhook will be sent in %v", timeUntilNextSend)
 			timer.Reset(timeUntilNextSend)
+			s.setArmed(now.Add(timeUntilNextSend))
 		}
 	}
 }
 
-func (s *Scheduler) getTimeUntilNextSend() time.Duration {
-	now := getTime()
-
-	// Create target time: today at 6:00:00 AM in local timezone
-	target := time.Date(now.Year(), now.Month(), now.Day(), 6, 0, 0, 0, now.Location())
+// setArmed records when the daily timer fires next
+func (s *Scheduler) setArmed(at time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.armedAt = at
+}
 
-	// If 6 AM today has already passed, schedule for tomorrow
-	if now.After(target) || now.Equal(target) {
-		target = target.Add(24 * time.Hour)
-	}
+// getTimeUntilNextSend returns the duration from now until the next send time.
+// now is passed in so the computation is independent of the wall clock.
+func (s *Scheduler) getTimeUntilNextSend(now time.Time) time.Duration {
+	target := s.nextSendAfter(now)
 
 	timeUntil := target.Sub(now)
-	log.Printf("[SCHEDULER] Current time: %s, Next 6AM: %s, Time until: %v",
-		now.Format("2006-01-02 15:04:05"),
-		target.Format("2006-01-02 15:04:05"),
-		timeUntil)
+	slog.Debug("[SCHEDULER] Computed next send",
+		"now", now.Format("2006-01-02 15:04:05"),
+		"next", target.Format("2006-01-02 15:04:05"),
+		"in", timeUntil)
 	return timeUntil
 }
 
-// sendDailyWebhook sends the daily webhook
-func (s *Scheduler) sendDailyWebhook() {
+// nextSendAfter returns the first send time strictly after now. A cron
+// schedule replaces the daily send time when configured.
+func (s *Scheduler) nextSendAfter(now time.Time) time.Time {
+	s.mutex.Lock()
+	hour, minute, schedule := s.sendHour, s.sendMinute, s.cron
+	s.mutex.Unlock()
+
+	if schedule != nil {
+		return schedule.Next(now)
+	}
+	return nextDailyAt(now, hour, minute)
+}
+
+// nextDailyAt returns the first time strictly after now at hour:minute in
+// now's timezone
+func nextDailyAt(now time.Time, hour, minute int) time.Time {
+	// Create target time: today at the send time in now's timezone
+	target := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+
+	// If the send time today has already passed, schedule for tomorrow. Adding a day
+	// through time.Date keeps the wall clock time stable across DST changes,
+	// where a day is not always 24 hours long.
+	if !now.Before(target) {
+		target = time.Date(now.Year(), now.Month(), now.Day()+1, hour, minute, 0, 0, now.Location())
+	}
+	return target
+}
+
+// NextFireTime returns when the scheduler timer fires next
+func (s *Scheduler) NextFireTime() time.Time {
+	return s.nextSendAfter(getTime())
+}
+
+// NextSendTime returns the next time the daily webhook is actually sent,
+// taking pauses, skip days and a pending snooze into account. It returns the
+// zero time if nothing is sent within the next year.
+func (s *Scheduler) NextSendTime() time.Time {
+	snoozed := s.storage.GetWebhookSnoozed()
+	fire := s.NextFireTime()
+	for range 366 {
+		if s.skipReason(fire) == "" {
+			if !snoozed {
+				return fire
+			}
+			snoozed = false
+		}
+		fire = s.nextSendAfter(fire)
+	}
+	return time.Time{}
+}
+
+// Snooze skips the next daily send that would otherwise happen and returns
+// the send after it
+func (s *Scheduler) Snooze() (time.Time, error) {
+	if err := s.storage.SetWebhookSnoozed(true); err != nil {
+		return time.Time{}, err
+	}
+	return s.NextSendTime(), nil
+}
+
+// takeSnooze clears a pending snooze and reports whether there was one
+func (s *Scheduler) takeSnooze() bool {
+	snoozed, err := s.storage.TakeWebhookSnoozed()
+	if err != nil {
+		slog.Warn("[SCHEDULER] Failed to clear snooze", "error", err)
+	}
+	return snoozed
+}
+
+// sendDailyWebhook sends the daily webhook. Waiting between retries is cut
+// short when ctx is cancelled or stopChan is closed. A send started while
+// another one is still running is skipped.
+func (s *Scheduler) sendDailyWebhook(ctx context.Context, stopChan <-chan struct{}) {
+	if !s.sending.CompareAndSwap(false, true) {
+		log.Println("[SCHEDULER] A daily webhook send is still running, skipping this one")
+		return
+	}
+	defer s.sending.Store(false)
+
 	log.Println("[SCHEDULER] Attempting to send daily webhook...")
 
 	// Check if webhook is still enabled
@@ -148,30 +394,148 @@ func (s *Scheduler) sendDailyWebhook() {
 	}
 
 	// Get webhook status for logging
-	enabled, url := s.dailyWebhook.GetStatus()
-	log.Printf("[SCHEDULER] Webhook status - Enabled: %v, URL configured: %v", enabled, url != "")
-
-	// Send the webhook with retry logic
-	maxRetries := 3
+	enabled, urls := s.dailyWebhook.GetStatus()
+	log.Printf("[SCHEDULER] Webhook status - Enabled: %v, URLs configured: %d", enabled, urls)
+
+	// Send the webhook with retry logic. A retry only resends to the
+	// destinations that failed, so the others don't get the post twice.
+	maxRetries := s.MaxRetries()
+	attempts := 0
+	var err error
 	for i := 0; i < maxRetries; i++ {
+		if !s.waitHealthy(ctx, stopChan) {
+			return
+		}
+
 		log.Printf("[SCHEDULER] Sending webhook (attempt %d/%d)...", i+1, maxRetries)
-		err := s.dailyWebhook.SendDailyWebhook()
+		attempts++
+		if i == 0 {
+			err = s.dailyWebhook.SendDailyWebhook()
+		} else {
+			err = s.dailyWebhook.RetryDailyWebhook()
+		}
 		if err == nil {
 			log.Println("[SCHEDULER] Daily webhook sent successfully")
 			return
 		}
 
-		log.Printf("[SCHEDULER] Failed to send daily webhook (attempt %d/%d): %v", i+1, maxRetries, err)
+		slog.Warn("[SCHEDULER] Failed to send daily webhook", "attempt", i+1, "of", maxRetries, "error", err)
+
+		// Retrying a deleted webhook can't succeed, today or any other day.
+		// The daily webhook already stopped posting to it; it is only turned
+		// off once nothing is left to post to. Other destinations that
+		// failed are still retried below.
+		unknownWebhook := errors.Is(err, webhook.ErrUnknownWebhook)
+		if unknownWebhook {
+			if !s.dailyWebhook.HasDestination() {
+				s.disableUnknownWebhook()
+				return
+			}
+			s.alert(fmt.Sprintf("⚠️ Discord reports a daily webhook as deleted (Unknown Webhook), so it is skipped until the bot restarts. Remove it from `WEBHOOK_URL`. Errors of this send: %v", err))
+		}
+
+		if !s.dailyWebhook.Retryable() {
+			if unknownWebhook {
+				// The deleted webhook was the only failure, the alert covers it
+				return
+			}
+			log.Println("[SCHEDULER] No destination left to retry")
+			break
+		}
 
 		if i < maxRetries-1 {
 			// Wait before retrying (exponential backoff)
 			waitTime := time.Duration(i+1) * 5 * time.Minute
 			log.Printf("[SCHEDULER] Waiting %v before retry...", waitTime)
-			time.Sleep(waitTime)
+			select {
+			case <-time.After(waitTime):
+			case <-ctx.Done():
+				log.Println("[SCHEDULER] Retries cancelled")
+				return
+			case <-stopChan:
+				log.Println("[SCHEDULER] Retries cancelled, scheduler stopped")
+				return
+			}
+		}
+	}
+
+	slog.Error("[SCHEDULER] Failed to send daily webhook", "attempts", attempts)
+	s.alert(fmt.Sprintf("⚠️ The daily webhook failed after %d attempts: %v", attempts, err))
+
+	if errors.Is(err, webhook.ErrNoImages) {
+		if err := s.dailyWebhook.NotifyNoImages(); err != nil {
+			slog.Error("[SCHEDULER] Failed to post the no-images notice", "error", err)
+		}
+	}
+}
+
+// waitHealthy holds a send back while the Discord session is down,
+// rechecking every healthRecheck for at most maxHealthDeferral. It returns
+// false if the wait was cancelled.
+func (s *Scheduler) waitHealthy(ctx context.Context, stopChan <-chan struct{}) bool {
+	s.mutex.Lock()
+	healthy := s.healthy
+	s.mutex.Unlock()
+	if healthy == nil {
+		return true
+	}
+
+	deadline := time.Now().Add(maxHealthDeferral)
+	for !healthy() {
+		if time.Now().After(deadline) {
+			slog.Warn("[SCHEDULER] Discord session still down, sending anyway", "after", maxHealthDeferral)
+			return true
+		}
+
+		slog.Warn("[SCHEDULER] Discord session is down, deferring the daily webhook", "by", healthRecheck)
+		select {
+		case <-time.After(healthRecheck):
+		case <-ctx.Done():
+			log.Println("[SCHEDULER] Deferred send cancelled")
+			return false
+		case <-stopChan:
+			log.Println("[SCHEDULER] Deferred send cancelled, scheduler stopped")
+			return false
 		}
 	}
+	return true
+}
 
-	log.Printf("[SCHEDULER] Failed to send daily webhook after %d attempts", maxRetries)
+// disableUnknownWebhook turns the daily webhook off after Discord reported
+// it as deleted and tells the operators to set up a new one
+func (s *Scheduler) disableUnknownWebhook() {
+	slog.Warn("[SCHEDULER] Webhook no longer exists on Discord, disabling the daily webhook")
+	s.dailyWebhook.SetEnabled(false)
+	if err := s.storage.SetDailyWebhookEnabled(false); err != nil {
+		slog.Error("[SCHEDULER] Failed to save the disabled daily webhook", "error", err)
+	}
+	s.alert("🚫 Discord reports the daily webhook as deleted (Unknown Webhook), so the daily post was turned off. Set a new `WEBHOOK_URL`, restart the bot and turn it back on with `/webhook`.")
+}
+
+// alert reports a failed daily send through the alerter, if one is set
+func (s *Scheduler) alert(message string) {
+	s.mutex.Lock()
+	alerter := s.alerter
+	s.mutex.Unlock()
+
+	if alerter == nil {
+		return
+	}
+	if err := alerter(message); err != nil {
+		slog.Error("[SCHEDULER] Failed to send alert", "error", err)
+	}
+}
+
+// skipReason returns why the daily webhook should not be sent at now, or an
+// empty string if it should be sent. Pauses expire on their own.
+func (s *Scheduler) skipReason(now time.Time) string {
+	if until := s.storage.GetWebhookPausedUntil(); now.Before(until) {
+		return fmt.Sprintf("paused until %s", until.In(now.Location()).Format("2006-01-02 15:04"))
+	}
+	if slices.Contains(s.storage.GetWebhookSkipDays(), now.Weekday()) {
+		return fmt.Sprintf("%s is a skip day", now.Weekday())
+	}
+	return ""
 }
 
 // IsRunning returns whether the scheduler is currently running
@@ -181,12 +545,34 @@ func (s *Scheduler) IsRunning() bool {
 	return s.running
 }
 
+// Schedule returns a human readable description of when the daily webhook is sent
+func (s *Scheduler) Schedule() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	schedule := fmt.Sprintf("daily at %02d:%02d", s.sendHour, s.sendMinute)
+	if s.cron != nil {
+		schedule = fmt.Sprintf("on cron `%s`", s.cron)
+	}
+	if loc := location.Load(); loc != nil {
+		schedule += fmt.Sprintf(" (%s)", loc)
+	}
+	return schedule
+}
+
 // ForceSend forces sending a daily webhook immediately
 func (s *Scheduler) ForceSend() error {
 	if !s.dailyWebhook.IsEnabled() {
 		return fmt.Errorf("daily webhook is disabled")
 	}
+	if s.sending.Load() {
+		return ErrSendRunning
+	}
+
+	s.mutex.Lock()
+	stopChan := s.stopChan
+	s.mutex.Unlock()
 
-	go s.sendDailyWebhook()
+	go s.sendDailyWebhook(context.Background(), stopChan)
 	return nil
 }
@@ -0,0 +1,217 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"KawaiiBot/api"
+	"KawaiiBot/config"
+	"KawaiiBot/storage"
+	"KawaiiBot/webhook"
+)
+
+// newTestScheduler returns a scheduler with empty settings and a daily
+// webhook without destinations, sending at hour:minute
+func newTestScheduler(t *testing.T, hour, minute int) *Scheduler {
+	t.Helper()
+	st, err := storage.New(filepath.Join(t.TempDir(), "settings.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dw := webhook.New(nil, nil, config.Webhook{})
+	return New(dw, st, config.Webhook{SendHour: hour, SendMinute: minute, MaxRetries: 1, Workers: 1})
+}
+
+// waitArmed waits for the running timer to be armed for want
+func waitArmed(t *testing.T, s *Scheduler, want time.Time) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		s.mutex.Lock()
+		armed := s.armedAt
+		s.mutex.Unlock()
+		if armed.Equal(want) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timer armed for %v, want %v", armed, want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSetSendTimeRearmsTimer(t *testing.T) {
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	defer func(orig func() time.Time) { getTime = orig }(getTime)
+	getTime = func() time.Time { return now }
+
+	s := newTestScheduler(t, 6, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := s.Start(ctx, "UTC"); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+
+	waitArmed(t, s, time.Date(2026, 3, 3, 6, 0, 0, 0, time.UTC))
+
+	if err := s.SetSendTime(18, 30); err != nil {
+		t.Fatal(err)
+	}
+	waitArmed(t, s, time.Date(2026, 3, 2, 18, 30, 0, 0, time.UTC))
+}
+
+func TestGetTimeUntilNextSend(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, berlin)
+	}
+
+	tests := []struct {
+		name         string
+		now          time.Time
+		hour, minute int
+		want         time.Duration
+	}{
+		{"before the send time", at(time.June, 10, 5, 0), 6, 0, time.Hour},
+		{"after the send time", at(time.June, 10, 7, 0), 6, 0, 23 * time.Hour},
+		{"exactly at the send time", at(time.June, 10, 6, 0), 6, 0, 24 * time.Hour},
+		{"just before midnight", at(time.June, 10, 23, 59), 0, 0, time.Minute},
+		{"into spring forward", at(time.March, 28, 7, 0), 6, 0, 22 * time.Hour},
+		{"into fall back", at(time.October, 24, 7, 0), 6, 0, 24 * time.Hour},
+		{"send time in the skipped hour", at(time.March, 28, 23, 0), 2, 30, 3*time.Hour + 30*time.Minute},
+		{"on the fall back day", at(time.October, 25, 1, 0), 6, 0, 6 * time.Hour},
+		{"day after fall back", at(time.October, 25, 7, 0), 6, 0, 23 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Scheduler{sendHour: tt.hour, sendMinute: tt.minute}
+			if got := s.getTimeUntilNextSend(tt.now); got != tt.want {
+				t.Errorf("getTimeUntilNextSend(%v) = %v, want %v", tt.now, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStartStop(t *testing.T) {
+	// The daily webhook of newTestScheduler has no destination
+	s := newTestScheduler(t, 6, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := s.Stop(); err == nil {
+		t.Error("Stop before Start succeeded")
+	}
+	for round := 1; round <= 2; round++ {
+		if err := s.Start(ctx, "UTC"); err != nil {
+			t.Fatalf("Start #%d: %v", round, err)
+		}
+		if err := s.Start(ctx, "UTC"); err == nil {
+			t.Errorf("Start #%d while running succeeded", round)
+		}
+		if err := s.Stop(); err != nil {
+			t.Fatalf("Stop #%d: %v", round, err)
+		}
+	}
+	if err := s.Stop(); err == nil {
+		t.Error("Stop of a stopped scheduler succeeded")
+	}
+}
+
+// stubAPI routes every outgoing request of the default transport, which the
+// API clients and webhook posts use, to handler for the rest of the test
+func stubAPI(t *testing.T, handler http.HandlerFunc) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	target, _ := url.Parse(server.URL)
+
+	orig := http.DefaultTransport
+	http.DefaultTransport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		req = req.Clone(req.Context())
+		req.URL.Scheme, req.URL.Host = target.Scheme, target.Host
+		return orig.RoundTrip(req)
+	})
+	t.Cleanup(func() {
+		http.DefaultTransport = orig
+		server.Close()
+	})
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestSendDailyWebhookDisablesDeletedWebhook(t *testing.T) {
+	var posts atomic.Int32
+	stubAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/images":
+			json.NewEncoder(w).Encode(api.WaifuResponse{Items: []api.WaifuImage{{ID: 1, URL: "https://cdn.waifu.im/1.jpg"}}})
+		case "/api/webhooks/1/deleted":
+			posts.Add(1)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "Unknown Webhook", "code": 10015}`))
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	})
+
+	st, err := storage.New(filepath.Join(t.TempDir(), "settings.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.SetDailyWebhookEnabled(true); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.Webhook{
+		URLs:       []string{"https://discord.com/api/webhooks/1/deleted"},
+		Waifus:     1,
+		MaxRetries: 3,
+		Workers:    1,
+	}
+	dw := webhook.New(nil, api.NewWaifuClient("test"), cfg)
+	s := New(dw, st, cfg)
+	var alerts []string
+	s.SetAlerter(func(message string) error {
+		alerts = append(alerts, message)
+		return nil
+	})
+
+	s.sendDailyWebhook(context.Background(), make(chan struct{}))
+
+	if got := posts.Load(); got != 1 {
+		t.Errorf("posted to the deleted webhook %d times, want no retries", got)
+	}
+	if dw.IsEnabled() {
+		t.Error("the daily webhook is still enabled")
+	}
+	if st.GetDailyWebhookEnabled() {
+		t.Error("the disabled daily webhook wasn't saved")
+	}
+	if len(alerts) != 1 || !strings.Contains(alerts[0], "Unknown Webhook") {
+		t.Errorf("alerts = %q, want one about the deleted webhook", alerts)
+	}
+}
+
+func TestNowBeforeStart(t *testing.T) {
+	defer location.Store(location.Load())
+	location.Store(nil)
+
+	s := newTestScheduler(t, 6, 0)
+	if got := s.Now().Location(); got != time.UTC {
+		t.Errorf("Now before Start is in %v, want UTC", got)
+	}
+}
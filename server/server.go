@@ -0,0 +1,99 @@
+// Package server runs the optional HTTP API used to control the bot from
+// outside Discord, e.g. from an external cron
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// TokenHeader carries the shared secret on every request
+const TokenHeader = "X-KawaiiBot-Token"
+
+// Server is the HTTP API server
+type Server struct {
+	httpServer   *http.Server
+	token        string
+	triggerDaily func() error
+}
+
+// response is the JSON body of every reply
+type response struct {
+	OK      bool   `json:"ok"`
+	Message string `json:"message,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// New creates a server listening on addr. Requests must send token in the
+// TokenHeader header. triggerDaily starts a daily webhook send.
+func New(addr, token string, triggerDaily func() error) *Server {
+	s := &Server{
+		token:        token,
+		triggerDaily: triggerDaily,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /trigger/daily", s.authenticated(s.handleTriggerDaily))
+
+	s.httpServer = &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s
+}
+
+// Start serves requests in the background until Shutdown is called
+func (s *Server) Start() {
+	go func() {
+		log.Printf("[HTTP] Listening on %s", s.httpServer.Addr)
+		if err := s.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("[HTTP] Server failed", "error", err)
+		}
+	}()
+}
+
+// Shutdown stops the server, waiting for running requests until ctx is done
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.httpServer.Shutdown(ctx)
+}
+
+// authenticated rejects requests without the shared secret
+func (s *Server) authenticated(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get(TokenHeader)
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			slog.Warn("[HTTP] Rejected unauthenticated request", "method", r.Method, "path", r.URL.Path, "remote", r.RemoteAddr)
+			writeJSON(w, http.StatusUnauthorized, response{Error: "missing or invalid " + TokenHeader})
+			return
+		}
+		next(w, r)
+	}
+}
+
+// handleTriggerDaily starts a daily webhook send. The send runs in the
+// background, so success means it was started, not delivered.
+func (s *Server) handleTriggerDaily(w http.ResponseWriter, r *http.Request) {
+	if err := s.triggerDaily(); err != nil {
+		writeJSON(w, http.StatusConflict, response{Error: err.Error()})
+		return
+	}
+
+	log.Printf("[HTTP] Daily webhook triggered by %s", r.RemoteAddr)
+	writeJSON(w, http.StatusAccepted, response{OK: true, Message: "daily webhook send started"})
+}
+
+// writeJSON writes body as a JSON reply with the given status
+func writeJSON(w http.ResponseWriter, status int, body response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		slog.Warn("[HTTP] Failed to write response", "error", err)
+	}
+}
@@ -2,16 +2,66 @@
 package storage
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"sync"
+	"time"
 )
 
 // Settings represents the bot settings stored in the JSON file
 type Settings struct {
-	DailyWebhookEnabled bool `json:"daily_webhook_enabled"`
+	DailyWebhookEnabled bool                     `json:"daily_webhook_enabled"`
+	WebhookSkipDays     []time.Weekday           `json:"webhook_skip_days,omitempty"`
+	WebhookPausedUntil  time.Time                `json:"webhook_paused_until,omitzero"`
+	WebhookSnoozed      bool                     `json:"webhook_snoozed,omitempty"`
+	Guilds              map[string]GuildSettings `json:"guilds,omitempty"`
+	LifetimeStats       Stats                    `json:"lifetime_stats,omitzero"`
+	LastDailyPost       *DailyPost               `json:"last_daily_post,omitempty"`
+}
+
+// DailyPost is the last daily webhook message that was delivered
+type DailyPost struct {
+	SentAt  time.Time       `json:"sent_at"`
+	Payload json.RawMessage `json:"payload"` // the webhook payload as sent
+}
+
+// GuildSettings represents the settings of a single guild
+type GuildSettings struct {
+	NSFWGate           bool              `json:"nsfw_gate,omitempty"`
+	NSFWConfirmedUsers []string          `json:"nsfw_confirmed_users,omitempty"`
+	SuppressLinkEmbeds bool              `json:"suppress_link_embeds,omitempty"`
+	TrashReactions     bool              `json:"trash_reactions,omitempty"`
+	ImageCaptions      bool              `json:"image_captions,omitempty"`
+	StrictOptions      bool              `json:"strict_options,omitempty"` // reject invalid slash command option values
+	EmbedSize          string            `json:"embed_size,omitempty"`     // "thumbnail" or empty for full size
+	DefaultRating      string            `json:"default_rating,omitempty"` // rating when none is asked for, empty means safe
+	MaxSFWCount        int               `json:"max_sfw_count,omitempty"`  // 0 means the bot default
+	MaxNSFWCount       int               `json:"max_nsfw_count,omitempty"` // 0 means the bot default
+	Stats              Stats             `json:"stats,omitzero"`
+	StatsResetAt       time.Time         `json:"stats_reset_at,omitzero"`
+	DailySchedule      *GuildSchedule    `json:"daily_schedule,omitempty"`
+	Messages           map[string]string `json:"messages,omitempty"`   // custom message templates by name
+	RoleGates          map[string]string `json:"role_gates,omitempty"` // required role ID by command name, "nsfw" for all NSFW requests
+}
+
+// GuildSchedule is a guild's own daily post, sent by the bot to one of its
+// channels at the guild's local time
+type GuildSchedule struct {
+	ChannelID string `json:"channel_id"`
+	Hour      int    `json:"hour"`
+	Minute    int    `json:"minute"`
+	Timezone  string `json:"timezone,omitempty"` // IANA zone, empty means the bot's zone
+}
+
+// Stats counts the image requests served
+type Stats struct {
+	Requests int `json:"requests"`
+	Images   int `json:"images"`
 }
 
 // Storage handles persistent storage of bot settings
@@ -19,6 +69,8 @@ type Storage struct {
 	filename string
 	settings Settings
 	mutex    sync.RWMutex
+	saveMu   sync.Mutex // orders writes so an older snapshot never overwrites a newer one
+	dirty    bool       // counters changed since the last save, see Flush
 }
 
 // New creates a new Storage instance
@@ -30,6 +82,10 @@ func New(filename string) (*Storage, error) {
 		},
 	}
 
+	if err := checkPath(filename); err != nil {
+		return nil, err
+	}
+
 	// Try to load existing settings
 	if err := s.load(); err != nil {
 		// If file doesn't exist, create it with default settings
@@ -45,6 +101,33 @@ func New(filename string) (*Storage, error) {
 	return s, nil
 }
 
+// checkPath makes sure filename can hold the settings file. A directory at
+// that path, usually a misconfigured volume mount, or a read only parent
+// directory would otherwise only surface as confusing read or write errors.
+func checkPath(filename string) error {
+	if info, err := os.Stat(filename); err == nil && info.IsDir() {
+		return fmt.Errorf("settings path %s is a directory, not a file: set STORAGE_PATH to a file path or mount the directory that contains it", filename)
+	}
+
+	dir := filepath.Dir(filename)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create settings directory %s: %w", dir, err)
+	}
+
+	probe, err := os.CreateTemp(dir, ".write-check-*")
+	if err != nil {
+		return fmt.Errorf("settings directory %s is not writable: %w", dir, err)
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+	return nil
+}
+
+// CheckWritable reports whether the settings file can still be written
+func (s *Storage) CheckWritable() error {
+	return checkPath(s.filename)
+}
+
 // load reads settings from the JSON file
 func (s *Storage) load() error {
 	data, err := os.ReadFile(s.filename)
@@ -64,29 +147,75 @@ func (s *Storage) load() error {
 	return nil
 }
 
-// save writes settings to the JSON file
+// save writes settings to the JSON file. The file is replaced in one rename,
+// so a crash or a full disk mid-write leaves the previous settings intact.
 func (s *Storage) save() error {
-	s.mutex.RLock()
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+
+	s.mutex.Lock()
 	data, err := json.MarshalIndent(s.settings, "", "  ")
-	s.mutex.RUnlock()
+	s.dirty = false
+	s.mutex.Unlock()
 	if err != nil {
 		return fmt.Errorf("failed to marshal settings: %w", err)
 	}
 
-	// ✅ Create parent directory if it doesn't exist
+	if err := s.writeFile(data); err != nil {
+		// Keep the counters of this snapshot for the next flush
+		s.mutex.Lock()
+		s.dirty = true
+		s.mutex.Unlock()
+		return err
+	}
+	return nil
+}
+
+// writeFile replaces the settings file with data through a temporary file in
+// the same directory
+func (s *Storage) writeFile(data []byte) error {
 	dir := filepath.Dir(s.filename)
-	if dir != "" && dir != "." {
-		if err := os.MkdirAll(dir, 0o755); err != nil {
-			return fmt.Errorf("failed to create directory %s: %w", dir, err)
-		}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
 	}
 
-	if err := os.WriteFile(s.filename, data, 0o644); err != nil {
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(s.filename)+"-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary settings file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // fails harmlessly once renamed
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0o644)
+	}
+	if err != nil {
 		return fmt.Errorf("failed to write settings file: %w", err)
 	}
+
+	if err := os.Rename(tmp.Name(), s.filename); err != nil {
+		return fmt.Errorf("failed to replace settings file: %w", err)
+	}
 	return nil
 }
 
+// Flush saves counters recorded since the last save, if any
+func (s *Storage) Flush() error {
+	s.mutex.RLock()
+	dirty := s.dirty
+	s.mutex.RUnlock()
+	if !dirty {
+		return nil
+	}
+	return s.save()
+}
+
 // GetDailyWebhookEnabled returns whether the daily webhook is enabled
 func (s *Storage) GetDailyWebhookEnabled() bool {
 	s.mutex.RLock()
@@ -97,13 +226,18 @@ func (s *Storage) GetDailyWebhookEnabled() bool {
 // SetDailyWebhookEnabled sets whether the daily webhook is enabled
 func (s *Storage) SetDailyWebhookEnabled(enabled bool) error {
 	s.mutex.Lock()
+	changed := s.settings.DailyWebhookEnabled != enabled
 	s.settings.DailyWebhookEnabled = enabled
 	s.mutex.Unlock()
 
+	if !changed {
+		return nil
+	}
 	return s.save()
 }
 
-// ToggleDailyWebhookEnabled toggles the daily webhook enabled status
+// ToggleDailyWebhookEnabled toggles the daily webhook enabled status and
+// returns the new state. The toggle is undone if it can't be saved.
 func (s *Storage) ToggleDailyWebhookEnabled() (bool, error) {
 	s.mutex.Lock()
 	s.settings.DailyWebhookEnabled = !s.settings.DailyWebhookEnabled
@@ -111,12 +245,101 @@ func (s *Storage) ToggleDailyWebhookEnabled() (bool, error) {
 	s.mutex.Unlock()
 
 	if err := s.save(); err != nil {
-		return false, err
+		s.mutex.Lock()
+		s.settings.DailyWebhookEnabled = !newState
+		s.mutex.Unlock()
+		return !newState, err
 	}
 
 	return newState, nil
 }
 
+// GetWebhookSkipDays returns the weekdays the daily webhook is not sent on
+func (s *Storage) GetWebhookSkipDays() []time.Weekday {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return slices.Clone(s.settings.WebhookSkipDays)
+}
+
+// SetWebhookSkipDays sets the weekdays the daily webhook is not sent on
+func (s *Storage) SetWebhookSkipDays(days []time.Weekday) error {
+	s.mutex.Lock()
+	s.settings.WebhookSkipDays = slices.Clone(days)
+	s.mutex.Unlock()
+
+	return s.save()
+}
+
+// GetWebhookPausedUntil returns the time until which the daily webhook is paused
+func (s *Storage) GetWebhookPausedUntil() time.Time {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.settings.WebhookPausedUntil
+}
+
+// SetWebhookPausedUntil pauses the daily webhook until the given time, a zero time resumes it
+func (s *Storage) SetWebhookPausedUntil(until time.Time) error {
+	s.mutex.Lock()
+	s.settings.WebhookPausedUntil = until
+	s.mutex.Unlock()
+
+	return s.save()
+}
+
+// GetWebhookSnoozed returns whether the next daily send is snoozed
+func (s *Storage) GetWebhookSnoozed() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.settings.WebhookSnoozed
+}
+
+// SetWebhookSnoozed sets whether the next daily send is snoozed
+func (s *Storage) SetWebhookSnoozed(snoozed bool) error {
+	s.mutex.Lock()
+	changed := s.settings.WebhookSnoozed != snoozed
+	s.settings.WebhookSnoozed = snoozed
+	s.mutex.Unlock()
+
+	if !changed {
+		return nil
+	}
+	return s.save()
+}
+
+// GetLastDailyPost returns the last delivered daily post, or nil if none was
+// recorded yet
+func (s *Storage) GetLastDailyPost() *DailyPost {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if s.settings.LastDailyPost == nil {
+		return nil
+	}
+	post := *s.settings.LastDailyPost
+	return &post
+}
+
+// SetLastDailyPost records the daily post that was just delivered
+func (s *Storage) SetLastDailyPost(post DailyPost) error {
+	s.mutex.Lock()
+	s.settings.LastDailyPost = &post
+	s.mutex.Unlock()
+
+	return s.save()
+}
+
+// TakeWebhookSnoozed clears the snooze and returns whether it was set
+func (s *Storage) TakeWebhookSnoozed() (bool, error) {
+	s.mutex.Lock()
+	snoozed := s.settings.WebhookSnoozed
+	s.settings.WebhookSnoozed = false
+	s.mutex.Unlock()
+
+	if !snoozed {
+		return false, nil
+	}
+	return true, s.save()
+}
+
 // GetAllSettings returns all settings
 func (s *Storage) GetAllSettings() Settings {
 	s.mutex.RLock()
@@ -124,3 +347,210 @@ func (s *Storage) GetAllSettings() Settings {
 	return s.settings
 }
 
+// GetGuildSettings returns the settings of a guild, or defaults if none are stored
+func (s *Storage) GetGuildSettings(guildID string) GuildSettings {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	guild := s.settings.Guilds[guildID]
+	guild.NSFWConfirmedUsers = slices.Clone(guild.NSFWConfirmedUsers)
+	guild.Messages = maps.Clone(guild.Messages)
+	guild.RoleGates = maps.Clone(guild.RoleGates)
+	return guild
+}
+
+// updateGuild applies update to a guild's settings and saves them, unless
+// the update changed nothing
+func (s *Storage) updateGuild(guildID string, update func(*GuildSettings)) error {
+	s.mutex.Lock()
+	if s.settings.Guilds == nil {
+		s.settings.Guilds = make(map[string]GuildSettings)
+	}
+	guild := s.settings.Guilds[guildID]
+	// Compare encoded, the maps and slices of guild are shared with the update
+	before, _ := json.Marshal(guild)
+	update(&guild)
+	after, _ := json.Marshal(guild)
+	changed := !bytes.Equal(before, after)
+	if changed {
+		s.settings.Guilds[guildID] = guild
+	}
+	s.mutex.Unlock()
+
+	if !changed {
+		return nil
+	}
+	return s.save()
+}
+
+// SetNSFWGate sets whether NSFW requests in a guild require a one-time confirmation
+func (s *Storage) SetNSFWGate(guildID string, enabled bool) error {
+	return s.updateGuild(guildID, func(guild *GuildSettings) {
+		guild.NSFWGate = enabled
+	})
+}
+
+// SetSuppressLinkEmbeds sets whether URL fallback messages in a guild hide link previews
+func (s *Storage) SetSuppressLinkEmbeds(guildID string, suppress bool) error {
+	return s.updateGuild(guildID, func(guild *GuildSettings) {
+		guild.SuppressLinkEmbeds = suppress
+	})
+}
+
+// SetImageCaptions sets whether multi-image posts in a guild number each
+// picture with its ID
+func (s *Storage) SetImageCaptions(guildID string, enabled bool) error {
+	return s.updateGuild(guildID, func(guild *GuildSettings) {
+		guild.ImageCaptions = enabled
+	})
+}
+
+// SetEmbedSize sets how large the pictures of embeds the bot posts in a guild
+// are shown, "thumbnail" or empty for full size
+func (s *Storage) SetEmbedSize(guildID, size string) error {
+	return s.updateGuild(guildID, func(guild *GuildSettings) {
+		guild.EmbedSize = size
+	})
+}
+
+// SetDefaultRating sets the rating picture commands in a guild use when the
+// user doesn't pick one
+func (s *Storage) SetDefaultRating(guildID, rating string) error {
+	return s.updateGuild(guildID, func(guild *GuildSettings) {
+		guild.DefaultRating = rating
+	})
+}
+
+// SetStrictOptions sets whether slash commands in a guild reject invalid
+// option values instead of falling back to their defaults
+func (s *Storage) SetStrictOptions(guildID string, strict bool) error {
+	return s.updateGuild(guildID, func(guild *GuildSettings) {
+		guild.StrictOptions = strict
+	})
+}
+
+// SetTrashReactions sets whether picture messages in a guild can be deleted
+// by reacting with a trash emoji
+func (s *Storage) SetTrashReactions(guildID string, enabled bool) error {
+	return s.updateGuild(guildID, func(guild *GuildSettings) {
+		guild.TrashReactions = enabled
+	})
+}
+
+// SetMessageTemplate sets a guild's template for a bot message, an empty
+// template restores the default
+func (s *Storage) SetMessageTemplate(guildID, name, template string) error {
+	return s.updateGuild(guildID, func(guild *GuildSettings) {
+		messages := maps.Clone(guild.Messages)
+		if template == "" {
+			delete(messages, name)
+		} else {
+			if messages == nil {
+				messages = make(map[string]string)
+			}
+			messages[name] = template
+		}
+		guild.Messages = messages
+	})
+}
+
+// SetRoleGate sets the role a guild requires for a command, an empty role ID
+// removes the requirement
+func (s *Storage) SetRoleGate(guildID, command, roleID string) error {
+	return s.updateGuild(guildID, func(guild *GuildSettings) {
+		gates := maps.Clone(guild.RoleGates)
+		if roleID == "" {
+			delete(gates, command)
+		} else {
+			if gates == nil {
+				gates = make(map[string]string)
+			}
+			gates[command] = roleID
+		}
+		guild.RoleGates = gates
+	})
+}
+
+// SetCountLimits sets the most SFW and NSFW pictures per command in a guild
+func (s *Storage) SetCountLimits(guildID string, sfw, nsfw int) error {
+	return s.updateGuild(guildID, func(guild *GuildSettings) {
+		guild.MaxSFWCount = sfw
+		guild.MaxNSFWCount = nsfw
+	})
+}
+
+// SetGuildSchedule sets a guild's daily schedule. nil removes it.
+func (s *Storage) SetGuildSchedule(guildID string, schedule *GuildSchedule) error {
+	return s.updateGuild(guildID, func(guild *GuildSettings) {
+		guild.DailySchedule = schedule
+	})
+}
+
+// GetGuildSchedules returns the daily schedules of all guilds that have one
+func (s *Storage) GetGuildSchedules() map[string]GuildSchedule {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	schedules := make(map[string]GuildSchedule)
+	for guildID, guild := range s.settings.Guilds {
+		if guild.DailySchedule != nil {
+			schedules[guildID] = *guild.DailySchedule
+		}
+	}
+	return schedules
+}
+
+// IsNSFWConfirmed returns whether a user has passed the NSFW gate in a guild
+func (s *Storage) IsNSFWConfirmed(guildID, userID string) bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return slices.Contains(s.settings.Guilds[guildID].NSFWConfirmedUsers, userID)
+}
+
+// ConfirmNSFW records that a user has passed the NSFW gate in a guild
+func (s *Storage) ConfirmNSFW(guildID, userID string) error {
+	return s.updateGuild(guildID, func(guild *GuildSettings) {
+		if !slices.Contains(guild.NSFWConfirmedUsers, userID) {
+			guild.NSFWConfirmedUsers = append(guild.NSFWConfirmedUsers, userID)
+		}
+	})
+}
+
+// RecordServed counts a served image request for a guild and the lifetime
+// totals. DMs have no guild ID and only count towards the lifetime totals.
+// The counters are only kept in memory until the next save or Flush, so
+// busy guilds don't rewrite the settings file on every request.
+func (s *Storage) RecordServed(guildID string, images int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.settings.LifetimeStats.Requests++
+	s.settings.LifetimeStats.Images += images
+	if guildID != "" {
+		if s.settings.Guilds == nil {
+			s.settings.Guilds = make(map[string]GuildSettings)
+		}
+		guild := s.settings.Guilds[guildID]
+		guild.Stats.Requests++
+		guild.Stats.Images += images
+		s.settings.Guilds[guildID] = guild
+	}
+	s.dirty = true
+}
+
+// GetLifetimeStats returns the totals across all guilds, unaffected by resets
+func (s *Storage) GetLifetimeStats() Stats {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.settings.LifetimeStats
+}
+
+// ResetGuildStats zeroes a guild's counters and returns the previous values
+func (s *Storage) ResetGuildStats(guildID string) (Stats, error) {
+	var previous Stats
+	err := s.updateGuild(guildID, func(guild *GuildSettings) {
+		previous = guild.Stats
+		guild.Stats = Stats{}
+		guild.StatsResetAt = time.Now()
+	})
+	return previous, err
+}
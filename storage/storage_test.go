@@ -0,0 +1,190 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestToggleDailyWebhookEnabledConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+	s, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const toggles = 51
+	results := make(chan bool, toggles)
+	var wg sync.WaitGroup
+	for range toggles {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			state, err := s.ToggleDailyWebhookEnabled()
+			if err != nil {
+				t.Error(err)
+			}
+			results <- state
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	// Every toggle flips the state exactly once, so the results alternate
+	enabled := 0
+	for state := range results {
+		if state {
+			enabled++
+		}
+	}
+	if enabled != toggles/2+1 {
+		t.Errorf("%d of %d toggles returned enabled, want %d", enabled, toggles, toggles/2+1)
+	}
+	if !s.GetDailyWebhookEnabled() {
+		t.Error("an odd number of toggles left the webhook disabled")
+	}
+
+	// The last write wins on disk too
+	reloaded, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reloaded.GetDailyWebhookEnabled() {
+		t.Error("the saved settings lost the final toggle")
+	}
+}
+
+func TestNewRejectsUnusablePath(t *testing.T) {
+	t.Run("directory at the settings path", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "bot_settings.json")
+		if err := os.Mkdir(path, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		_, err := New(path)
+		if err == nil || !strings.Contains(err.Error(), "is a directory") {
+			t.Errorf("New = %v, want an error saying the path is a directory", err)
+		}
+	})
+
+	t.Run("read only directory", func(t *testing.T) {
+		if os.Geteuid() == 0 {
+			t.Skip("root can write to read only directories")
+		}
+		dir := t.TempDir()
+		if err := os.Chmod(dir, 0o555); err != nil {
+			t.Fatal(err)
+		}
+		defer os.Chmod(dir, 0o755)
+
+		_, err := New(filepath.Join(dir, "bot_settings.json"))
+		if err == nil || !strings.Contains(err.Error(), "not writable") {
+			t.Errorf("New = %v, want an error saying the directory is not writable", err)
+		}
+	})
+
+	t.Run("missing parent directory", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "data", "bot_settings.json")
+		if _, err := New(path); err != nil {
+			t.Fatalf("New = %v, want the directory created", err)
+		}
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("settings file wasn't created: %v", err)
+		}
+	})
+}
+
+func TestRecordServedFlush(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+	s, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, _ := os.ReadFile(path)
+
+	s.RecordServed("g", 3)
+	s.RecordServed("", 1)
+	if after, _ := os.ReadFile(path); string(after) != string(before) {
+		t.Error("recording a request rewrote the settings file")
+	}
+
+	if err := s.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reloaded.GetLifetimeStats(); got != (Stats{Requests: 2, Images: 4}) {
+		t.Errorf("lifetime stats after reload = %+v, want 2 requests and 4 images", got)
+	}
+	if got := reloaded.GetGuildSettings("g").Stats; got != (Stats{Requests: 1, Images: 3}) {
+		t.Errorf("guild stats after reload = %+v, want 1 request and 3 images", got)
+	}
+
+	// Any other save takes the counters along, leaving nothing to flush
+	s.RecordServed("g", 1)
+	if err := s.SetDailyWebhookEnabled(true); err != nil {
+		t.Fatal(err)
+	}
+	saved, _ := os.ReadFile(path)
+	if err := s.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if flushed, _ := os.ReadFile(path); string(flushed) != string(saved) {
+		t.Error("Flush rewrote counters that were already saved")
+	}
+}
+
+func TestSaveReplacesFileAtomically(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "settings.json")
+	s, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A failed write leaves the previous file and counters alone
+	if err := s.SetDailyWebhookEnabled(true); err != nil {
+		t.Fatal(err)
+	}
+	good, _ := os.ReadFile(path)
+	s.RecordServed("g", 1)
+	// Renaming the new file over a directory fails after it was written
+	taken := filepath.Join(dir, "taken")
+	if err := os.MkdirAll(filepath.Join(taken, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	s.filename = taken
+	if err := s.Flush(); err == nil {
+		t.Fatal("Flush over a directory succeeded")
+	}
+	s.filename = path
+	if got, _ := os.ReadFile(path); string(got) != string(good) {
+		t.Error("a failed save changed the settings file")
+	}
+	if err := s.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.GetGuildSettings("g").Stats.Requests; got != 1 {
+		t.Errorf("%d requests counted, want the failed flush retried", got)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.Name() != "settings.json" && entry.Name() != "taken" {
+			t.Errorf("leftover file %s after saving", entry.Name())
+		}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o644 {
+		t.Errorf("settings file mode %v, want 0644", perm)
+	}
+}
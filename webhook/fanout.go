@@ -0,0 +1,81 @@
+package webhook
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// destinationAttempts is how often one message is tried per destination
+// before the destination counts as failed
+const destinationAttempts = 2
+
+// destinationBackoff is the wait before a message is tried again
+var destinationBackoff = 5 * time.Second
+
+// destination is one place a daily payload is delivered to
+type destination struct {
+	key  string // identifies the destination across retries of one delivery
+	name string
+	send func(WebhookPayload) error
+}
+
+// fanOut sends the parts of a split payload to every destination, running at
+// most workers destinations at once. A destination starts at its part in
+// from, 0 if it has none. Each part is retried on its own, and the returned
+// failed map holds the first part every failed destination is still missing,
+// so retrying the delivery never repeats a message that already went out.
+// A deleted webhook is left out of failed, since retrying it can't succeed.
+// The errors of all failed destinations are combined; sent reports whether
+// any destination received a message.
+func fanOut(workers int, destinations []destination, parts []WebhookPayload, from map[string]int) (sent bool, failed map[string]int, err error) {
+	var (
+		wg    sync.WaitGroup
+		mutex sync.Mutex
+		errs  []error
+	)
+	failed = make(map[string]int)
+	slots := make(chan struct{}, max(workers, 1))
+
+	for _, dest := range destinations {
+		wg.Go(func() {
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			start := from[dest.key]
+			next, err := sendParts(parts, start, func(part WebhookPayload) error {
+				return retryDestination(dest, part)
+			})
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			sent = sent || next > start
+			if err != nil {
+				errs = append(errs, err)
+				if !errors.Is(err, ErrUnknownWebhook) {
+					failed[dest.key] = next
+				}
+			}
+		})
+	}
+
+	wg.Wait()
+	return sent, failed, errors.Join(errs...)
+}
+
+// retryDestination sends one message to a destination, trying again after
+// destinationBackoff if it fails. A deleted webhook isn't retried.
+func retryDestination(dest destination, part WebhookPayload) error {
+	var err error
+	for attempt := 1; attempt <= destinationAttempts; attempt++ {
+		if err = dest.send(part); err == nil || errors.Is(err, ErrUnknownWebhook) {
+			return err
+		}
+		if attempt < destinationAttempts {
+			slog.Warn("[WEBHOOK] Sending failed, retrying", "destination", dest.name, "attempt", attempt, "of", destinationAttempts, "retry_in", destinationBackoff, "error", err)
+			time.Sleep(destinationBackoff)
+		}
+	}
+	return err
+}
@@ -0,0 +1,101 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"KawaiiBot/config"
+)
+
+func TestFanOutBoundsConcurrency(t *testing.T) {
+	const workers = 2
+	var running, peak atomic.Int32
+
+	var destinations []destination
+	for n := range 6 {
+		destinations = append(destinations, destination{
+			key:  fmt.Sprint(n),
+			name: fmt.Sprintf("dest %d", n),
+			send: func(WebhookPayload) error {
+				now := running.Add(1)
+				defer running.Add(-1)
+				for {
+					old := peak.Load()
+					if now <= old || peak.CompareAndSwap(old, now) {
+						break
+					}
+				}
+				time.Sleep(20 * time.Millisecond)
+				return nil
+			},
+		})
+	}
+
+	sent, failed, err := fanOut(workers, destinations, []WebhookPayload{{Content: "hi"}}, nil)
+	if !sent || len(failed) != 0 || err != nil {
+		t.Fatalf("fanOut = %v, %v, %v; want a clean send", sent, failed, err)
+	}
+	if got := peak.Load(); got != workers {
+		t.Errorf("at most %d destinations ran at once, want %d", got, workers)
+	}
+}
+
+func TestRetryDailyWebhookResendsOnlyWhatFailed(t *testing.T) {
+	defer func(backoff time.Duration) { destinationBackoff = backoff }(destinationBackoff)
+	destinationBackoff = 0
+
+	var mutex sync.Mutex
+	posts := map[string]int{}
+	flakyFailures := destinationAttempts // fail every attempt of the first send
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		switch r.URL.Path {
+		case "/gone":
+			w.WriteHeader(http.StatusNotFound)
+			return
+		case "/flaky":
+			if flakyFailures > 0 {
+				flakyFailures--
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+		posts[r.URL.Path]++
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	dw := New(nil, nil, config.Webhook{
+		URLs:    []string{server.URL + "/ok", server.URL + "/flaky", server.URL + "/gone"},
+		Workers: 3,
+	})
+	parts := []WebhookPayload{{Content: "1"}, {Content: "2"}}
+
+	err := dw.send(&pendingDelivery{parts: parts}, nil)
+	if err == nil || !errors.Is(err, ErrUnknownWebhook) {
+		t.Fatalf("send error = %v, want the failures of flaky and gone", err)
+	}
+	if !dw.Retryable() {
+		t.Fatal("flaky failed, but nothing is left to retry")
+	}
+	if _, urls := dw.GetStatus(); urls != 2 {
+		t.Errorf("%d webhook URLs left, want the deleted one dropped", urls)
+	}
+
+	if err := dw.RetryDailyWebhook(); err != nil {
+		t.Fatalf("retry failed: %v", err)
+	}
+	if dw.Retryable() {
+		t.Error("everything was delivered, but a retry is still pending")
+	}
+	if posts["/ok"] != len(parts) || posts["/flaky"] != len(parts) {
+		t.Errorf("posts = %v, want every webhook to get each message exactly once", posts)
+	}
+}
@@ -0,0 +1,174 @@
+package webhook
+
+import (
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"KawaiiBot/api"
+)
+
+// maxImageAttempts bounds how often a provider is asked for a replacement
+// when its image URL is missing or unreachable
+const maxImageAttempts = 3
+
+// allowedTagsBatch is how many images are requested per attempt while
+// WEBHOOK_ALLOWED_TAGS filters them
+const allowedTagsBatch = 10
+
+// headClient checks that image URLs are reachable before they are embedded
+var headClient = &http.Client{Timeout: 10 * time.Second}
+
+// imageReachable reports whether an image URL answers a HEAD request. Discord
+// silently drops embed images it can't load.
+func imageReachable(url string) bool {
+	resp, err := headClient.Head(url)
+	if err != nil {
+		slog.Warn("[WEBHOOK] Image is unreachable", "url", url, "error", err)
+		return false
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		slog.Warn("[WEBHOOK] Image returned an error status", "url", url, "status", resp.StatusCode)
+		return false
+	}
+	return true
+}
+
+// fetchWaifu fetches count random waifu images (mixed SFW/NSFW) with
+// reachable URLs and allowed tags. It returns fewer if not enough could be
+// found, and nil without asking waifu.im when count is 0.
+func (dw *DailyWebhook) fetchWaifu(count int) []api.WaifuImage {
+	if count == 0 {
+		return nil
+	}
+
+	var found []api.WaifuImage
+	for attempt := 1; attempt <= maxImageAttempts; attempt++ {
+		log.Printf("[WEBHOOK] Fetching %d random waifu image(s) (attempt %d/%d)...", count-len(found), attempt, maxImageAttempts)
+		images, err := dw.waifuAPI.GetWaifuImages(api.NSFWModeAll, dw.batchSize(count-len(found)))
+		if err != nil {
+			slog.Warn("[WEBHOOK] Failed to fetch waifu image", "error", err)
+			return found
+		}
+		if len(images) == 0 {
+			log.Println("[WEBHOOK] No waifu image returned")
+			return found
+		}
+
+		for _, img := range images {
+			if slices.ContainsFunc(found, func(f api.WaifuImage) bool { return f.ID == img.ID }) {
+				continue
+			}
+			if !dw.waifuTagsAllowed(img.Tags) {
+				log.Printf("[WEBHOOK] Skipping waifu image %d with tags outside WEBHOOK_ALLOWED_TAGS", img.ID)
+				continue
+			}
+			if img.URL == "" {
+				// An image without a URL can't be embedded
+				log.Printf("[WEBHOOK] Skipping waifu image %d without a URL", img.ID)
+				continue
+			}
+			if !imageReachable(img.URL) {
+				continue
+			}
+
+			slog.Debug("[WEBHOOK] Waifu image details",
+				"id", img.ID, "url", img.URL, "extension", img.Extension, "nsfw", img.IsNSFW)
+			if found = append(found, img); len(found) == count {
+				return found
+			}
+		}
+	}
+
+	slog.Warn("[WEBHOOK] Not enough suitable waifu images", "found", len(found), "wanted", count, "attempts", maxImageAttempts)
+	return found
+}
+
+// fetchCatgirl fetches count random catgirl images (mixed SFW/NSFW) with
+// reachable URLs and allowed tags. It returns fewer if not enough could be
+// found, and nil without asking nekos.moe when count is 0.
+func (dw *DailyWebhook) fetchCatgirl(count int) []api.Image {
+	if count == 0 {
+		return nil
+	}
+
+	var found []api.Image
+	for attempt := 1; attempt <= maxImageAttempts; attempt++ {
+		log.Printf("[WEBHOOK] Fetching %d random catgirl image(s) (attempt %d/%d)...", count-len(found), attempt, maxImageAttempts)
+		images, err := dw.nekosAPI.GetRandomImages(dw.batchSize(count-len(found)), "")
+		if err != nil {
+			slog.Warn("[WEBHOOK] Failed to fetch catgirl image", "error", err)
+			return found
+		}
+		if len(images) == 0 {
+			log.Println("[WEBHOOK] No catgirl image returned")
+			return found
+		}
+
+		for _, img := range images {
+			if slices.ContainsFunc(found, func(f api.Image) bool { return f.ID == img.ID }) {
+				continue
+			}
+			if !dw.tagsAllowed(img.Tags) {
+				log.Printf("[WEBHOOK] Skipping catgirl image %s with tags outside WEBHOOK_ALLOWED_TAGS", img.ID)
+				continue
+			}
+			catgirlURL := fmt.Sprintf("https://nekos.moe/image/%s.jpg", img.ID)
+			if !imageReachable(catgirlURL) {
+				continue
+			}
+
+			slog.Debug("[WEBHOOK] Catgirl image details",
+				"id", img.ID, "url", catgirlURL, "nsfw", img.NSFW)
+			if found = append(found, img); len(found) == count {
+				return found
+			}
+		}
+	}
+
+	slog.Warn("[WEBHOOK] Not enough suitable catgirl images", "found", len(found), "wanted", count, "attempts", maxImageAttempts)
+	return found
+}
+
+// batchSize is how many images are requested per attempt while missing more
+// images are still needed
+func (dw *DailyWebhook) batchSize(missing int) int {
+	if dw.allowedTags != nil {
+		return max(missing, allowedTagsBatch)
+	}
+	return missing
+}
+
+// tagAllowed reports whether a tag is in WEBHOOK_ALLOWED_TAGS
+func (dw *DailyWebhook) tagAllowed(tag string) bool {
+	return dw.allowedTags[strings.ToLower(strings.TrimSpace(tag))]
+}
+
+// tagsAllowed reports whether every tag is allowed. Without an allow-list
+// every image passes; with one, untagged images are rejected since nothing
+// vouches for their content.
+func (dw *DailyWebhook) tagsAllowed(tags []string) bool {
+	if dw.allowedTags == nil {
+		return true
+	}
+	return len(tags) > 0 && !slices.ContainsFunc(tags, func(tag string) bool {
+		return !dw.tagAllowed(tag)
+	})
+}
+
+// waifuTagsAllowed is tagsAllowed for waifu.im tags, which may be listed by
+// either their name or their slug
+func (dw *DailyWebhook) waifuTagsAllowed(tags []api.Tag) bool {
+	if dw.allowedTags == nil {
+		return true
+	}
+	return len(tags) > 0 && !slices.ContainsFunc(tags, func(tag api.Tag) bool {
+		return !dw.tagAllowed(tag.Name) && !dw.tagAllowed(tag.Slug)
+	})
+}
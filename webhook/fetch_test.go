@@ -0,0 +1,117 @@
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"KawaiiBot/api"
+	"KawaiiBot/config"
+)
+
+func TestFetchWaifuReplacesDeadImages(t *testing.T) {
+	var searches atomic.Int32
+	stubAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/images":
+			// The first search only finds a picture whose URL is dead
+			id := int64(searches.Add(1))
+			url := "https://cdn.waifu.im/live.jpg"
+			if id == 1 {
+				url = "https://cdn.waifu.im/dead.jpg"
+			}
+			json.NewEncoder(w).Encode(api.WaifuResponse{Items: []api.WaifuImage{{ID: id, URL: url}}})
+		case "/dead.jpg":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	})
+
+	dw := New(nil, api.NewWaifuClient("test"), config.Webhook{})
+	images := dw.fetchWaifu(1)
+	if len(images) != 1 || images[0].URL != "https://cdn.waifu.im/live.jpg" {
+		t.Fatalf("got %+v, want only the reachable replacement", images)
+	}
+	if got := searches.Load(); got != 2 {
+		t.Errorf("searched %d times, want a single refetch", got)
+	}
+}
+
+func TestFetchWaifuGivesUpOnDeadImages(t *testing.T) {
+	var searches atomic.Int32
+	stubAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/images" {
+			id := int64(searches.Add(1))
+			json.NewEncoder(w).Encode(api.WaifuResponse{Items: []api.WaifuImage{{ID: id, URL: "https://cdn.waifu.im/dead.jpg"}}})
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	dw := New(nil, api.NewWaifuClient("test"), config.Webhook{})
+	if images := dw.fetchWaifu(1); len(images) != 0 {
+		t.Errorf("got %+v, want no unreachable image embedded", images)
+	}
+	if got := searches.Load(); got != maxImageAttempts {
+		t.Errorf("searched %d times, want %d attempts", got, maxImageAttempts)
+	}
+}
+
+func TestFetchOnlyAllowedTags(t *testing.T) {
+	var searches atomic.Int32
+	stubAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/images":
+			// The first batch has nothing fully inside the allow-list
+			if searches.Add(1) == 1 {
+				json.NewEncoder(w).Encode(api.WaifuResponse{Items: []api.WaifuImage{
+					{ID: 1, URL: "https://cdn.waifu.im/1.jpg", Tags: []api.Tag{{Name: "Maid"}, {Name: "Ero"}}},
+					{ID: 2, URL: "https://cdn.waifu.im/2.jpg"},
+				}})
+				return
+			}
+			json.NewEncoder(w).Encode(api.WaifuResponse{Items: []api.WaifuImage{
+				{ID: 3, URL: "https://cdn.waifu.im/3.jpg", Tags: []api.Tag{{Name: "Uniform"}, {Name: "Ero"}}},
+				{ID: 4, URL: "https://cdn.waifu.im/4.jpg", Tags: []api.Tag{{Name: "Maid"}, {Name: "Uniform", Slug: "uniform"}}},
+			}})
+		case strings.HasSuffix(r.URL.Path, "/random/image"):
+			json.NewEncoder(w).Encode(api.RandomImageResponse{Images: []api.Image{
+				{ID: "mixed", Tags: []string{"cat ears", "nsfw"}},
+				{ID: "untagged"},
+				{ID: "allowed", Tags: []string{"Cat Ears", " maid "}},
+			}})
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	})
+
+	dw := New(api.New("test"), api.NewWaifuClient("test"), config.Webhook{
+		AllowedTags: []string{"maid", "uniform", "cat ears"},
+	})
+
+	waifus := dw.fetchWaifu(1)
+	if len(waifus) != 1 || waifus[0].ID != 4 {
+		t.Errorf("got waifu images %+v, want only image 4 whose tags are all allowed", waifus)
+	}
+	if got := searches.Load(); got != 2 {
+		t.Errorf("searched waifu.im %d times, want a refetch after the first batch", got)
+	}
+
+	catgirls := dw.fetchCatgirl(1)
+	if len(catgirls) != 1 || catgirls[0].ID != "allowed" {
+		t.Errorf("got catgirl images %+v, want only the one whose tags are all allowed", catgirls)
+	}
+}
+
+func TestTagsAllowedWithoutAllowList(t *testing.T) {
+	dw := New(nil, nil, config.Webhook{})
+	if !dw.tagsAllowed(nil) || !dw.tagsAllowed([]string{"anything"}) {
+		t.Error("an image was rejected without WEBHOOK_ALLOWED_TAGS")
+	}
+	if !dw.waifuTagsAllowed(nil) {
+		t.Error("an untagged waifu image was rejected without WEBHOOK_ALLOWED_TAGS")
+	}
+}
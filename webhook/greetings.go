@@ -0,0 +1,24 @@
+package webhook
+
+import "time"
+
+// defaultGreeting opens the daily post when no greetings are configured
+const defaultGreeting = "## 🌸 Your daily motivational waifu/catgirl 🌸\n*Starting your day with some kawaii energy!* 💕\n🎲 *Today's random selection!* 🎲"
+
+// greetingFor picks the greeting of the day. A greeting configured for the
+// exact date wins over one for the day of the year, which wins over the
+// rotation. The choice only depends on the calendar date, so every
+// destination gets the same greeting that day.
+func (dw *DailyWebhook) greetingFor(date time.Time) string {
+	if greeting, ok := dw.occasions[date.Format(time.DateOnly)]; ok {
+		return greeting
+	}
+	if greeting, ok := dw.occasions[date.Format("01-02")]; ok {
+		return greeting
+	}
+	if len(dw.greetings) == 0 {
+		return defaultGreeting
+	}
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC).Unix() / (24 * 60 * 60)
+	return dw.greetings[day%int64(len(dw.greetings))]
+}
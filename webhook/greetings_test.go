@@ -0,0 +1,50 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGreetingForOccasions(t *testing.T) {
+	dw := &DailyWebhook{
+		greetings: []string{"rotation"},
+		occasions: map[string]string{
+			"01-01":      "new year",
+			"06-01":      "anniversary",
+			"2026-06-01": "fifth anniversary",
+		},
+	}
+
+	tests := []struct {
+		name string
+		date time.Time
+		want string
+	}{
+		{"day of the year", time.Date(2027, time.January, 1, 9, 0, 0, 0, time.UTC), "new year"},
+		{"exact date wins", time.Date(2026, time.June, 1, 9, 0, 0, 0, time.UTC), "fifth anniversary"},
+		{"exact date in another year", time.Date(2027, time.June, 1, 9, 0, 0, 0, time.UTC), "anniversary"},
+		{"no occasion", time.Date(2026, time.June, 2, 9, 0, 0, 0, time.UTC), "rotation"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dw.greetingFor(tt.date); got != tt.want {
+				t.Errorf("greetingFor(%s) = %q, want %q", tt.date.Format(time.DateOnly), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGreetingForUsesTheDateOfItsTimezone(t *testing.T) {
+	dw := &DailyWebhook{occasions: map[string]string{"01-01": "new year"}}
+	tokyo := time.FixedZone("JST", 9*60*60)
+
+	// Still New Year's Eve in UTC, but already New Year's Day in Tokyo
+	instant := time.Date(2026, time.December, 31, 20, 0, 0, 0, time.UTC)
+	if got := dw.greetingFor(instant.In(tokyo)); got != "new year" {
+		t.Errorf("greetingFor in Tokyo = %q, want the New Year's greeting", got)
+	}
+	if got := dw.greetingFor(instant); got != defaultGreeting {
+		t.Errorf("greetingFor in UTC = %q, want the default greeting", got)
+	}
+}
@@ -0,0 +1,72 @@
+package webhook
+
+import "unicode/utf8"
+
+// Discord limits for a whole message
+const (
+	maxEmbedsPerMessage = 10
+	maxEmbedTotalChars  = 6000 // across the titles, descriptions and fields of all embeds
+)
+
+// embedChars counts the characters of an embed that Discord charges against
+// maxEmbedTotalChars
+func embedChars(e WebhookEmbed) int {
+	n := utf8.RuneCountInString(e.Title) + utf8.RuneCountInString(e.Description)
+	for _, f := range e.Fields {
+		n += utf8.RuneCountInString(f.Name) + utf8.RuneCountInString(f.Value)
+	}
+	return n
+}
+
+// fitEmbed trims an embed that exceeds the character budget on its own. The
+// description is shortened first, then fields are dropped from the end.
+func fitEmbed(e WebhookEmbed) WebhookEmbed {
+	over := embedChars(e) - maxEmbedTotalChars
+	if over <= 0 {
+		return e
+	}
+
+	if desc := []rune(e.Description); len(desc) > 0 {
+		e.Description = string(desc[:max(len(desc)-over-1, 0)]) + "…"
+		over = embedChars(e) - maxEmbedTotalChars
+	}
+	for over > 0 && len(e.Fields) > 0 {
+		e.Fields = e.Fields[:len(e.Fields)-1]
+		over = embedChars(e) - maxEmbedTotalChars
+	}
+	return e
+}
+
+// splitPayload spreads the embeds of a payload over as many messages as
+// needed to stay within the per-message embed count and character budget.
+// The content and its allowed mentions go with the first message.
+func splitPayload(payload WebhookPayload) []WebhookPayload {
+	var parts []WebhookPayload
+	current := WebhookPayload{Content: payload.Content, AllowedMentions: payload.AllowedMentions}
+	chars := 0
+
+	for _, embed := range payload.Embeds {
+		embed = fitEmbed(embed)
+		n := embedChars(embed)
+		if len(current.Embeds) > 0 && (len(current.Embeds) == maxEmbedsPerMessage || chars+n > maxEmbedTotalChars) {
+			parts = append(parts, current)
+			current, chars = WebhookPayload{}, 0
+		}
+		current.Embeds = append(current.Embeds, embed)
+		chars += n
+	}
+	return append(parts, current)
+}
+
+// sendParts sends the messages of a split payload in order, starting at part
+// from and stopping at the first failure. It returns the index of the first
+// part that didn't go out, len(parts) once all did, so a retry can resume
+// there instead of repeating the messages before it.
+func sendParts(parts []WebhookPayload, from int, send func(WebhookPayload) error) (int, error) {
+	for n := from; n < len(parts); n++ {
+		if err := send(parts[n]); err != nil {
+			return n, err
+		}
+	}
+	return len(parts), nil
+}
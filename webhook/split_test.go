@@ -0,0 +1,78 @@
+package webhook
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSplitPayload(t *testing.T) {
+	long := strings.Repeat("x", 2500)
+	mentions := &AllowedMentions{Parse: []string{}, Roles: []string{"123"}}
+
+	tests := []struct {
+		name   string
+		embeds []WebhookEmbed
+		parts  []int // embeds per message
+	}{
+		{"empty", nil, []int{0}},
+		{"fits", make([]WebhookEmbed, 3), []int{3}},
+		{"too many embeds", make([]WebhookEmbed, 12), []int{10, 2}},
+		{"over 6000 characters", []WebhookEmbed{{Description: long}, {Description: long}, {Description: long}}, []int{2, 1}},
+		{"one huge embed", []WebhookEmbed{{Description: strings.Repeat("x", 7000)}}, []int{1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts := splitPayload(WebhookPayload{Content: "hello", Embeds: tt.embeds, AllowedMentions: mentions})
+			if len(parts) != len(tt.parts) {
+				t.Fatalf("got %d messages, want %d", len(parts), len(tt.parts))
+			}
+
+			for n, part := range parts {
+				if len(part.Embeds) != tt.parts[n] {
+					t.Errorf("message %d has %d embeds, want %d", n, len(part.Embeds), tt.parts[n])
+				}
+				chars := 0
+				for _, e := range part.Embeds {
+					chars += embedChars(e)
+				}
+				if chars > maxEmbedTotalChars {
+					t.Errorf("message %d has %d embed characters, over the limit of %d", n, chars, maxEmbedTotalChars)
+				}
+
+				first := n == 0
+				if (part.Content != "") != first || (part.AllowedMentions != nil) != first {
+					t.Errorf("message %d: content %q, mentions %v; only the first message should carry them", n, part.Content, part.AllowedMentions)
+				}
+			}
+		})
+	}
+}
+
+func TestSendPartsResumes(t *testing.T) {
+	parts := []WebhookPayload{{Content: "1"}, {Content: "2"}, {Content: "3"}}
+	var sent []string
+	fail := true
+	send := func(part WebhookPayload) error {
+		if part.Content == "2" && fail {
+			return errors.New("boom")
+		}
+		sent = append(sent, part.Content)
+		return nil
+	}
+
+	next, err := sendParts(parts, 0, send)
+	if err == nil || next != 1 {
+		t.Fatalf("sendParts = %d, %v; want 1 and an error", next, err)
+	}
+
+	fail = false
+	next, err = sendParts(parts, next, send)
+	if err != nil || next != len(parts) {
+		t.Fatalf("resumed sendParts = %d, %v; want %d and no error", next, err, len(parts))
+	}
+	if got := strings.Join(sent, ","); got != "1,2,3" {
+		t.Errorf("sent %s, want every message exactly once", got)
+	}
+}
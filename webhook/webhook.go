@@ -3,52 +3,156 @@ package webhook
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
-	"os"
 	"regexp"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
 	"KawaiiBot/api"
+	"KawaiiBot/config"
 )
 
+// ErrNoImages is returned when neither provider produced an image for the daily webhook
+var ErrNoImages = errors.New("no images could be fetched for the daily webhook")
+
+// ErrNoWebhookURL is returned by CheckURL when WEBHOOK_URL is not set
+var ErrNoWebhookURL = errors.New("no webhook URL configured")
+
+// ErrUnknownWebhook is returned when Discord answers a post to the webhook
+// URL with 404, meaning the webhook was deleted
+var ErrUnknownWebhook = errors.New("unknown webhook, it was probably deleted on Discord")
+
+// noImagesText is posted instead of the daily pictures when WEBHOOK_EMPTY_NOTICE is set
+const noImagesText = "😿 Sorry, I couldn't fetch today's pictures. See you tomorrow!"
+
+// SentHook is called with each daily payload after it was delivered
+type SentHook func(payload WebhookPayload, sentAt time.Time)
+
+// ChannelSender posts a daily payload to a Discord channel through the bot
+// session. whole reports whether payload is the entire post rather than one
+// message of a post split over several.
+type ChannelSender func(channelID string, payload WebhookPayload, whole bool) error
+
 // DailyWebhook handles the daily webhook functionality
 type DailyWebhook struct {
-	webhookURL string
-	nekosAPI   *api.Client
-	waifuAPI   *api.WaifuClient
-	enabled    bool
-	mutex      sync.RWMutex
-	lastSent   time.Time
+	webhookURLs   []string
+	channelID     string
+	showTags      bool
+	allowedTags   map[string]bool // WEBHOOK_ALLOWED_TAGS, nil allows every tag
+	mentionRole   string          // role pinged by the daily post, if any
+	waifus        int             // waifu pictures per daily post, 0 skips waifu.im
+	catgirls      int             // catgirl pictures per daily post, 0 skips nekos.moe
+	workers       int             // destinations sent to at once
+	emptyNotice   bool
+	noGreeting    bool // leave the content out, posting the embeds only
+	greetings     []string
+	occasions     map[string]string // greetings for special dates, see greetingFor
+	location      *time.Location    // timezone the greeting's date is taken in
+	channelSender ChannelSender
+	sentHook      SentHook
+	nekosAPI      *api.Client
+	waifuAPI      *api.WaifuClient
+	enabled       bool
+	pending       *pendingDelivery // last daily payload, for RetryDailyWebhook
+	mutex         sync.RWMutex
+	lastSent      time.Time
 }
 
 // New creates a new DailyWebhook instance
-func New(nekosAPI *api.Client, waifuAPI *api.WaifuClient) *DailyWebhook {
-	webhookURL := os.Getenv("WEBHOOK_URL")
-
-	// Validate webhook URL format if provided
-	if webhookURL != "" && !isValidDiscordWebhookURL(webhookURL) {
-		log.Printf("[WEBHOOK] Warning: WEBHOOK_URL does not appear to be a valid Discord webhook URL: %s", webhookURL)
+func New(nekosAPI *api.Client, waifuAPI *api.WaifuClient, cfg config.Webhook) *DailyWebhook {
+	// Validate the webhook URL format of every entry
+	for _, url := range cfg.URLs {
+		if !isValidDiscordWebhookURL(url) {
+			slog.Warn("[WEBHOOK] WEBHOOK_URL does not appear to be a valid Discord webhook URL", "url", MaskURL(url))
+		}
 	}
 
 	dw := &DailyWebhook{
-		webhookURL: webhookURL,
-		nekosAPI:   nekosAPI,
-		waifuAPI:   waifuAPI,
-		enabled:    true,
+		webhookURLs: cfg.URLs,
+		channelID:   cfg.ChannelID,
+		showTags:    cfg.ShowTags,
+		mentionRole: cfg.MentionRole,
+		waifus:      cfg.Waifus,
+		catgirls:    cfg.Catgirls,
+		workers:     cfg.Workers,
+		emptyNotice: cfg.EmptyNotice,
+		noGreeting:  cfg.NoGreeting,
+		greetings:   cfg.Greetings,
+		occasions:   cfg.Occasions,
+		location:    time.Local,
+		nekosAPI:    nekosAPI,
+		waifuAPI:    waifuAPI,
+		enabled:     true,
+	}
+
+	if len(cfg.AllowedTags) > 0 {
+		dw.allowedTags = make(map[string]bool, len(cfg.AllowedTags))
+		for _, tag := range cfg.AllowedTags {
+			dw.allowedTags[tag] = true
+		}
 	}
 
 	return dw
 }
 
+// SetLocation sets the timezone the date of the greeting is taken in
+func (dw *DailyWebhook) SetLocation(loc *time.Location) {
+	dw.mutex.Lock()
+	defer dw.mutex.Unlock()
+	dw.location = loc
+}
+
+// SetSentHook sets the function told about every delivered daily payload
+func (dw *DailyWebhook) SetSentHook(hook SentHook) {
+	dw.mutex.Lock()
+	defer dw.mutex.Unlock()
+	dw.sentHook = hook
+}
+
+// SetChannelSender sets the function used to post to DAILY_CHANNEL_ID
+func (dw *DailyWebhook) SetChannelSender(sender ChannelSender) {
+	dw.mutex.Lock()
+	defer dw.mutex.Unlock()
+	dw.channelSender = sender
+}
+
 // IsEnabled returns whether the daily webhook is enabled
 func (dw *DailyWebhook) IsEnabled() bool {
 	dw.mutex.RLock()
 	defer dw.mutex.RUnlock()
-	return dw.enabled && dw.webhookURL != ""
+	return dw.enabled && dw.hasDestination()
+}
+
+// HasDestination returns whether a webhook URL or daily channel is configured
+func (dw *DailyWebhook) HasDestination() bool {
+	dw.mutex.RLock()
+	defer dw.mutex.RUnlock()
+	return dw.hasDestination()
+}
+
+// hasDestination must be called with the mutex held
+func (dw *DailyWebhook) hasDestination() bool {
+	return len(dw.webhookURLs) > 0 || dw.channelID != ""
+}
+
+// Counts returns how many waifu and catgirl pictures a daily post shows
+func (dw *DailyWebhook) Counts() (waifus, catgirls int) {
+	return dw.waifus, dw.catgirls
+}
+
+// GetChannelID returns the channel the bot posts the daily pictures to, if any
+func (dw *DailyWebhook) GetChannelID() string {
+	dw.mutex.RLock()
+	defer dw.mutex.RUnlock()
+	return dw.channelID
 }
 
 // SetEnabled sets the enabled status of the daily webhook
@@ -68,25 +172,82 @@ func (dw *DailyWebhook) Toggle() bool {
 	return dw.enabled
 }
 
-// GetStatus returns the current status of the daily webhook
-func (dw *DailyWebhook) GetStatus() (enabled bool, url string) {
+// GetStatus returns the current status of the daily webhook and the number
+// of webhook URLs it posts to
+func (dw *DailyWebhook) GetStatus() (enabled bool, urls int) {
+	dw.mutex.RLock()
+	defer dw.mutex.RUnlock()
+	return dw.enabled, len(dw.webhookURLs)
+}
+
+// WebhookURLs returns the webhook URLs the daily post is sent to
+func (dw *DailyWebhook) WebhookURLs() []string {
 	dw.mutex.RLock()
 	defer dw.mutex.RUnlock()
-	return dw.enabled, dw.webhookURL
+	return slices.Clone(dw.webhookURLs)
+}
+
+// dropURL stops posting to a webhook Discord reports as deleted
+func (dw *DailyWebhook) dropURL(url string) {
+	dw.mutex.Lock()
+	defer dw.mutex.Unlock()
+	dw.webhookURLs = slices.DeleteFunc(dw.webhookURLs, func(u string) bool { return u == url })
 }
 
 // WebhookPayload represents the JSON payload for Discord webhook
 type WebhookPayload struct {
-	Content string         `json:"content"`
-	Embeds  []WebhookEmbed `json:"embeds,omitempty"`
+	Content         string           `json:"content,omitempty"`
+	Embeds          []WebhookEmbed   `json:"embeds,omitempty"`
+	AllowedMentions *AllowedMentions `json:"allowed_mentions,omitempty"`
+}
+
+// AllowedMentions limits which mentions in the content ping anyone
+type AllowedMentions struct {
+	Parse []string `json:"parse"`
+	Roles []string `json:"roles,omitempty"`
 }
 
 // WebhookEmbed represents an embed in the webhook payload
 type WebhookEmbed struct {
-	Title       string `json:"title,omitempty"`
-	Description string `json:"description,omitempty"`
-	Image       *Image `json:"image,omitempty"`
-	Color       int    `json:"color,omitempty"`
+	Title       string       `json:"title,omitempty"`
+	URL         string       `json:"url,omitempty"` // makes the title a link
+	Description string       `json:"description,omitempty"`
+	Image       *Image       `json:"image,omitempty"`
+	Color       int          `json:"color,omitempty"`
+	Fields      []EmbedField `json:"fields,omitempty"`
+}
+
+// EmbedField represents a field in an embed
+type EmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline,omitempty"`
+}
+
+// Discord limits for embed fields
+const (
+	maxEmbedFields     = 25
+	maxFieldValueChars = 1024
+)
+
+// tagsField renders tag names as a comma separated embed field
+func tagsField(tags []string) EmbedField {
+	return EmbedField{Name: "Tags", Value: truncateField(strings.Join(tags, ", "))}
+}
+
+// truncateField shortens a value to the embed field value limit
+func truncateField(value string) string {
+	if runes := []rune(value); len(runes) > maxFieldValueChars {
+		value = string(runes[:maxFieldValueChars-1]) + "…"
+	}
+	return value
+}
+
+// addField appends a field to the embed unless it already has the maximum
+func (e *WebhookEmbed) addField(field EmbedField) {
+	if len(e.Fields) < maxEmbedFields && field.Value != "" {
+		e.Fields = append(e.Fields, field)
+	}
 }
 
 // Image represents an image in an embed
@@ -94,6 +255,14 @@ type Image struct {
 	URL string `json:"url"`
 }
 
+// pendingDelivery is a daily payload on its way to the destinations
+type pendingDelivery struct {
+	payload   WebhookPayload // as built, without the role mention
+	parts     []WebhookPayload
+	failed    map[string]int // first part each failed destination is missing, by key
+	announced bool           // the sent hook was told about the payload
+}
+
 // SendDailyWebhook sends the daily webhook with waifu and catgirl pictures
 func (dw *DailyWebhook) SendDailyWebhook() error {
 	if !dw.IsEnabled() {
@@ -101,48 +270,151 @@ func (dw *DailyWebhook) SendDailyWebhook() error {
 	}
 
 	log.Println("[WEBHOOK] Starting daily webhook send process...")
-	log.Printf("[WEBHOOK] Webhook URL: %s", dw.webhookURL)
+	for _, url := range dw.WebhookURLs() {
+		log.Printf("[WEBHOOK] Webhook URL: %s", MaskURL(url))
+	}
 
-	// Always get random content (mixed SFW/NSFW) by not specifying NSFW preference
-	log.Println("[WEBHOOK] Fetching random waifu image...")
+	dw.mutex.Lock()
+	dw.pending = nil
+	dw.mutex.Unlock()
 
-	// For waifu.im, we'll try passing false as a neutral option to get mixed results
-	waifuImages, err := dw.waifuAPI.GetWaifuImages(2, 1)
+	payload, err := dw.buildPayload()
 	if err != nil {
-		return fmt.Errorf("failed to fetch waifu image: %w", err)
+		return err
+	}
+
+	// Stay within Discord's embed limits per message
+	parts := splitPayload(dw.withMention(payload))
+	if len(parts) > 1 {
+		log.Printf("[WEBHOOK] Payload exceeds the embed limits of one message, sending it as %d messages", len(parts))
+	}
+	return dw.send(&pendingDelivery{payload: payload, parts: parts}, nil)
+}
+
+// RetryDailyWebhook resends the payload of the last SendDailyWebhook to the
+// destinations it failed for, starting at the first message each of them
+// is missing. If that send never built a payload it starts over.
+func (dw *DailyWebhook) RetryDailyWebhook() error {
+	if !dw.IsEnabled() {
+		return fmt.Errorf("daily webhook is disabled")
+	}
+
+	dw.mutex.RLock()
+	pending := dw.pending
+	dw.mutex.RUnlock()
+	if pending == nil {
+		return dw.SendDailyWebhook()
+	}
+
+	log.Printf("[WEBHOOK] Resending the daily payload to %d destination(s) it failed for...", len(pending.failed))
+	return dw.send(pending, pending.failed)
+}
+
+// Retryable reports whether RetryDailyWebhook has anything left to send after
+// a failed send: a destination that failed, or a payload that wasn't built
+func (dw *DailyWebhook) Retryable() bool {
+	dw.mutex.RLock()
+	defer dw.mutex.RUnlock()
+	return dw.pending == nil || len(dw.pending.failed) > 0
+}
+
+// send delivers a daily payload to the destinations in only, or to all of
+// them if only is nil, and remembers the ones that are still missing parts
+func (dw *DailyWebhook) send(d *pendingDelivery, only map[string]int) error {
+	sent, failed, err := dw.deliver(d.parts, only)
+
+	dw.mutex.Lock()
+	d.failed = failed
+	dw.pending = d
+	announce := sent && !d.announced
+	d.announced = d.announced || sent
+	now := time.Now()
+	if announce {
+		dw.lastSent = now
+	}
+	hook := dw.sentHook
+	dw.mutex.Unlock()
+
+	if announce && hook != nil {
+		hook(d.payload, now)
 	}
-	log.Printf("[WEBHOOK] Fetched %d waifu images", len(waifuImages))
+	return err
+}
 
-	// Validate waifu image URLs
-	if len(waifuImages) > 0 {
-		log.Printf("[WEBHOOK] Waifu image details: ID=%d, URL=%s, Extension=%s, NSFW=%t",
-			waifuImages[0].ID, waifuImages[0].URL, waifuImages[0].Extension, waifuImages[0].IsNSFW)
+// SendToChannel posts a fresh daily payload to a single channel through the
+// channel sender. It is used for guilds with their own daily schedule and
+// doesn't depend on the global webhook being enabled.
+func (dw *DailyWebhook) SendToChannel(channelID string) error {
+	dw.mutex.RLock()
+	sender := dw.channelSender
+	dw.mutex.RUnlock()
+	if sender == nil {
+		return fmt.Errorf("no channel sender configured for channel %s", channelID)
 	}
 
-	// Fetch one catgirl image - use empty rating to get random mixed content
-	log.Println("[WEBHOOK] Fetching random catgirl image...")
-	catgirlImages, err := dw.nekosAPI.GetRandomImages(1, "")
+	payload, err := dw.buildPayload()
 	if err != nil {
-		return fmt.Errorf("failed to fetch catgirl image: %w", err)
+		return err
+	}
+	parts := splitPayload(payload)
+	next, err := sendParts(parts, 0, func(part WebhookPayload) error {
+		return sender(channelID, part, len(parts) == 1)
+	})
+	if err != nil && next > 0 {
+		return fmt.Errorf("posted %d of %d messages to channel %s, the rest failed: %w", next, len(parts), channelID, err)
 	}
-	log.Printf("[WEBHOOK] Fetched %d catgirl images", len(catgirlImages))
+	if err != nil {
+		return fmt.Errorf("failed to post to channel %s: %w", channelID, err)
+	}
+	return nil
+}
+
+// Reroll builds a replacement for a single-message daily post. The result
+// fits in one message and shows none of the image URLs in exclude. It returns
+// ErrNoImages if no such payload could be built.
+func (dw *DailyWebhook) Reroll(exclude []string) (WebhookPayload, error) {
+	for attempt := 1; attempt <= maxImageAttempts; attempt++ {
+		payload, err := dw.buildPayload()
+		if err != nil {
+			return WebhookPayload{}, err
+		}
 
-	// Validate catgirl image URLs
-	if len(catgirlImages) > 0 {
-		catgirlURL := fmt.Sprintf("https://nekos.moe/image/%s.jpg", catgirlImages[0].ID)
-		log.Printf("[WEBHOOK] Catgirl image details: ID=%s, Constructed URL=%s, NSFW=%t",
-			catgirlImages[0].ID, catgirlURL, catgirlImages[0].NSFW)
+		// Keeping only the first message would drop pictures
+		if parts := splitPayload(payload); len(parts) > 1 {
+			log.Printf("[WEBHOOK] Rerolled daily pictures don't fit in one message (attempt %d/%d)", attempt, maxImageAttempts)
+			continue
+		}
+		repeats := slices.ContainsFunc(payload.Embeds, func(embed WebhookEmbed) bool {
+			return embed.Image != nil && slices.Contains(exclude, embed.Image.URL)
+		})
+		if !repeats {
+			return payload, nil
+		}
+		log.Printf("[WEBHOOK] Rerolled daily pictures repeat an image (attempt %d/%d)", attempt, maxImageAttempts)
 	}
+	return WebhookPayload{}, ErrNoImages
+}
+
+// buildPayload fetches today's pictures and builds the daily message. It
+// returns ErrNoImages if neither provider delivered a picture.
+func (dw *DailyWebhook) buildPayload() (WebhookPayload, error) {
+	// A failing provider only drops its embeds, the other one is still sent.
+	// A provider with a count of 0 isn't asked at all.
+	waifuImages := dw.fetchWaifu(dw.waifus)
+	catgirlImages := dw.fetchCatgirl(dw.catgirls)
 
 	// Build content with fallback URLs in case embeds fail
-	content := "## 🌸 Your daily motivational waifu/catgirl 🌸\n*Starting your day with some kawaii energy!* 💕\n🎲 *Today's random selection!* 🎲"
+	dw.mutex.RLock()
+	loc := dw.location
+	dw.mutex.RUnlock()
+	content := dw.greetingFor(time.Now().In(loc))
 
 	// Add direct URLs to content as fallback
 	//	if len(waifuImages) > 0 {
 	//		content += fmt.Sprintf("\n\n**💜 Daily Waifu:** %s", waifuImages[0].URL)
 	//	}
-	if len(catgirlImages) > 0 {
-		content += fmt.Sprintf("\n**🐱 Daily Catgirl:** https://nekos.moe/image/%s.jpg", catgirlImages[0].ID)
+	for _, img := range catgirlImages {
+		content += fmt.Sprintf("\n**🐱 Daily Catgirl:** https://nekos.moe/image/%s.jpg", img.ID)
 	}
 
 	// Create webhook payload with both embeds and fallback URLs
@@ -151,70 +423,261 @@ func (dw *DailyWebhook) SendDailyWebhook() error {
 		Embeds:  []WebhookEmbed{},
 	}
 
-	// Add waifu embed if we got an image
-	if len(waifuImages) > 0 {
+	// Add a waifu embed for every image we got
+	for _, img := range waifuImages {
 		waifuEmbed := WebhookEmbed{
 			Title:       "💜 Daily Waifu",
+			URL:         waifuPageURL(img),
 			Description: "Here's your beautiful waifu for today!",
-			Image:       &Image{URL: waifuImages[0].URL},
+			Image:       &Image{URL: img.URL},
 			Color:       0x9B59B6, // Purple color
 		}
+		waifuEmbed.addField(EmbedField{Name: "🎨 Artist", Value: truncateField(api.CreditArtists(img.Artists)), Inline: true})
+		if dw.showTags {
+			tags := make([]string, 0, len(img.Tags))
+			for _, tag := range img.Tags {
+				tags = append(tags, tag.Name)
+			}
+			waifuEmbed.addField(tagsField(tags))
+		}
 		payload.Embeds = append(payload.Embeds, waifuEmbed)
 	}
 
-	// Add catgirl embed if we got an image
-	if len(catgirlImages) > 0 {
+	// Add a catgirl embed for every image we got
+	for _, img := range catgirlImages {
 		catgirlEmbed := WebhookEmbed{
 			Title:       "🐱 Daily Catgirl",
+			URL:         fmt.Sprintf("https://nekos.moe/post/%s", img.ID),
 			Description: "And here's your adorable catgirl!",
-			Image:       &Image{URL: fmt.Sprintf("https://nekos.moe/image/%s.jpg", catgirlImages[0].ID)},
+			Image:       &Image{URL: fmt.Sprintf("https://nekos.moe/image/%s.jpg", img.ID)},
 			Color:       0xE91E63, // Pink color
 		}
+		if dw.showTags {
+			catgirlEmbed.addField(tagsField(img.Tags))
+		}
 		payload.Embeds = append(payload.Embeds, catgirlEmbed)
 	}
 
-	// Send webhook
-	log.Println("[WEBHOOK] Sending webhook payload...")
-	return dw.sendWebhook(payload)
+	// Never post the greeting on its own, nor an empty message without it
+	if len(payload.Embeds) == 0 {
+		return WebhookPayload{}, ErrNoImages
+	}
+	if dw.noGreeting {
+		payload.Content = ""
+	}
+	return payload, nil
+}
+
+// withMention prepends the DAILY_MENTION_ROLE_ID mention to the content and
+// allows exactly that role to be pinged
+func (dw *DailyWebhook) withMention(payload WebhookPayload) WebhookPayload {
+	if dw.mentionRole == "" {
+		return payload
+	}
+
+	payload.Content = strings.TrimSpace(fmt.Sprintf("<@&%s> %s", dw.mentionRole, payload.Content))
+	payload.AllowedMentions = &AllowedMentions{Parse: []string{}, Roles: []string{dw.mentionRole}}
+	return payload
 }
 
-// sendWebhook sends the actual webhook request
-func (dw *DailyWebhook) sendWebhook(payload WebhookPayload) error {
+// NotifyNoImages posts a short notice that today's pictures couldn't be
+// fetched. It does nothing unless WEBHOOK_EMPTY_NOTICE is set.
+func (dw *DailyWebhook) NotifyNoImages() error {
+	if !dw.emptyNotice {
+		return nil
+	}
+
+	log.Println("[WEBHOOK] Posting notice that no images could be fetched...")
+	_, _, err := dw.deliver(splitPayload(WebhookPayload{Content: noImagesText}), nil)
+	return err
+}
+
+// waifuPageURL links a waifu image to its source, or to the image itself if
+// the source is unknown
+func waifuPageURL(img api.WaifuImage) string {
+	if strings.HasPrefix(img.Source, "http://") || strings.HasPrefix(img.Source, "https://") {
+		return img.Source
+	}
+	return img.URL
+}
+
+// deliver sends the parts of a payload to every configured destination, or
+// only to those in only, resuming each at its part there. A failing
+// destination doesn't stop the others; their errors are combined. See fanOut
+// for sent and failed.
+func (dw *DailyWebhook) deliver(parts []WebhookPayload, only map[string]int) (sent bool, failed map[string]int, err error) {
+	dw.mutex.RLock()
+	webhookURLs, channelID, sender := slices.Clone(dw.webhookURLs), dw.channelID, dw.channelSender
+	dw.mutex.RUnlock()
+
+	if only != nil {
+		webhookURLs = slices.DeleteFunc(webhookURLs, func(url string) bool {
+			_, ok := only[url]
+			return !ok
+		})
+		if _, ok := only[channelKey(channelID)]; !ok {
+			channelID = ""
+		}
+	}
+
+	var destinations []destination
+	var errs []error
+	if len(webhookURLs) > 0 {
+		log.Printf("[WEBHOOK] Sending webhook payload to %d webhook(s)...", len(webhookURLs))
+	}
+	for n, url := range webhookURLs {
+		name := "webhook"
+		if len(webhookURLs) > 1 {
+			name = fmt.Sprintf("webhook %d", n+1)
+		}
+		destinations = append(destinations, destination{
+			key:  url,
+			name: name,
+			send: func(part WebhookPayload) error {
+				err := sendWebhook(url, part)
+				if errors.Is(err, ErrUnknownWebhook) {
+					slog.Warn("[WEBHOOK] Webhook was deleted on Discord, skipping it from now on", "url", MaskURL(url))
+					dw.dropURL(url)
+				}
+				if err != nil && len(webhookURLs) > 1 {
+					return fmt.Errorf("%s: %w", name, err)
+				}
+				return err
+			},
+		})
+	}
+	if channelID != "" {
+		log.Printf("[WEBHOOK] Posting daily pictures to channel %s...", channelID)
+		if sender == nil {
+			errs = append(errs, fmt.Errorf("no channel sender configured for channel %s", channelID))
+		} else {
+			destinations = append(destinations, destination{
+				key:  channelKey(channelID),
+				name: "channel " + channelID,
+				send: func(part WebhookPayload) error {
+					if err := sender(channelID, part, len(parts) == 1); err != nil {
+						return fmt.Errorf("failed to post to channel %s: %w", channelID, err)
+					}
+					return nil
+				},
+			})
+		}
+	}
+
+	sent, failed, err = fanOut(dw.workers, destinations, parts, only)
+	return sent, failed, errors.Join(append(errs, err)...)
+}
+
+// channelKey is the destination key of DAILY_CHANNEL_ID
+func channelKey(channelID string) string {
+	return "channel:" + channelID
+}
+
+// sendWebhook sends the actual webhook request to one webhook URL
+func sendWebhook(url string, payload WebhookPayload) error {
+	status, err := postWebhook(url, payload)
+	if status == http.StatusNotFound {
+		return fmt.Errorf("%w: %w", ErrUnknownWebhook, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Println("[WEBHOOK] Daily webhook sent successfully!")
+	return nil
+}
+
+// CheckURL fetches every configured webhook without posting to it, reporting
+// whether Discord still knows them. It returns ErrNoWebhookURL if no URL is set.
+func (dw *DailyWebhook) CheckURL(ctx context.Context) error {
+	urls := dw.WebhookURLs()
+	if len(urls) == 0 {
+		return ErrNoWebhookURL
+	}
+
+	var errs []error
+	for n, url := range urls {
+		if err := checkURL(ctx, url); err != nil {
+			if len(urls) > 1 {
+				err = fmt.Errorf("webhook %d: %w", n+1, err)
+			}
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkURL fetches one webhook URL
+func checkURL(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook unreachable: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// postWebhook posts a payload to a webhook URL and returns the HTTP status.
+// A non-2xx status is reported as an error.
+func postWebhook(url string, payload WebhookPayload) (int, error) {
 	jsonData, err := json.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+		return 0, fmt.Errorf("failed to marshal webhook payload: %w", err)
 	}
 
-	log.Printf("[WEBHOOK] Creating HTTP request to: %s", dw.webhookURL)
-	log.Printf("[WEBHOOK] Payload size: %d bytes", len(jsonData))
+	slog.Debug("[WEBHOOK] Creating HTTP request", "url", MaskURL(url))
+	slog.Debug("[WEBHOOK] Payload size", "bytes", len(jsonData))
 
-	req, err := http.NewRequest("POST", dw.webhookURL, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return fmt.Errorf("failed to create webhook request: %w", err)
+		return 0, fmt.Errorf("failed to create webhook request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 
 	client := &http.Client{Timeout: 30 * time.Second}
-	log.Println("[WEBHOOK] Sending HTTP request...")
+	slog.Debug("[WEBHOOK] Sending HTTP request...")
 	resp, err := client.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to send webhook: %w", err)
+		return 0, fmt.Errorf("failed to send webhook: %w", err)
 	}
 	defer resp.Body.Close()
 
-	log.Printf("[WEBHOOK] Webhook response status: %d", resp.StatusCode)
+	slog.Debug("[WEBHOOK] Webhook response", "status", resp.StatusCode)
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("webhook returned status code %d", resp.StatusCode)
+		return resp.StatusCode, fmt.Errorf("webhook returned status code %d", resp.StatusCode)
 	}
+	return resp.StatusCode, nil
+}
 
-	dw.mutex.Lock()
-	dw.lastSent = time.Now()
-	dw.mutex.Unlock()
+// Notify posts a plain text message to a webhook URL
+func Notify(url, content string) error {
+	_, err := postWebhook(url, WebhookPayload{Content: content})
+	return err
+}
 
-	log.Println("[WEBHOOK] Daily webhook sent successfully!")
-	return nil
+// ErrInvalidWebhookURL is returned by Ping for URLs that aren't Discord webhooks
+var ErrInvalidWebhookURL = errors.New("not a valid Discord webhook URL")
+
+// pingText is the test message sent by Ping
+const pingText = "✅ KawaiiBot can reach this webhook"
+
+// Ping sends a single test message to a webhook URL without saving it and
+// returns the HTTP status of the response.
+func Ping(url string) (int, error) {
+	if !isValidDiscordWebhookURL(url) {
+		return 0, ErrInvalidWebhookURL
+	}
+	return postWebhook(url, WebhookPayload{Content: pingText})
 }
 
 // GetLastSent returns the last time a daily webhook was sent
@@ -224,6 +687,22 @@ func (dw *DailyWebhook) GetLastSent() time.Time {
 	return dw.lastSent
 }
 
+// MaskURL hides the secret token of a webhook URL, keeping the webhook ID visible
+func MaskURL(url string) string {
+	if url == "" {
+		return ""
+	}
+	idx := strings.LastIndex(url, "/")
+	if idx < 0 || idx == len(url)-1 {
+		return "****"
+	}
+	token := url[idx+1:]
+	if len(token) <= 4 {
+		return url[:idx+1] + "****"
+	}
+	return url[:idx+1] + token[:4] + "****"
+}
+
 // isValidDiscordWebhookURL checks if the URL appears to be a valid Discord webhook URL
 func isValidDiscordWebhookURL(url string) bool {
 	// Discord webhook URLs should match this pattern:
@@ -232,7 +711,7 @@ func isValidDiscordWebhookURL(url string) bool {
 	pattern := `^https://(?:discord\.com|discordapp\.com)/api/webhooks/\d+/[a-zA-Z0-9_-]+$`
 	matched, err := regexp.MatchString(pattern, url)
 	if err != nil {
-		log.Printf("[WEBHOOK] Error validating webhook URL: %v", err)
+		slog.Warn("[WEBHOOK] Error validating webhook URL", "error", err)
 		return false
 	}
 	return matched
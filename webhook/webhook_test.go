@@ -0,0 +1,133 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"KawaiiBot/api"
+	"KawaiiBot/config"
+)
+
+// stubAPI routes every outgoing request of the default transport, which the
+// API clients and webhook posts use, to handler for the rest of the test
+func stubAPI(t *testing.T, handler http.HandlerFunc) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	target, _ := url.Parse(server.URL)
+
+	orig := http.DefaultTransport
+	http.DefaultTransport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		req = req.Clone(req.Context())
+		req.URL.Scheme, req.URL.Host = target.Scheme, target.Host
+		return orig.RoundTrip(req)
+	})
+	t.Cleanup(func() {
+		http.DefaultTransport = orig
+		server.Close()
+	})
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestSendDailyWebhookWithoutImages(t *testing.T) {
+	var mutex sync.Mutex
+	var posts []WebhookPayload
+	stubAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/webhooks/1/hook" {
+			// Both image providers are down
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var payload WebhookPayload
+		json.NewDecoder(r.Body).Decode(&payload)
+		mutex.Lock()
+		posts = append(posts, payload)
+		mutex.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	dw := New(api.New("test"), api.NewWaifuClient("test"), config.Webhook{
+		URLs:        []string{"https://discord.com/api/webhooks/1/hook"},
+		Waifus:      1,
+		Catgirls:    1,
+		Workers:     1,
+		EmptyNotice: true,
+	})
+
+	if err := dw.SendDailyWebhook(); !errors.Is(err, ErrNoImages) {
+		t.Fatalf("SendDailyWebhook = %v, want ErrNoImages so the scheduler retries", err)
+	}
+	if len(posts) != 0 {
+		t.Fatalf("posted %+v without any images", posts)
+	}
+
+	if err := dw.NotifyNoImages(); err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 1 || posts[0].Content != noImagesText || len(posts[0].Embeds) != 0 {
+		t.Errorf("posted %+v, want just the no-images notice", posts)
+	}
+}
+
+func TestBuildPayloadSkipsZeroCounts(t *testing.T) {
+	tests := []struct {
+		name                     string
+		waifus, catgirls         int
+		waifuCalls, catgirlCalls int32
+	}{
+		{"waifus only", 2, 0, 1, 0},
+		{"catgirls only", 0, 3, 0, 1},
+		{"both", 1, 1, 1, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var waifuCalls, catgirlCalls atomic.Int32
+			stubAPI(t, func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.URL.Path == "/images":
+					waifuCalls.Add(1)
+					var page api.WaifuResponse
+					for id := range int64(tt.waifus) {
+						page.Items = append(page.Items, api.WaifuImage{ID: id, URL: fmt.Sprintf("https://cdn.waifu.im/%d.jpg", id)})
+					}
+					json.NewEncoder(w).Encode(page)
+				case strings.HasSuffix(r.URL.Path, "/random/image"):
+					catgirlCalls.Add(1)
+					var page api.RandomImageResponse
+					for id := range tt.catgirls {
+						page.Images = append(page.Images, api.Image{ID: fmt.Sprint(id)})
+					}
+					json.NewEncoder(w).Encode(page)
+				default:
+					w.WriteHeader(http.StatusOK)
+				}
+			})
+
+			dw := New(api.New("test"), api.NewWaifuClient("test"), config.Webhook{Waifus: tt.waifus, Catgirls: tt.catgirls})
+			payload, err := dw.buildPayload()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := len(payload.Embeds); got != tt.waifus+tt.catgirls {
+				t.Errorf("%d embeds, want %d", got, tt.waifus+tt.catgirls)
+			}
+			if got := waifuCalls.Load(); got != tt.waifuCalls {
+				t.Errorf("asked waifu.im %d times, want %d", got, tt.waifuCalls)
+			}
+			if got := catgirlCalls.Load(); got != tt.catgirlCalls {
+				t.Errorf("asked nekos.moe %d times, want %d", got, tt.catgirlCalls)
+			}
+		})
+	}
+}